@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/encrypt"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/tokens"
+)
+
+const usage = `Usage: chathub [command]
+
+Without a command, chathub serves MCP tools over the configured transport.
+
+Commands:
+  sync          Sync conversations with another backend (chathub sync -h)
+  export        Write all conversations as Markdown files (chathub export -h)
+  migrate       Move conversations to a new path layout (chathub migrate -h)
+  lint          Validate conversation frontmatter (chathub lint -h)
+  dedupe        Find and merge duplicate conversations (chathub dedupe -h)
+  stats         Show conversation counts by source, model, tag and month (chathub stats -h)
+  schema        Print the frontmatter JSON Schema
+  keygen        Print a new encryption key for CHATHUB_ENCRYPTION_KEYS
+  rotate-keys   Re-encrypt stored conversations with the primary key
+  help          Show this help
+`
+
+// runCommand runs a CLI subcommand.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "sync":
+		return runSync(args)
+	case "export":
+		return runExport(args)
+	case "migrate":
+		return runMigrate(args)
+	case "lint":
+		return runLint(args)
+	case "dedupe":
+		return runDedupe(args)
+	case "stats":
+		return runStats(args)
+	case "schema":
+		return runSchema()
+	case "keygen":
+		return runKeygen()
+	case "rotate-keys":
+		return runRotateKeys()
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command: %s", name)
+	}
+}
+
+// openStorage opens the configured storage backend for a CLI command.
+// Commands write straight to the backend and fail if it is unavailable:
+// the outbox is only replayed while the server runs, so a command's
+// queued writes would sit there unnoticed.
+func openStorage(cfg *config.Config) (*storage.Storage, error) {
+	return openStorageWith(cfg, false)
+}
+
+// openServerStorage opens the configured storage backend for the server,
+// with the outbox if one is configured.
+func openServerStorage(cfg *config.Config) (*storage.Storage, error) {
+	return openStorageWith(cfg, true)
+}
+
+// openStorageWith opens the configured storage backend with all storage
+// options applied, and the outbox if outbox is set.
+func openStorageWith(cfg *config.Config, outbox bool) (*storage.Storage, error) {
+	// Sources determine paths, so register them before anything is saved
+	for _, src := range cfg.Sources {
+		err := frontmatter.RegisterSource(frontmatter.SourceInfo{
+			ID:           src.ID,
+			Name:         src.Name,
+			Author:       src.Author,
+			Folder:       src.Folder,
+			Participants: src.Participants,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_SOURCES: %w", err)
+		}
+	}
+
+	slugMode, err := frontmatter.ParseSlugMode(cfg.SlugMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHATHUB_SLUG_MODE: %w", err)
+	}
+	frontmatter.SetSlugMode(slugMode)
+
+	tokenizer, err := tokens.ParseTokenizer(cfg.Tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHATHUB_TOKENIZER: %w", err)
+	}
+	tokens.SetDefault(tokenizer)
+	prices := make(map[string]tokens.Price, len(cfg.ModelPrices))
+	for model, p := range cfg.ModelPrices {
+		prices[model] = tokens.Price{Input: p.Input, Output: p.Output}
+	}
+	if err := tokens.SetPrices(prices); err != nil {
+		return nil, fmt.Errorf("invalid CHATHUB_MODEL_PRICES: %w", err)
+	}
+
+	layout, err := frontmatter.ParseLayout(cfg.PathLayout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHATHUB_PATH_LAYOUT: %w", err)
+	}
+	format, err := frontmatter.ParseFormat(cfg.FrontmatterFormat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHATHUB_FRONTMATTER_FORMAT: %w", err)
+	}
+
+	opts := []storage.Option{
+		storage.WithLayout(layout),
+		storage.WithFrontmatterFormat(format),
+		storage.WithTrashRetention(cfg.TrashRetention),
+		storage.WithVersions(cfg.Versions),
+	}
+
+	if len(cfg.Replicas) > 0 {
+		replication := storage.ReplicationConfig{
+			Quorum:       cfg.ReplicaQuorum,
+			MaxRetries:   cfg.ReplicaMaxRetries,
+			RetryBackoff: cfg.ReplicaRetryBackoff,
+		}
+		for _, r := range cfg.Replicas {
+			replication.Replicas = append(replication.Replicas, storage.BackendSpec{Name: r.Backend, Config: r.Config})
+		}
+		opts = append(opts, storage.WithReplication(replication))
+	}
+
+	if outbox && cfg.OutboxDir != "" {
+		opts = append(opts, storage.WithOutbox(storage.OutboxConfig{
+			Dir:          cfg.OutboxDir,
+			Target:       cfg.StoreID(),
+			RetryBackoff: cfg.OutboxRetryBackoff,
+		}))
+	}
+
+	if cfg.EncryptionMode != "" {
+		keyring, err := encrypt.LoadKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+		}
+		opts = append(opts, storage.WithEncryption(keyring, encrypt.Mode(cfg.EncryptionMode)))
+	}
+
+	store, err := storage.NewFromConfig(cfg.Backend, cfg.BackendConfig, cfg.Folder, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+func runKeygen() error {
+	key, err := encrypt.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func runRotateKeys() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.EncryptionMode == "" {
+		return fmt.Errorf("encryption is not enabled (set CHATHUB_ENCRYPTION)")
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	backend, ok := store.Backend().(*encrypt.Backend)
+	if !ok {
+		return fmt.Errorf("storage backend is not encrypted")
+	}
+
+	result, err := backend.Rotate(context.Background(), cfg.Folder)
+	if err != nil {
+		return fmt.Errorf("failed to rotate keys: %w", err)
+	}
+
+	fmt.Printf("Re-encrypted %d file(s), encrypted %d plaintext file(s), %d already current\n",
+		len(result.Rotated), len(result.Encrypted), result.Unchanged)
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+func runDedupe(args []string) error {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	merge := fs.Bool("merge", false, "keep the most complete copy of each group and move the others to the trash")
+	dryRun := fs.Bool("dry-run", false, "with -merge, show what would change without changing anything")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub dedupe [flags]")
+		fmt.Fprintln(fs.Output(), "\nReports groups of near-duplicate conversations, such as the same chat saved")
+		fmt.Fprintln(fs.Output(), "from two clients. With -merge, the copy with the most text is kept and gets the")
+		fmt.Fprintln(fs.Output(), "tags, categories, participants and URLs of the others.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := storage.Dedupe(context.Background(), store, storage.DedupeOptions{Merge: *merge, DryRun: *dryRun})
+	if result != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			printDedupeResult(store, result, *merge)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("dedupe failed: %w", err)
+	}
+	return nil
+}
+
+func printDedupeResult(store *storage.Storage, result *storage.DedupeResult, merge bool) {
+	for _, g := range result.Groups {
+		fmt.Printf("keep     %s\n", store.RelPath(g.Keep))
+		for _, d := range g.Duplicates {
+			action := "dup     "
+			if d.Trashed || (merge && result.DryRun) {
+				action = "trash   "
+			}
+			fmt.Printf("%s %s (jaccard %.2f, simhash distance %d)\n", action, store.RelPath(d.Path), d.Jaccard, d.Distance)
+		}
+	}
+
+	for _, skip := range result.Skipped {
+		fmt.Printf("skip     %s (%s)\n", store.RelPath(skip.Path), skip.Reason)
+	}
+
+	prefix := ""
+	if result.DryRun {
+		prefix = "[dry run] "
+	}
+	fmt.Printf("%s%d checked, %d group(s) of duplicates, %d trashed\n", prefix, result.Checked, len(result.Groups), result.Trashed)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := fs.String("dir", "", "directory to write Markdown files to (required)")
+	dryRun := fs.Bool("dry-run", false, "show what would be written without writing")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub export -dir <directory> [flags]")
+		fmt.Fprintln(fs.Output(), "\nWrites every conversation as Hugo Markdown under <directory>/CHATHUB_FOLDER.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return fmt.Errorf("missing -dir")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	target, err := storage.NewFromConfig(config.BackendFile, map[string]string{"root": *dir}, cfg.Folder)
+	if err != nil {
+		return fmt.Errorf("failed to open export directory: %w", err)
+	}
+	defer target.Close()
+
+	result, err := storage.Sync(context.Background(), store, target, storage.SyncOptions{
+		Conflict: storage.ConflictSource,
+		DryRun:   *dryRun,
+	})
+	if result != nil {
+		printSyncResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	errorsOnly := fs.Bool("errors-only", false, "do not report warnings")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub lint [flags] [path ...]")
+		fmt.Fprintln(fs.Output(), "\nValidates conversation frontmatter, by default across the whole store.")
+		fmt.Fprintln(fs.Output(), "Exits with an error if any errors are found.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := storage.Lint(context.Background(), store, storage.LintOptions{Paths: fs.Args()})
+	if err != nil {
+		return fmt.Errorf("lint failed: %w", err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printLintResult(result, *errorsOnly)
+	}
+
+	if result.Errors > 0 {
+		return fmt.Errorf("%d error(s) in %d conversation(s)", result.Errors, result.Checked)
+	}
+	return nil
+}
+
+func printLintResult(result *storage.LintResult, errorsOnly bool) {
+	for _, f := range result.Files {
+		for _, issue := range f.Issues {
+			if errorsOnly && issue.Severity != frontmatter.SeverityError {
+				continue
+			}
+			fmt.Printf("%s: %s\n", f.Path, issue)
+		}
+	}
+	fmt.Printf("%d checked, %d errors, %d warnings\n", result.Checked, result.Errors, result.Warnings)
+}
+
+func runSchema() error {
+	schema, err := frontmatter.JSONSchema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
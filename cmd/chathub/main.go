@@ -13,7 +13,9 @@ import (
 
 	"github.com/agentplexus/mcpkit/runtime"
 	"github.com/grokify/chathub/internal/config"
-	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/redact"
+	"github.com/grokify/chathub/internal/semantic"
+	"github.com/grokify/chathub/internal/summary"
 	"github.com/grokify/chathub/internal/tools"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -24,12 +26,19 @@ const (
 )
 
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run() error {
+func run(args []string) error {
+	if len(args) > 0 {
+		return runCommand(args[0], args[1:])
+	}
+	return serve()
+}
+
+func serve() error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -37,12 +46,28 @@ func run() error {
 	}
 
 	// Initialize storage backend
-	store, err := storage.NewFromConfig(cfg.Backend, cfg.BackendConfig, cfg.Folder)
+	store, err := openServerStorage(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+		return err
 	}
 	defer store.Close()
 
+	// Build the redaction pipeline
+	redactor, err := redact.NewFromConfig(redact.Mode(cfg.RedactMode), cfg.RedactDetectors, cfg.RedactPatterns)
+	if err != nil {
+		return fmt.Errorf("failed to configure redaction: %w", err)
+	}
+
+	summarizeMode, err := summary.ParseMode(cfg.SummarizeMode)
+	if err != nil {
+		return fmt.Errorf("invalid CHATHUB_SUMMARIZE: %w", err)
+	}
+
+	embedder, err := semantic.NewEmbedder(cfg.Embeddings, cfg.EmbeddingsURL, cfg.EmbeddingsModel, cfg.EmbeddingsAPIKey, cfg.EmbeddingsConcepts)
+	if err != nil {
+		return fmt.Errorf("invalid CHATHUB_EMBEDDINGS: %w", err)
+	}
+
 	// Create MCP runtime
 	rt := runtime.New(&mcp.Implementation{
 		Name:    appName,
@@ -50,12 +75,24 @@ func run() error {
 	}, nil)
 
 	// Register tools
-	tools.RegisterAll(rt, store)
+	tools.RegisterAll(rt, store, tools.Options{
+		ConfirmPolicy: cfg.ConfirmPolicy,
+		Redactor:      redactor,
+		Summarize:     summarizeMode,
+		Semantic:      semantic.NewIndex(embedder, cfg.IndexDir, cfg.StoreID()),
+	})
 
 	// Set up context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Purge trashed conversations past their retention period
+	if purged, err := store.PurgeExpiredTrash(ctx); err != nil {
+		log.Printf("failed to purge expired trash: %v", err)
+	} else if len(purged) > 0 {
+		log.Printf("Purged %d expired conversation(s) from trash", len(purged))
+	}
+
 	// Handle signals for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	layoutFlag := fs.String("layout", "", "target path layout (default CHATHUB_PATH_LAYOUT)")
+	dryRun := fs.Bool("dry-run", false, "show what would change without changing anything")
+	noAliases := fs.Bool("no-aliases", false, "do not add the old URL of moved files to their aliases")
+	batchSize := fs.Int("batch-size", storage.DefaultMigrateBatchSize, "files moved per commit")
+	restart := fs.Bool("restart", false, "ignore progress saved by an interrupted migration")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub migrate [flags]")
+		fmt.Fprintln(fs.Output(), "\nMoves conversations to the paths given by a layout and upgrades their frontmatter.")
+		fmt.Fprintln(fs.Output(), "An interrupted migration resumes where it left off when run again.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	target := cfg.PathLayout
+	if *layoutFlag != "" {
+		target = *layoutFlag
+	}
+	layout, err := frontmatter.ParseLayout(target)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := storage.Migrate(context.Background(), store, storage.MigrateOptions{
+		Layout:    layout,
+		Aliases:   !*noAliases,
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+		Restart:   *restart,
+	})
+	if result != nil {
+		printMigrateResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	if !*dryRun && layout.String() != store.Layout().String() {
+		fmt.Printf("Set CHATHUB_PATH_LAYOUT=%q so new conversations use the same layout.\n", layout.String())
+	}
+	return nil
+}
+
+func printMigrateResult(result *storage.MigrateResult) {
+	for _, c := range result.Changes {
+		if c.Moved() {
+			fmt.Printf("move     %s -> %s\n", c.From, c.To)
+		} else {
+			fmt.Printf("upgrade  %s\n", c.From)
+		}
+		if len(c.Upgraded) > 0 {
+			fmt.Printf("         + %s\n", strings.Join(c.Upgraded, ", "))
+		}
+		if c.Alias != "" {
+			fmt.Printf("         + alias %s\n", c.Alias)
+		}
+	}
+	for _, s := range result.Skipped {
+		fmt.Printf("skip     %s (%s)\n", s.Path, s.Reason)
+	}
+
+	prefix := ""
+	if result.DryRun {
+		prefix = "[dry run] "
+	}
+	fmt.Printf("%s%d moved, %d upgraded, %d skipped, %d unchanged",
+		prefix, result.Moved(), result.Upgraded(), len(result.Skipped), result.Unchanged)
+	if result.Resumed > 0 {
+		fmt.Printf(", %d done by an earlier run", result.Resumed)
+	}
+	fmt.Println()
+}
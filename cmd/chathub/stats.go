@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	source := fs.String("source", "", "only count conversations from this source")
+	tags := fs.Int("tags", 10, "number of top tags to show (0 for all)")
+	jsonOut := fs.Bool("json", false, "print the statistics as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub stats [flags]")
+		fmt.Fprintln(fs.Output(), "\nCounts conversations by source, model, tag, category and month, with message")
+		fmt.Fprintln(fs.Output(), "and token totals. The SQLite backend answers from its index; other backends")
+		fmt.Fprintln(fs.Output(), "read every conversation.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := storage.Stats(context.Background(), store, storage.StatsOptions{Source: *source, Tags: max(*tags, 0)})
+	if err != nil {
+		return fmt.Errorf("stats failed: %w", err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	printStatsResult(result)
+	return nil
+}
+
+func printStatsResult(result *storage.StatsResult) {
+	fmt.Printf("%d conversations, %d messages, %d tokens", result.Conversations, result.Messages, result.Tokens)
+	if result.Cost > 0 {
+		fmt.Printf(", $%.2f", result.Cost)
+	}
+	fmt.Println()
+	fmt.Printf("average %.1f messages, %.1f tokens per conversation\n", result.AverageMessages, result.AverageTokens)
+	if result.First != "" {
+		fmt.Printf("from %s to %s\n", result.First, result.Last)
+	}
+
+	tags := "Top tags"
+	if len(result.Tags) == result.TagCount {
+		tags = "Tags"
+	}
+	for _, section := range []struct {
+		title  string
+		counts []storage.StatsCount
+	}{
+		{"Sources", result.Sources},
+		{"Models", result.Models},
+		{fmt.Sprintf("%s (%d distinct)", tags, result.TagCount), result.Tags},
+		{"Categories", result.Categories},
+		{"Months", result.Months},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s\n", section.title)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range section.counts {
+			fmt.Fprintf(w, "  %s\t%d\t%d msgs\t%d tokens\n", c.Name, c.Conversations, c.Messages, c.Tokens)
+		}
+		w.Flush()
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Printf("\nSkipped %d unreadable conversation(s)\n", len(result.Skipped))
+		for _, skip := range result.Skipped {
+			fmt.Printf("  %s (%s)\n", skip.Path, skip.Error)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/encrypt"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// kvFlag collects repeated key=value flags.
+type kvFlag map[string]string
+
+func (f kvFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f kvFlag) Set(value string) error {
+	k, v, found := strings.Cut(value, "=")
+	if !found || k == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[k] = v
+	return nil
+}
+
+func runSync(args []string) error {
+	targetConfig := kvFlag{}
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	target := fs.String("to", "", "backend to sync with (github, s3, dropbox, file, memory)")
+	fs.Var(targetConfig, "to-config", "target backend `key=value` setting, repeatable; defaults come from the backend's environment variables")
+	targetFolder := fs.String("to-folder", "", "conversation folder on the target (default: CHATHUB_FOLDER)")
+	targetEncrypted := fs.Bool("to-encrypted", false, "encrypt the target with the configured encryption keys")
+	direction := fs.String("direction", "push", "push (configured -> target), pull (target -> configured), or both")
+	conflict := fs.String("conflict", string(storage.ConflictSkip), "conflict policy: skip, newer, source, or dest")
+	deleteFiles := fs.Bool("delete", false, "propagate deletions")
+	dryRun := fs.Bool("dry-run", false, "show what would change without writing")
+	stateName := fs.String("state", "", "name of the sync state kept in the source (default: target backend name)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chathub sync -to <backend> [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !config.ValidBackend(*target) {
+		return fmt.Errorf("invalid or missing -to backend: %q", *target)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Open the target with its environment config, overridden by flags
+	backendConfig := config.LoadBackendConfig(*target)
+	for k, v := range targetConfig {
+		backendConfig[k] = v
+	}
+	folder := *targetFolder
+	if folder == "" {
+		folder = cfg.Folder
+	}
+	var targetOpts []storage.Option
+	if *targetEncrypted {
+		keyring, err := encrypt.LoadKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load encryption keys: %w", err)
+		}
+		mode := encrypt.Mode(cfg.EncryptionMode)
+		if !encrypt.ValidMode(mode) {
+			mode = encrypt.ModeFull
+		}
+		targetOpts = append(targetOpts, storage.WithEncryption(keyring, mode))
+	}
+	targetStore, err := storage.NewFromConfig(*target, backendConfig, folder, targetOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to open target: %w", err)
+	}
+	defer targetStore.Close()
+
+	opts := storage.SyncOptions{
+		Direction: storage.SyncOneWay,
+		Conflict:  storage.ConflictPolicy(*conflict),
+		Delete:    *deleteFiles,
+		DryRun:    *dryRun,
+		StateName: *stateName,
+	}
+	if opts.StateName == "" {
+		opts.StateName = *target
+	}
+
+	src, dst := store, targetStore
+	switch *direction {
+	case "push":
+	case "pull":
+		src, dst = targetStore, store
+	case "both":
+		opts.Direction = storage.SyncBidirectional
+	default:
+		return fmt.Errorf("invalid direction: %s", *direction)
+	}
+
+	switch opts.Conflict {
+	case storage.ConflictSkip, storage.ConflictNewer, storage.ConflictSource, storage.ConflictDest:
+	default:
+		return fmt.Errorf("invalid conflict policy: %s", opts.Conflict)
+	}
+
+	result, err := storage.Sync(context.Background(), src, dst, opts)
+	if result != nil {
+		printSyncResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+	if n := result.Count(storage.SyncConflict); n > 0 {
+		fmt.Fprintf(os.Stderr, "%d conflict(s) left unresolved; rerun with -conflict to choose a side\n", n)
+	}
+	return nil
+}
+
+func printSyncResult(result *storage.SyncResult) {
+	for _, c := range result.Changes {
+		line := fmt.Sprintf("%-8s %-6s %s", c.Action, c.To, c.Path)
+		if c.Reason != "" {
+			line += " (" + c.Reason + ")"
+		}
+		fmt.Println(line)
+	}
+
+	prefix := ""
+	if result.DryRun {
+		prefix = "[dry run] "
+	}
+	fmt.Printf("%s%d created, %d updated, %d deleted, %d conflicts, %d unchanged\n",
+		prefix,
+		result.Count(storage.SyncCreate),
+		result.Count(storage.SyncUpdate),
+		result.Count(storage.SyncDelete),
+		result.Count(storage.SyncConflict),
+		result.Unchanged)
+}
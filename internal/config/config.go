@@ -2,10 +2,19 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Backend types
@@ -14,6 +23,7 @@ const (
 	BackendS3      = "s3"
 	BackendDropbox = "dropbox"
 	BackendFile    = "file"
+	BackendSQLite  = "sqlite"
 	BackendMemory  = "memory"
 )
 
@@ -23,6 +33,34 @@ const (
 	TransportHTTP  = "http"
 )
 
+// Confirmation policies for clients without elicitation support
+const (
+	ConfirmPolicyAllow = "allow"
+	ConfirmPolicyDeny  = "deny"
+)
+
+// ReplicaSpec configures one replica backend.
+type ReplicaSpec struct {
+	Backend string
+	Config  map[string]string
+}
+
+// SourceConfig adds or customizes a source platform. Empty fields default
+// to the ID.
+type SourceConfig struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name,omitempty"`
+	Author       string   `json:"author,omitempty"`
+	Folder       string   `json:"folder,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+}
+
+// ModelPrice is what a model charges, in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
 // Config holds the ChatHub configuration.
 type Config struct {
 	Backend           string
@@ -43,6 +81,80 @@ type Config struct {
 	OAuth2Password     string
 	OAuth2ClientID     string
 	OAuth2ClientSecret string
+
+	// PathLayout is the path template for new conversations, relative to
+	// Folder. Empty uses the default {source}/{date}_{slug}.md.
+	PathLayout string
+
+	// FrontmatterFormat is the frontmatter syntax for new conversations:
+	// yaml, toml or json. Existing files keep their format.
+	FrontmatterFormat string
+
+	// SlugMode is how slugs are generated from non-ASCII titles: ascii
+	// transliterates them, unicode keeps them.
+	SlugMode string
+
+	// Tokenizer counts the tokens of saved conversations: auto,
+	// cl100k_base, o200k_base or heuristic.
+	Tokenizer string
+
+	// ModelPrices add to or override the built-in model prices used for
+	// cost estimates, keyed by model name prefix.
+	ModelPrices map[string]ModelPrice
+
+	// TrashRetention is how long deleted conversations are kept in the
+	// trash before being purged. Zero disables automatic purging.
+	TrashRetention time.Duration
+
+	// Versions is how many previous versions of each conversation are kept
+	// when a tool overwrites it, for diff_conversations. Zero keeps none.
+	Versions int
+
+	// SummarizeMode decides how conversations saved without a description
+	// are summarized: auto, extractive or off.
+	SummarizeMode string
+
+	// Semantic search embeddings: hashing (built-in, offline) or openai
+	// (an OpenAI-compatible endpoint), and where vectors are cached. An
+	// empty IndexDir keeps them in memory, as does encryption, since the
+	// cached vectors reveal what conversations are about.
+	Embeddings       string
+	EmbeddingsURL    string
+	EmbeddingsModel  string
+	EmbeddingsAPIKey string
+	IndexDir         string
+
+	// EmbeddingsConcepts are groups of words the hashing embedder treats
+	// as related, such as ["auth", "login", "signin"].
+	EmbeddingsConcepts [][]string
+
+	// ConfirmPolicy decides whether destructive operations proceed when the
+	// client cannot be asked for confirmation via elicitation.
+	ConfirmPolicy string
+
+	// Redaction of secrets and personal data on save and append
+	RedactMode      string            // off, warn, redact, or block
+	RedactDetectors []string          // built-in detectors to enable; empty enables all
+	RedactPatterns  map[string]string // custom detector name to regex
+
+	// Client-side encryption at rest
+	EncryptionMode    string // "" (disabled), full, or body
+	EncryptionKeys    string // comma-separated [id:]base64 keys, primary first
+	EncryptionKeyFile string // file containing keys, used if EncryptionKeys is empty
+
+	// Write-through replication to additional backends
+	Replicas            []ReplicaSpec
+	ReplicaQuorum       int
+	ReplicaMaxRetries   int
+	ReplicaRetryBackoff time.Duration
+
+	// Local outbox for writes the backend cannot accept while offline or
+	// rate-limited. Empty disables it.
+	OutboxDir          string
+	OutboxRetryBackoff time.Duration
+
+	// Sources added to, or overriding, the built-in source platforms
+	Sources []SourceConfig
 }
 
 // Load loads configuration from environment variables.
@@ -52,24 +164,93 @@ func Load() (*Config, error) {
 	oauth2Enabled := getEnvBool("CHATHUB_OAUTH2", ngrokEnabled) // defaults to true when ngrok is enabled
 
 	cfg := &Config{
-		Backend:            getEnv("CHATHUB_BACKEND", BackendGitHub),
-		Folder:             getEnv("CHATHUB_FOLDER", "conversations"),
-		Transport:          getEnv("CHATHUB_TRANSPORT", TransportStdio),
-		Port:               getEnvInt("CHATHUB_PORT", 8080),
-		NgrokEnabled:       ngrokEnabled,
-		NgrokAuthtoken:     getEnv("CHATHUB_NGROK_AUTHTOKEN", ""),
-		NgrokDomain:        getEnv("CHATHUB_NGROK_DOMAIN", ""),
-		OAuthEnabled:       getEnvBool("CHATHUB_OAUTH", false), // legacy OAuth client_credentials
-		OAuthClientID:      getEnv("CHATHUB_OAUTH_CLIENT_ID", ""),
-		OAuthClientSecret:  getEnv("CHATHUB_OAUTH_CLIENT_SECRET", ""),
-		OAuth2Enabled:      oauth2Enabled,
-		OAuth2Username:     getEnv("CHATHUB_OAUTH2_USERNAME", "admin"),
-		OAuth2Password:     getEnv("CHATHUB_OAUTH2_PASSWORD", ""),
-		OAuth2ClientID:     getEnv("CHATHUB_OAUTH2_CLIENT_ID", ""),
-		OAuth2ClientSecret: getEnv("CHATHUB_OAUTH2_CLIENT_SECRET", ""),
-	}
-
-	cfg.BackendConfig = loadBackendConfig(cfg.Backend)
+		Backend:             getEnv("CHATHUB_BACKEND", BackendGitHub),
+		Folder:              getEnv("CHATHUB_FOLDER", "conversations"),
+		Transport:           getEnv("CHATHUB_TRANSPORT", TransportStdio),
+		Port:                getEnvInt("CHATHUB_PORT", 8080),
+		NgrokEnabled:        ngrokEnabled,
+		NgrokAuthtoken:      getEnv("CHATHUB_NGROK_AUTHTOKEN", ""),
+		NgrokDomain:         getEnv("CHATHUB_NGROK_DOMAIN", ""),
+		OAuthEnabled:        getEnvBool("CHATHUB_OAUTH", false), // legacy OAuth client_credentials
+		OAuthClientID:       getEnv("CHATHUB_OAUTH_CLIENT_ID", ""),
+		OAuthClientSecret:   getEnv("CHATHUB_OAUTH_CLIENT_SECRET", ""),
+		OAuth2Enabled:       oauth2Enabled,
+		OAuth2Username:      getEnv("CHATHUB_OAUTH2_USERNAME", "admin"),
+		OAuth2Password:      getEnv("CHATHUB_OAUTH2_PASSWORD", ""),
+		OAuth2ClientID:      getEnv("CHATHUB_OAUTH2_CLIENT_ID", ""),
+		OAuth2ClientSecret:  getEnv("CHATHUB_OAUTH2_CLIENT_SECRET", ""),
+		PathLayout:          getEnv("CHATHUB_PATH_LAYOUT", ""),
+		FrontmatterFormat:   getEnv("CHATHUB_FRONTMATTER_FORMAT", "yaml"),
+		SlugMode:            getEnv("CHATHUB_SLUG_MODE", "ascii"),
+		Tokenizer:           getEnv("CHATHUB_TOKENIZER", "auto"),
+		SummarizeMode:       getEnv("CHATHUB_SUMMARIZE", "auto"),
+		Embeddings:          getEnv("CHATHUB_EMBEDDINGS", "hashing"),
+		EmbeddingsURL:       getEnv("CHATHUB_EMBEDDINGS_URL", ""),
+		EmbeddingsModel:     getEnv("CHATHUB_EMBEDDINGS_MODEL", ""),
+		EmbeddingsAPIKey:    getEnv("CHATHUB_EMBEDDINGS_API_KEY", ""),
+		TrashRetention:      getEnvDuration("CHATHUB_TRASH_RETENTION", 30*24*time.Hour),
+		Versions:            getEnvInt("CHATHUB_VERSIONS", 10),
+		ConfirmPolicy:       getEnv("CHATHUB_CONFIRM_POLICY", ConfirmPolicyAllow),
+		RedactMode:          getEnv("CHATHUB_REDACT_MODE", "redact"),
+		RedactDetectors:     getEnvList("CHATHUB_REDACT_DETECTORS"),
+		EncryptionMode:      getEnv("CHATHUB_ENCRYPTION", ""),
+		EncryptionKeys:      getEnv("CHATHUB_ENCRYPTION_KEYS", ""),
+		EncryptionKeyFile:   getEnv("CHATHUB_ENCRYPTION_KEY_FILE", ""),
+		ReplicaQuorum:       getEnvInt("CHATHUB_REPLICA_QUORUM", 1),
+		ReplicaMaxRetries:   getEnvInt("CHATHUB_REPLICA_MAX_RETRIES", 5),
+		ReplicaRetryBackoff: getEnvDuration("CHATHUB_REPLICA_RETRY_BACKOFF", 2*time.Second),
+		OutboxRetryBackoff:  getEnvDuration("CHATHUB_OUTBOX_RETRY_BACKOFF", 5*time.Second),
+	}
+
+	// The index is not encrypted, so encrypted storage keeps it in memory
+	switch dir := os.Getenv("CHATHUB_INDEX_DIR"); {
+	case dir == "off", cfg.EncryptionMode != "":
+	case dir == "":
+		cfg.IndexDir = DefaultIndexDir()
+	default:
+		cfg.IndexDir = dir
+	}
+
+	replicas, err := ParseReplicas(os.Getenv("CHATHUB_REPLICAS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Replicas = replicas
+
+	if patterns := os.Getenv("CHATHUB_REDACT_PATTERNS"); patterns != "" {
+		if err := json.Unmarshal([]byte(patterns), &cfg.RedactPatterns); err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_REDACT_PATTERNS (expected JSON object of name to regex): %w", err)
+		}
+	}
+
+	if concepts := os.Getenv("CHATHUB_EMBEDDINGS_CONCEPTS"); concepts != "" {
+		if err := json.Unmarshal([]byte(concepts), &cfg.EmbeddingsConcepts); err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_EMBEDDINGS_CONCEPTS (expected JSON array of arrays of words): %w", err)
+		}
+	}
+
+	if prices := os.Getenv("CHATHUB_MODEL_PRICES"); prices != "" {
+		if err := json.Unmarshal([]byte(prices), &cfg.ModelPrices); err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_MODEL_PRICES (expected JSON object of model to {\"input\", \"output\"} prices): %w", err)
+		}
+	}
+
+	sources, err := loadSources(os.Getenv("CHATHUB_SOURCES_FILE"), os.Getenv("CHATHUB_SOURCES"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Sources = sources
+
+	cfg.BackendConfig = LoadBackendConfig(cfg.Backend)
+
+	// The outbox is on by default for GitHub, which is remote and rate-limited
+	switch dir := os.Getenv("CHATHUB_OUTBOX_DIR"); {
+	case dir == "off":
+	case dir != "":
+		cfg.OutboxDir = dir
+	case cfg.Backend == BackendGitHub:
+		cfg.OutboxDir = DefaultOutboxDir(cfg.StoreID())
+	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -78,12 +259,32 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// loadSources reads source definitions, as JSON arrays, from a file and
+// then from an inline value. Later definitions override earlier ones.
+func loadSources(file, inline string) ([]SourceConfig, error) {
+	var sources []SourceConfig
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CHATHUB_SOURCES_FILE: %w", err)
+		}
+		if err := json.Unmarshal(data, &sources); err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_SOURCES_FILE %s (expected JSON array of sources): %w", file, err)
+		}
+	}
+	if inline != "" {
+		var more []SourceConfig
+		if err := json.Unmarshal([]byte(inline), &more); err != nil {
+			return nil, fmt.Errorf("invalid CHATHUB_SOURCES (expected JSON array of sources): %w", err)
+		}
+		sources = append(sources, more...)
+	}
+	return sources, nil
+}
+
 // Validate validates the configuration.
 func (c *Config) Validate() error {
-	switch c.Backend {
-	case BackendGitHub, BackendS3, BackendDropbox, BackendFile, BackendMemory:
-		// valid
-	default:
+	if !ValidBackend(c.Backend) {
 		return fmt.Errorf("invalid backend: %s", c.Backend)
 	}
 
@@ -94,6 +295,36 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid transport: %s", c.Transport)
 	}
 
+	switch c.ConfirmPolicy {
+	case ConfirmPolicyAllow, ConfirmPolicyDeny:
+		// valid
+	default:
+		return fmt.Errorf("invalid confirm policy: %s", c.ConfirmPolicy)
+	}
+
+	switch c.EncryptionMode {
+	case "":
+		// disabled
+	case "full", "body":
+		if c.EncryptionKeys == "" && c.EncryptionKeyFile == "" {
+			return errors.New("CHATHUB_ENCRYPTION_KEYS or CHATHUB_ENCRYPTION_KEY_FILE is required when encryption is enabled")
+		}
+	default:
+		return fmt.Errorf("invalid encryption mode: %s", c.EncryptionMode)
+	}
+
+	if c.ReplicaQuorum < 1 || c.ReplicaQuorum > len(c.Replicas)+1 {
+		return fmt.Errorf("invalid replica quorum %d for %d backend(s)", c.ReplicaQuorum, len(c.Replicas)+1)
+	}
+
+	if c.TrashRetention < 0 {
+		return fmt.Errorf("invalid trash retention: %s", c.TrashRetention)
+	}
+
+	if c.Versions < 0 {
+		return fmt.Errorf("invalid versions: %d", c.Versions)
+	}
+
 	// Validate backend-specific config
 	switch c.Backend {
 	case BackendGitHub:
@@ -119,7 +350,84 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-func loadBackendConfig(backend string) map[string]string {
+// ValidBackend checks if a backend name is valid.
+func ValidBackend(backend string) bool {
+	switch backend {
+	case BackendGitHub, BackendS3, BackendDropbox, BackendFile, BackendSQLite, BackendMemory:
+		return true
+	default:
+		return false
+	}
+}
+
+// StoreID identifies where conversations are stored: the backend, its
+// settings other than credentials, and the folder.
+func (c *Config) StoreID() string {
+	var sb strings.Builder
+	sb.WriteString(c.Backend)
+	for _, key := range slices.Sorted(maps.Keys(c.BackendConfig)) {
+		if key != "token" {
+			fmt.Fprintf(&sb, " %s=%s", key, c.BackendConfig[key])
+		}
+	}
+	fmt.Fprintf(&sb, " folder=%s", c.Folder)
+	return sb.String()
+}
+
+// DefaultOutboxDir returns the default outbox location for the store
+// identified by storeID, in the user cache directory, or "" if it cannot be
+// determined. Each store gets its own directory, so writes queued for one
+// are never replayed into another.
+func DefaultOutboxDir(storeID string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(storeID))
+	return filepath.Join(dir, "chathub", "outbox", hex.EncodeToString(sum[:8]))
+}
+
+// DefaultIndexDir returns the default directory of the semantic search
+// index, in the user's cache directory.
+func DefaultIndexDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "chathub", "index")
+}
+
+// ParseReplicas parses a comma-separated list of replica backends, each
+// written as "backend" or "backend?key=value&key=value". Settings not given
+// inline come from the backend's environment variables.
+func ParseReplicas(spec string) ([]ReplicaSpec, error) {
+	var replicas []ReplicaSpec
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		backend, query, _ := strings.Cut(entry, "?")
+		if !ValidBackend(backend) {
+			return nil, fmt.Errorf("invalid replica backend: %s", backend)
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica settings for %s: %w", backend, err)
+		}
+
+		backendConfig := LoadBackendConfig(backend)
+		for k := range values {
+			backendConfig[k] = values.Get(k)
+		}
+		replicas = append(replicas, ReplicaSpec{Backend: backend, Config: backendConfig})
+	}
+	return replicas, nil
+}
+
+// LoadBackendConfig loads a backend's configuration from its environment variables.
+func LoadBackendConfig(backend string) map[string]string {
 	switch backend {
 	case BackendGitHub:
 		return map[string]string{
@@ -142,6 +450,10 @@ func loadBackendConfig(backend string) map[string]string {
 		return map[string]string{
 			"root": getEnv("FILE_ROOT", ""),
 		}
+	case BackendSQLite:
+		return map[string]string{
+			"path": getEnv("CHATHUB_SQLITE_PATH", "chathub.db"),
+		}
 	case BackendMemory:
 		return map[string]string{}
 	default:
@@ -156,6 +468,16 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if i, err := strconv.Atoi(value); err == nil {
@@ -173,3 +495,12 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
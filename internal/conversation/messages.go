@@ -0,0 +1,95 @@
+// Package conversation splits conversation bodies into messages.
+package conversation
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Message is one message of a conversation body.
+type Message struct {
+	// Speaker is the label before the message, e.g. "User" or "Claude".
+	// It is empty for text before the first message, such as a heading.
+	Speaker string
+
+	// Text is the message without the speaker label, trimmed.
+	Text string
+
+	// Start and End are the byte offsets of the message in the body,
+	// including the speaker label.
+	Start, End int
+}
+
+// IsUser reports whether the message was written by the user rather than
+// an assistant.
+func (m Message) IsUser() bool {
+	switch strings.ToLower(m.Speaker) {
+	case "user", "human", "you", "me", "prompt":
+		return true
+	}
+	return false
+}
+
+// speakerRegex matches a "**Speaker:**" or "**Speaker**:" label at the
+// start of a line.
+var speakerRegex = regexp.MustCompile(`^\*\*([^*\n:]{1,40})(?::\*\*|\*\*:)[ \t]*`)
+
+// Messages splits a body into messages, each starting with a bold speaker
+// label at the start of a line:
+//
+//	**User:** How do I build an MCP server in Go?
+//
+//	**ChatGPT:** To build an MCP server...
+//
+// Labels inside fenced code blocks are ignored. Non-blank text before the
+// first label is returned as a message without a speaker.
+func Messages(body []byte) []Message {
+	var messages []Message
+	current := Message{}
+	labelLen := 0
+	inFence := false
+
+	flush := func(end int) {
+		current.End = end
+		text := body[current.Start+labelLen : end]
+		current.Text = string(bytes.TrimSpace(text))
+		if current.Speaker != "" || current.Text != "" {
+			messages = append(messages, current)
+		}
+	}
+
+	for offset := 0; offset < len(body); {
+		line := body[offset:]
+		next := len(body)
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = offset + i + 1
+		}
+
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+		} else if !inFence {
+			if m := speakerRegex.FindSubmatch(line); m != nil {
+				flush(offset)
+				current = Message{Speaker: strings.TrimSpace(string(m[1])), Start: offset}
+				labelLen = len(m[0])
+			}
+		}
+		offset = next
+	}
+	flush(len(body))
+	return messages
+}
+
+// Count returns the number of messages with a speaker.
+func Count(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		if m.Speaker != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package conversation
+
+import (
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	body := []byte("# Building an MCP Server\n\n" +
+		"**User:** How do I build an MCP server in Go?\n\n" +
+		"**ChatGPT:** Use the SDK:\n\n" +
+		"```go\n**User:** not a message\n```\n\n" +
+		"**User**: Thanks!\n")
+
+	messages := Messages(body)
+	want := []struct{ speaker, text string }{
+		{"", "# Building an MCP Server"},
+		{"User", "How do I build an MCP server in Go?"},
+		{"ChatGPT", "Use the SDK:\n\n```go\n**User:** not a message\n```"},
+		{"User", "Thanks!"},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d: %+v", len(messages), len(want), messages)
+	}
+	for i, w := range want {
+		if messages[i].Speaker != w.speaker || messages[i].Text != w.text {
+			t.Errorf("message %d = %q %q, want %q %q", i, messages[i].Speaker, messages[i].Text, w.speaker, w.text)
+		}
+	}
+	if got := Count(messages); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+
+	// Offsets cover the body without gaps
+	end := 0
+	for _, m := range messages {
+		if m.Start != end {
+			t.Errorf("message %q starts at %d, want %d", m.Speaker, m.Start, end)
+		}
+		end = m.End
+	}
+	if end != len(body) {
+		t.Errorf("last message ends at %d, want %d", end, len(body))
+	}
+	if !messages[1].IsUser() || messages[2].IsUser() {
+		t.Error("IsUser() should be true for User only")
+	}
+}
+
+func TestMessagesWithoutLabels(t *testing.T) {
+	if got := Messages([]byte("Just some notes.\n")); len(got) != 1 || got[0].Speaker != "" {
+		t.Errorf("Messages() = %+v", got)
+	}
+	if got := Messages([]byte("  \n")); len(got) != 0 {
+		t.Errorf("Messages() of blank body = %+v", got)
+	}
+}
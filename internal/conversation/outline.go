@@ -0,0 +1,45 @@
+package conversation
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Headings returns the Markdown headings in text, outside code blocks,
+// with their leading #s.
+func Headings(text string) []string {
+	var headings []string
+	inFence := false
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			inFence = !inFence
+		case !inFence && isHeading(line):
+			headings = append(headings, line)
+		}
+	}
+	return headings
+}
+
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ')
+}
+
+// Preview returns the first non-blank line of text, shortened to at most
+// max characters.
+func Preview(text string, max int) string {
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > max {
+			runes := []rune(line)
+			return strings.TrimSpace(string(runes[:max])) + "..."
+		}
+		return line
+	}
+	return ""
+}
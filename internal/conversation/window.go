@@ -0,0 +1,105 @@
+package conversation
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"unicode/utf8"
+)
+
+// ErrBudgetTooSmall indicates that not even one character fits a budget.
+var ErrBudgetTooSmall = errors.New("budget is too small for any content")
+
+// Budget limits the size of a window. Zero limits are unlimited.
+type Budget struct {
+	MaxBytes  int
+	MaxTokens int
+
+	// Count returns the number of tokens in text. It is required when
+	// MaxTokens is set.
+	Count func(text string) int
+}
+
+func (b Budget) unlimited() bool {
+	return b.MaxBytes <= 0 && b.MaxTokens <= 0
+}
+
+// Fit returns where to cut body[start:end] so the part before the cut
+// stays within budget, and the tokens counted in it. It cuts after the
+// last message that fits; if the first message does not fit, after its
+// last line that fits, and if that line does not fit either, after its
+// last character that fits. Token counts are summed per message or line,
+// which may differ slightly from counting the whole window at once.
+func Fit(body []byte, messages []Message, start, end int, budget Budget) (cut, tokens int, err error) {
+	if budget.unlimited() {
+		if budget.Count != nil {
+			tokens = budget.Count(string(body[start:end]))
+		}
+		return end, tokens, nil
+	}
+
+	count := func(text []byte) int {
+		if budget.Count == nil {
+			return 0
+		}
+		return budget.Count(string(text))
+	}
+	fits := func(to, n int) bool {
+		return (budget.MaxBytes <= 0 || to-start <= budget.MaxBytes) &&
+			(budget.MaxTokens <= 0 || n <= budget.MaxTokens)
+	}
+
+	cut = start
+	for _, m := range messages {
+		from, to := max(m.Start, start), min(m.End, end)
+		if from >= to {
+			continue
+		}
+		if n := count(body[from:to]); fits(to, tokens+n) {
+			cut, tokens = to, tokens+n
+			continue
+		}
+		if cut > start {
+			return cut, tokens, nil
+		}
+
+		// The first message alone is over budget: cut it by lines
+		for from < to {
+			lineEnd := to
+			if i := bytes.IndexByte(body[from:to], '\n'); i >= 0 {
+				lineEnd = from + i + 1
+			}
+			if n := count(body[from:lineEnd]); fits(lineEnd, tokens+n) {
+				cut, tokens, from = lineEnd, tokens+n, lineEnd
+				continue
+			}
+			if cut == start {
+				cut, tokens = fitRunes(body, from, lineEnd, tokens, count, fits)
+			}
+			break
+		}
+		break
+	}
+	if cut == start && end > start {
+		return start, 0, ErrBudgetTooSmall
+	}
+	return cut, tokens, nil
+}
+
+// fitRunes finds the longest prefix of body[from:to] that fits, on a
+// character boundary, by binary search.
+func fitRunes(body []byte, from, to, tokens int, count func([]byte) int, fits func(int, int) bool) (int, int) {
+	var ends []int
+	for i := from; i < to; {
+		_, size := utf8.DecodeRune(body[i:to])
+		i += size
+		ends = append(ends, i)
+	}
+	k := sort.Search(len(ends), func(k int) bool {
+		return !fits(ends[k], tokens+count(body[from:ends[k]]))
+	})
+	if k == 0 {
+		return from, tokens
+	}
+	return ends[k-1], tokens + count(body[from:ends[k-1]])
+}
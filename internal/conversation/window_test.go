@@ -0,0 +1,67 @@
+package conversation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	body := []byte("**User:** one two\n\n**Claude:** three four\nfive six\n\n**User:** seven\n")
+	messages := Messages(body)
+	words := func(text string) int { return len(strings.Fields(text)) }
+
+	tests := []struct {
+		name   string
+		start  int
+		budget Budget
+		want   string
+	}{
+		{"unlimited", 0, Budget{}, string(body)},
+		{"whole messages by bytes", 0, Budget{MaxBytes: 52}, "**User:** one two\n\n**Claude:** three four\nfive six\n\n"},
+		{"first message by lines", messages[1].Start, Budget{MaxBytes: 30}, "**Claude:** three four\n"},
+		{"first line by characters", 0, Budget{MaxBytes: 12}, "**User:** on"},
+		{"tokens", 0, Budget{MaxTokens: 8, Count: words}, "**User:** one two\n\n**Claude:** three four\nfive six\n\n"},
+		{"tokens by lines", 0, Budget{MaxTokens: 2, Count: words}, "**User:** one "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cut, _, err := Fit(body, messages, tt.start, len(body), tt.budget)
+			if err != nil {
+				t.Fatalf("Fit() error = %v", err)
+			}
+			if got := string(body[tt.start:cut]); got != tt.want {
+				t.Errorf("Fit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := Fit(body, messages, 0, len(body), Budget{MaxTokens: 1, Count: func(string) int { return 5 }}); !errors.Is(err, ErrBudgetTooSmall) {
+		t.Errorf("Fit() error = %v, want ErrBudgetTooSmall", err)
+	}
+}
+
+func TestFitMultibyte(t *testing.T) {
+	body := []byte("**User:** 日本語のテスト")
+	cut, _, err := Fit(body, Messages(body), 0, len(body), Budget{MaxBytes: 14})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(body[:cut]); got != "**User:** 日" {
+		t.Errorf("Fit() = %q, want a cut on a character boundary", got)
+	}
+}
+
+func TestHeadingsAndPreview(t *testing.T) {
+	text := "Intro line\n\n## Setup\n\n```sh\n# not a heading\n```\n\n### Run it\n#hashtag\n"
+	got := Headings(text)
+	if len(got) != 2 || got[0] != "## Setup" || got[1] != "### Run it" {
+		t.Errorf("Headings() = %q", got)
+	}
+	if got := Preview("\n\n  "+strings.Repeat("a", 10)+"\nmore", 5); got != "aaaaa..." {
+		t.Errorf("Preview() = %q", got)
+	}
+	if got := Preview("short\nmore", 10); got != "short" {
+		t.Errorf("Preview() = %q", got)
+	}
+}
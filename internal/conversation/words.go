@@ -0,0 +1,39 @@
+package conversation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common English words that carry no topic.
+var stopWords = func() map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(`a about above after again all also am an and any are as at be because
+		been before being below between both but by can could did do does doing done down during each
+		even few for from further get got had has have having he her here hers him his how i if in into
+		is it its itself just let like make me might more most much must my no nor not now of off on once
+		only or other our out over own please really same say see she should so some such sure than thank
+		thanks that the their them then there these they thing things this those through to too try under
+		until up us use used using very want was way we well were what when where which while who whom why
+		will with would yes yet you your yours hi hello hey okay ok one two here's it's i'm you're
+		don't can't let's that's there's what's need know think going`) {
+		words[w] = true
+	}
+	return words
+}()
+
+// ContentWords returns the lowercase words of text that carry a topic:
+// words of three or more characters that are not stop words or numbers.
+func ContentWords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	}) {
+		w = strings.Trim(w, "-'")
+		if len([]rune(w)) < 3 || stopWords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
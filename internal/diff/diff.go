@@ -0,0 +1,264 @@
+// Package diff computes line diffs with the Myers algorithm and formats
+// them as unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEdits bounds the edit distance searched for. Inputs that differ by
+// more are reported as replaced entirely, keeping memory bounded.
+const maxEdits = 2000
+
+// Kind is the kind of an edit.
+type Kind int
+
+// Edit kinds
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a diff. A is the line's index in the old text and B
+// in the new one; the index of the side a line is not on is -1.
+type Edit struct {
+	Kind Kind
+	A, B int
+	Line string
+}
+
+// Lines returns the edits that turn a into b, with as few insertions and
+// deletions as possible.
+func Lines(a, b []string) []Edit {
+	// Common prefixes and suffixes, such as everything before an append,
+	// need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for i := range prefix {
+		edits = append(edits, Edit{Kind: Equal, A: i, B: i, Line: a[i]})
+	}
+	for _, e := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		if e.A >= 0 {
+			e.A += prefix
+		}
+		if e.B >= 0 {
+			e.B += prefix
+		}
+		edits = append(edits, e)
+	}
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, Edit{Kind: Equal, A: len(a) - i, B: len(b) - i, Line: a[len(a)-i]})
+	}
+	return edits
+}
+
+// myers returns a shortest edit script from a to b.
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 || n+m > 0 && minEdits(n, m) > maxEdits {
+		return replace(a, b)
+	}
+
+	// trace[d] holds the furthest x on each diagonal k in [-d, d] before
+	// step d, indexed by k+d
+	limit := min(n+m, maxEdits)
+	v := make([]int, 2*limit+3)
+	offset := limit + 1
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+	}
+	return replace(a, b)
+}
+
+// backtrack follows the trace back from the end of both inputs.
+func backtrack(a, b []string, trace [][]int, d int) []Edit {
+	x, y := len(a), len(b)
+	var reversed []Edit
+	for ; d > 0; d-- {
+		prev := trace[d] // values after step d-1, indexed by k+d
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Edit{Kind: Equal, A: x, B: y, Line: a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, Edit{Kind: Insert, A: -1, B: y, Line: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, Edit{Kind: Delete, A: x, B: -1, Line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, Edit{Kind: Equal, A: x, B: y, Line: a[x]})
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// minEdits is the least number of edits between inputs of lengths n and m.
+func minEdits(n, m int) int {
+	return max(n-m, m-n)
+}
+
+// replace deletes all of a and inserts all of b.
+func replace(a, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for i, line := range a {
+		edits = append(edits, Edit{Kind: Delete, A: i, B: -1, Line: line})
+	}
+	for i, line := range b {
+		edits = append(edits, Edit{Kind: Insert, A: -1, B: i, Line: line})
+	}
+	return edits
+}
+
+// Stats counts the inserted and deleted lines of edits.
+func Stats(edits []Edit) (inserted, deleted int) {
+	for _, e := range edits {
+		switch e.Kind {
+		case Insert:
+			inserted++
+		case Delete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+// SplitLines splits text into lines without their line endings.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Unified formats edits as a unified diff with the given number of context
+// lines around each change, under "---" and "+++" headers naming the old
+// and new texts. It returns "" if there are no changes.
+func Unified(from, to string, edits []Edit, context int) string {
+	context = max(context, 0)
+	var sb strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and the end of its hunk
+		first := start
+		for first < len(edits) && edits[first].Kind == Equal {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		end := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].Kind != Equal {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+		lo, hi := max(first-context, start), min(end+context, len(edits))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+		}
+		aStart, aLen, bStart, bLen := hunkRange(edits, lo, hi)
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", formatRange(aStart, aLen), formatRange(bStart, bLen))
+		for _, e := range edits[lo:hi] {
+			switch e.Kind {
+			case Equal:
+				sb.WriteString(" ")
+			case Delete:
+				sb.WriteString("-")
+			case Insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(e.Line)
+			sb.WriteString("\n")
+		}
+		start = hi
+	}
+	return sb.String()
+}
+
+// hunkRange returns the first line, from 1, and the number of lines of
+// the hunk edits[lo:hi] in the old and new texts.
+func hunkRange(edits []Edit, lo, hi int) (aStart, aLen, bStart, bLen int) {
+	for i, e := range edits[:hi] {
+		if e.Kind != Insert {
+			if i < lo {
+				aStart++
+			} else {
+				aLen++
+			}
+		}
+		if e.Kind != Delete {
+			if i < lo {
+				bStart++
+			} else {
+				bLen++
+			}
+		}
+	}
+	return aStart + 1, aLen, bStart + 1, bLen
+}
+
+// formatRange formats a hunk range as unified diff does: an empty range
+// is given by the line before it.
+func formatRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
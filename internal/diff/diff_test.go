@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both sides of a diff from its edits.
+func apply(edits []Edit) (a, b []string) {
+	for _, e := range edits {
+		if e.Kind != Insert {
+			a = append(a, e.Line)
+		}
+		if e.Kind != Delete {
+			b = append(b, e.Line)
+		}
+	}
+	return a, b
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name              string
+		a, b              string
+		inserted, deleted int
+	}{
+		{"equal", "a\nb\nc", "a\nb\nc", 0, 0},
+		{"append", "a\nb", "a\nb\nc\nd", 2, 0},
+		{"from empty", "", "a\nb", 2, 0},
+		{"to empty", "a\nb", "", 0, 2},
+		{"replace middle", "a\nb\nc", "a\nx\nc", 1, 1},
+		// The example from Myers' paper, with an edit distance of 5
+		{"interleaved", "a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SplitLines(tt.a), SplitLines(tt.b)
+			edits := Lines(a, b)
+			gotA, gotB := apply(edits)
+			if strings.Join(gotA, "\n") != tt.a || strings.Join(gotB, "\n") != tt.b {
+				t.Fatalf("edits rebuild %q and %q, want %q and %q", gotA, gotB, tt.a, tt.b)
+			}
+			inserted, deleted := Stats(edits)
+			if inserted+deleted != tt.inserted+tt.deleted {
+				t.Errorf("Stats() = +%d -%d, want %d edits", inserted, deleted, tt.inserted+tt.deleted)
+			}
+			for _, e := range edits {
+				if e.Kind != Insert && a[e.A] != e.Line || e.Kind != Delete && b[e.B] != e.Line {
+					t.Errorf("edit %+v has wrong indices", e)
+				}
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	var a []string
+	for i := range 20 {
+		a = append(a, string(rune('a'+i)))
+	}
+	b := append([]string(nil), a...)
+	b[1] = "B"
+	b = append(b[:15], append([]string{"new"}, b[15:]...)...)
+
+	got := Unified("old.md", "new.md", Lines(a, b), 2)
+	want := `--- old.md
++++ new.md
+@@ -1,4 +1,4 @@
+ a
+-b
++B
+ c
+ d
+@@ -14,4 +14,5 @@
+ n
+ o
++new
+ p
+ q
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Unified("a", "b", Lines(a, a), 3); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want empty", got)
+	}
+	if got := Unified("a", "b", Lines(nil, []string{"x"}), 3); !strings.Contains(got, "@@ -0,0 +1 @@\n+x\n") {
+		t.Errorf("Unified() from empty = %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/grokify/omnistorage"
+)
+
+// Backend wraps an omnistorage.Backend, encrypting content on write and
+// decrypting it on read. Paths and listings are not encrypted.
+type Backend struct {
+	inner   omnistorage.Backend
+	keyring *Keyring
+	mode    Mode
+}
+
+// NewBackend wraps inner with encryption using keyring and mode.
+func NewBackend(inner omnistorage.Backend, keyring *Keyring, mode Mode) *Backend {
+	return &Backend{
+		inner:   inner,
+		keyring: keyring,
+		mode:    mode,
+	}
+}
+
+// NewWriter returns a writer that encrypts its content when closed.
+func (b *Backend) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	return &writer{ctx: ctx, backend: b, path: path, opts: opts}, nil
+}
+
+// NewReader returns a reader over the decrypted content.
+func (b *Backend) NewReader(ctx context.Context, path string, opts ...omnistorage.ReaderOption) (io.ReadCloser, error) {
+	r, err := b.inner.NewReader(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, _, err := b.keyring.Decrypt(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+// Exists checks if a path exists.
+func (b *Backend) Exists(ctx context.Context, path string) (bool, error) {
+	return b.inner.Exists(ctx, path)
+}
+
+// Delete removes a path.
+func (b *Backend) Delete(ctx context.Context, path string) error {
+	return b.inner.Delete(ctx, path)
+}
+
+// List lists paths with a prefix.
+func (b *Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	return b.inner.List(ctx, prefix)
+}
+
+// Close closes the underlying backend.
+func (b *Backend) Close() error {
+	return b.inner.Close()
+}
+
+// Inner returns the wrapped backend, which holds the ciphertext.
+func (b *Backend) Inner() omnistorage.Backend {
+	return b.inner
+}
+
+// RotateResult summarizes a key rotation.
+type RotateResult struct {
+	Rotated   []string // re-encrypted with the primary key
+	Encrypted []string // previously stored in plaintext
+	Unchanged int
+}
+
+// Rotate re-encrypts every file under prefix that is stored in plaintext or
+// encrypted with a key other than the primary key. Once it completes, old
+// keys can be removed from the keyring.
+func (b *Backend) Rotate(ctx context.Context, prefix string) (RotateResult, error) {
+	var result RotateResult
+
+	files, err := b.inner.List(ctx, prefix)
+	if err != nil {
+		return result, err
+	}
+
+	primary := b.keyring.Primary().ID
+	for _, path := range files {
+		r, err := b.inner.NewReader(ctx, path)
+		if err != nil {
+			return result, err
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return result, err
+		}
+
+		plaintext, keyID, err := b.keyring.Decrypt(content)
+		if err != nil {
+			return result, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		if keyID == primary {
+			result.Unchanged++
+			continue
+		}
+
+		if err := b.write(ctx, path, plaintext); err != nil {
+			return result, err
+		}
+		if keyID == "" {
+			result.Encrypted = append(result.Encrypted, path)
+		} else {
+			result.Rotated = append(result.Rotated, path)
+		}
+	}
+
+	return result, nil
+}
+
+func (b *Backend) write(ctx context.Context, path string, plaintext []byte, opts ...omnistorage.WriterOption) error {
+	ciphertext, err := b.keyring.Encrypt(plaintext, b.mode)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+
+	w, err := b.inner.NewWriter(ctx, path, opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(ciphertext); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// writer buffers plaintext until Close, then encrypts and writes it.
+type writer struct {
+	ctx     context.Context
+	backend *Backend
+	path    string
+	opts    []omnistorage.WriterOption
+	buf     bytes.Buffer
+	closed  bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, omnistorage.ErrWriterClosed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.backend.write(w.ctx, w.path, w.buf.Bytes(), w.opts...)
+}
+
+var _ omnistorage.Backend = (*Backend)(nil)
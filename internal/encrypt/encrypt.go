@@ -0,0 +1,187 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// Mode selects how much of a document is encrypted.
+type Mode string
+
+// Encryption modes
+const (
+	// ModeFull encrypts the entire file.
+	ModeFull Mode = "full"
+	// ModeBody leaves frontmatter in plaintext so conversations can be
+	// listed and filtered without decryption, and encrypts only the body.
+	ModeBody Mode = "body"
+)
+
+// ValidMode checks if a mode is valid.
+func ValidMode(mode Mode) bool {
+	return mode == ModeFull || mode == ModeBody
+}
+
+const (
+	armorBegin   = "-----BEGIN CHATHUB ENCRYPTED MESSAGE-----"
+	armorEnd     = "-----END CHATHUB ENCRYPTED MESSAGE-----"
+	armorVersion = "1"
+	armorWidth   = 76
+)
+
+// ErrMalformed indicates that encrypted content could not be decoded.
+var ErrMalformed = errors.New("malformed encrypted message")
+
+// IsEncrypted reports whether content is an encrypted message.
+func IsEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(armorBegin))
+}
+
+// Seal encrypts plaintext with the primary key and returns an ASCII-armored
+// message that records the key ID.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	key := k.Primary()
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	var buf bytes.Buffer
+	buf.WriteString(armorBegin + "\n")
+	buf.WriteString("Version: " + armorVersion + "\n")
+	buf.WriteString("Key-ID: " + key.ID + "\n\n")
+	for len(encoded) > armorWidth {
+		buf.WriteString(encoded[:armorWidth] + "\n")
+		encoded = encoded[armorWidth:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString(armorEnd + "\n")
+
+	return buf.Bytes(), nil
+}
+
+// Open decrypts an armored message, returning the plaintext and the ID of
+// the key that was used.
+func (k *Keyring) Open(armored []byte) ([]byte, string, error) {
+	keyID, payload, err := parseArmor(armored)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, ok := k.Lookup(keyID)
+	if !ok {
+		return nil, keyID, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, keyID, err
+	}
+
+	if len(payload) < aead.NonceSize() {
+		return nil, keyID, ErrMalformed
+	}
+	nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, keyID, fmt.Errorf("failed to decrypt with key %s: %w", keyID, err)
+	}
+
+	return plaintext, keyID, nil
+}
+
+// Encrypt encrypts a document according to mode. In ModeBody, documents
+// with frontmatter keep the frontmatter in plaintext; anything else is
+// fully encrypted.
+func (k *Keyring) Encrypt(content []byte, mode Mode) ([]byte, error) {
+	if mode == ModeBody {
+		if header, body, ok := frontmatter.Split(content); ok {
+			sealed, err := k.Seal(body)
+			if err != nil {
+				return nil, err
+			}
+			return append(append([]byte{}, header...), sealed...), nil
+		}
+	}
+	return k.Seal(content)
+}
+
+// Decrypt reverses Encrypt for either mode. Plaintext content is returned
+// unchanged, so stores may mix encrypted and unencrypted files. It also
+// returns the key ID used, or "" if the content was not encrypted.
+func (k *Keyring) Decrypt(content []byte) ([]byte, string, error) {
+	if IsEncrypted(content) {
+		return k.Open(content)
+	}
+
+	if header, body, ok := frontmatter.Split(content); ok && IsEncrypted(body) {
+		plaintext, keyID, err := k.Open(body)
+		if err != nil {
+			return nil, keyID, err
+		}
+		return append(append([]byte{}, header...), plaintext...), keyID, nil
+	}
+
+	return content, "", nil
+}
+
+func newAEAD(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func parseArmor(armored []byte) (keyID string, payload []byte, err error) {
+	lines := strings.Split(strings.ReplaceAll(string(armored), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || lines[0] != armorBegin {
+		return "", nil, ErrMalformed
+	}
+
+	i := 1
+	version := ""
+	for ; i < len(lines) && lines[i] != ""; i++ {
+		name, value, found := strings.Cut(lines[i], ":")
+		if !found {
+			return "", nil, ErrMalformed
+		}
+		switch strings.TrimSpace(name) {
+		case "Version":
+			version = strings.TrimSpace(value)
+		case "Key-ID":
+			keyID = strings.TrimSpace(value)
+		}
+	}
+	if version != armorVersion {
+		return "", nil, fmt.Errorf("%w: unsupported version %q", ErrMalformed, version)
+	}
+
+	var encoded strings.Builder
+	for i++; i < len(lines); i++ {
+		if lines[i] == armorEnd {
+			payload, err := base64.StdEncoding.DecodeString(encoded.String())
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+			}
+			return keyID, payload, nil
+		}
+		encoded.WriteString(strings.TrimSpace(lines[i]))
+	}
+
+	return "", nil, fmt.Errorf("%w: missing end marker", ErrMalformed)
+}
@@ -0,0 +1,147 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/grokify/omnistorage"
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+const testDoc = "---\ntitle: Secret Plans\nsource: claude\n---\n\n**User:** the launch code is 1234\n"
+
+func mustKeyring(t *testing.T, specs ...string) *Keyring {
+	t.Helper()
+	if len(specs) == 0 {
+		spec, err := GenerateKey()
+		if err != nil {
+			t.Fatalf("GenerateKey() error = %v", err)
+		}
+		specs = []string{spec}
+	}
+	kr, err := ParseKeyring(strings.Join(specs, ","))
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	return kr
+}
+
+func TestEncryptModes(t *testing.T) {
+	kr := mustKeyring(t)
+
+	for _, mode := range []Mode{ModeFull, ModeBody} {
+		t.Run(string(mode), func(t *testing.T) {
+			ciphertext, err := kr.Encrypt([]byte(testDoc), mode)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if bytes.Contains(ciphertext, []byte("launch code")) {
+				t.Error("ciphertext contains plaintext body")
+			}
+
+			hasTitle := bytes.Contains(ciphertext, []byte("title: Secret Plans"))
+			if hasTitle != (mode == ModeBody) {
+				t.Errorf("plaintext frontmatter present = %v in mode %s", hasTitle, mode)
+			}
+
+			plaintext, keyID, err := kr.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if string(plaintext) != testDoc {
+				t.Errorf("Decrypt() = %q, want %q", plaintext, testDoc)
+			}
+			if keyID != kr.Primary().ID {
+				t.Errorf("Decrypt() key ID = %q, want %q", keyID, kr.Primary().ID)
+			}
+		})
+	}
+}
+
+func TestDecryptPlaintextPassthrough(t *testing.T) {
+	kr := mustKeyring(t)
+
+	out, keyID, err := kr.Decrypt([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(out) != testDoc || keyID != "" {
+		t.Errorf("Decrypt(plaintext) = %q, %q; want unchanged", out, keyID)
+	}
+}
+
+func TestUnknownKey(t *testing.T) {
+	ciphertext, err := mustKeyring(t).Encrypt([]byte(testDoc), ModeFull)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if _, _, err := mustKeyring(t).Decrypt(ciphertext); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with other keyring error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestBackendRotate(t *testing.T) {
+	ctx := context.Background()
+	oldKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	newKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	inner := memory.New()
+	oldBackend := NewBackend(inner, mustKeyring(t, oldKey), ModeBody)
+	writeFile(t, oldBackend, "conversations/a.md", testDoc)
+	writeFile(t, inner, "conversations/b.md", testDoc)
+
+	rotated := NewBackend(inner, mustKeyring(t, newKey, oldKey), ModeBody)
+	result, err := rotated.Rotate(ctx, "conversations")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if len(result.Rotated) != 1 || len(result.Encrypted) != 1 {
+		t.Errorf("Rotate() = %+v, want one rotated and one encrypted", result)
+	}
+
+	newOnly := NewBackend(inner, mustKeyring(t, newKey), ModeBody)
+	for _, path := range []string{"conversations/a.md", "conversations/b.md"} {
+		if got := readFile(t, newOnly, path); got != testDoc {
+			t.Errorf("read %s = %q, want %q", path, got, testDoc)
+		}
+	}
+}
+
+func writeFile(t *testing.T, b omnistorage.Backend, path, content string) {
+	t.Helper()
+	w, err := b.NewWriter(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func readFile(t *testing.T, b omnistorage.Backend, path string) string {
+	t.Helper()
+	r, err := b.NewReader(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewReader(%s) error = %v", path, err)
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(content)
+}
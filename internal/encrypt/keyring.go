@@ -0,0 +1,129 @@
+// Package encrypt provides client-side AES-GCM encryption for stored conversations.
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the required key length in bytes (AES-256).
+const KeySize = 32
+
+var (
+	// ErrNoKeys indicates that a keyring has no keys.
+	ErrNoKeys = errors.New("no encryption keys configured")
+
+	// ErrUnknownKey indicates that content was encrypted with a key that is
+	// not in the keyring.
+	ErrUnknownKey = errors.New("unknown encryption key")
+)
+
+// Key is a named AES-256 key.
+type Key struct {
+	ID     string
+	secret []byte
+}
+
+// Keyring holds encryption keys. The first key is the primary key used for
+// new writes; the others are only used to decrypt existing content, which
+// allows keys to be rotated.
+type Keyring struct {
+	keys []Key
+}
+
+// NewKeyring creates a Keyring from keys, the first being primary.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	return &Keyring{keys: keys}, nil
+}
+
+// NewKey creates a Key from raw bytes. If id is empty, one is derived from
+// the key material.
+func NewKey(id string, secret []byte) (Key, error) {
+	if len(secret) != KeySize {
+		return Key{}, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(secret))
+	}
+	if id == "" {
+		sum := sha256.Sum256(secret)
+		id = hex.EncodeToString(sum[:4])
+	}
+	return Key{ID: id, secret: secret}, nil
+}
+
+// ParseKeyring parses a key specification: entries separated by commas or
+// newlines, each either "base64key" or "id:base64key". Blank lines and lines
+// starting with "#" are ignored. The first entry is the primary key.
+func ParseKeyring(spec string) (*Keyring, error) {
+	var keys []Key
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "#") {
+			continue
+		}
+
+		id, encoded := "", field
+		if before, after, found := strings.Cut(field, ":"); found {
+			id, encoded = strings.TrimSpace(before), strings.TrimSpace(after)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		key, err := NewKey(id, secret)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// LoadKeyring parses keys from spec, or from the file at path if spec is empty.
+func LoadKeyring(spec, path string) (*Keyring, error) {
+	if spec == "" && path != "" {
+		data, err := os.ReadFile(path) // #nosec G304 -- path comes from operator configuration
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		spec = string(data)
+	}
+	return ParseKeyring(spec)
+}
+
+// GenerateKey returns a new random key in "id:base64key" form.
+func GenerateKey() (string, error) {
+	secret := make([]byte, KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	key, err := NewKey("", secret)
+	if err != nil {
+		return "", err
+	}
+	return key.ID + ":" + base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// Primary returns the key used for new writes.
+func (k *Keyring) Primary() Key {
+	return k.keys[0]
+}
+
+// Lookup returns the key with the given ID.
+func (k *Keyring) Lookup(id string) (Key, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
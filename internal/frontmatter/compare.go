@@ -0,0 +1,66 @@
+package frontmatter
+
+import (
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a frontmatter field that differs between two documents. From
+// is nil if the field was added and To is nil if it was removed.
+type Change struct {
+	Field string `json:"field"`
+	From  any    `json:"from,omitempty"`
+	To    any    `json:"to,omitempty"`
+}
+
+// Compare returns the fields that differ from a to b, Extra fields
+// included, in the key order of a followed by fields only b has. A nil
+// frontmatter has no fields.
+func Compare(a, b *Frontmatter) ([]Change, error) {
+	aKeys, aValues, err := fieldValues(a)
+	if err != nil {
+		return nil, err
+	}
+	bKeys, bValues, err := fieldValues(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, key := range aKeys {
+		if to, ok := bValues[key]; !ok || !reflect.DeepEqual(aValues[key], to) {
+			changes = append(changes, Change{Field: key, From: aValues[key], To: to})
+		}
+	}
+	for _, key := range bKeys {
+		if _, ok := aValues[key]; !ok {
+			changes = append(changes, Change{Field: key, To: bValues[key]})
+		}
+	}
+	return changes, nil
+}
+
+// fieldValues returns the keys a frontmatter renders, in order, and their
+// values.
+func fieldValues(f *Frontmatter) ([]string, map[string]any, error) {
+	if f == nil {
+		return nil, nil, nil
+	}
+	encoded, err := f.MarshalYAML()
+	if err != nil {
+		return nil, nil, err
+	}
+	node := encoded.(*yaml.Node)
+	var keys []string
+	values := make(map[string]any, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, node.Content[i].Value)
+		values[node.Content[i].Value] = value
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,51 @@
+package frontmatter
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a, _, err := Parse([]byte("---\ntitle: Keys\nsource: claude\ntags: [oauth]\nseries: auth\n---\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	b, _, err := Parse([]byte("---\ntitle: Key rotation\nsource: claude\ntags: [oauth, jwks]\nmodel: opus\n---\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	changes, err := Compare(a, b)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	var fields []string
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	// date is zero in both and source is unchanged
+	want := []string{"title", "tags", "series", "model"}
+	if len(fields) != len(want) {
+		t.Fatalf("changed fields = %v, want %v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Fatalf("changed fields = %v, want %v", fields, want)
+		}
+	}
+	if changes[0].From != "Keys" || changes[0].To != "Key rotation" {
+		t.Errorf("title change = %+v", changes[0])
+	}
+	if changes[2].To != nil || changes[2].From != "auth" {
+		t.Errorf("removed series = %+v, want From auth and no To", changes[2])
+	}
+	if changes[3].From != nil || changes[3].To != "opus" {
+		t.Errorf("added model = %+v, want To opus and no From", changes[3])
+	}
+
+	if changes, _ := Compare(a, a); len(changes) != 0 {
+		t.Errorf("Compare(a, a) = %+v, want no changes", changes)
+	}
+	if changes, _ := Compare(nil, b); len(changes) != len(fields)+1 {
+		t.Errorf("Compare(nil, b) = %d changes, want every field of b", len(changes))
+	}
+}
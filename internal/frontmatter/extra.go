@@ -0,0 +1,159 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a frontmatter key that Frontmatter does not define, such as
+// Hugo's params, series or toc. The value keeps its YAML representation.
+type Field struct {
+	Key   string
+	Value *yaml.Node
+}
+
+// plain has the fields of Frontmatter without its YAML methods.
+type plain Frontmatter
+
+// knownKeys holds the YAML keys of the Frontmatter struct fields.
+var knownKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeFor[Frontmatter]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// UnmarshalYAML decodes the known fields and keeps the remaining keys, and
+// the order of all keys, so Render can reproduce them.
+func (f *Frontmatter) UnmarshalYAML(node *yaml.Node) error {
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*f = Frontmatter(p)
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	budget := maxExtraNodes
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		f.order = append(f.order, key)
+		if !knownKeys[key] {
+			value, err := resolveAliases(node.Content[i+1], &budget)
+			if err != nil {
+				return err
+			}
+			f.Extra = append(f.Extra, Field{Key: key, Value: value})
+		}
+	}
+	return nil
+}
+
+// maxExtraNodes bounds the nodes of all Extra fields of a header once
+// aliases are expanded, so a small header nesting aliases many times over
+// (an alias bomb) fails instead of growing exponentially.
+const maxExtraNodes = 10000
+
+// ErrTooManyAliases indicates frontmatter whose aliases expand to more than
+// maxExtraNodes nodes.
+var ErrTooManyAliases = errors.New("frontmatter aliases expand to too many values")
+
+// resolveAliases returns a copy of node with aliases replaced by the nodes
+// they refer to, since their anchors may be in fields that are not kept.
+// Every node copied is taken from budget, shared across the header.
+func resolveAliases(node *yaml.Node, budget *int) (*yaml.Node, error) {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if *budget--; *budget < 0 {
+		return nil, ErrTooManyAliases
+	}
+	resolved := *node
+	resolved.Anchor = ""
+	if len(node.Content) > 0 {
+		resolved.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			var err error
+			if resolved.Content[i], err = resolveAliases(child, budget); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &resolved, nil
+}
+
+// MarshalYAML encodes the known fields followed by Extra. Keys read by
+// UnmarshalYAML keep their original order; keys added since come last.
+func (f *Frontmatter) MarshalYAML() (any, error) {
+	var node yaml.Node
+	if err := node.Encode((*plain)(f)); err != nil {
+		return nil, err
+	}
+	for _, field := range f.Extra {
+		if knownKeys[field.Key] || field.Value == nil {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.Key}
+		node.Content = append(node.Content, key, field.Value)
+	}
+
+	if len(f.order) > 0 {
+		position := make(map[string]int, len(f.order))
+		for i, key := range f.order {
+			position[key] = i
+		}
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+		rank := func(key string) int {
+			if pos, ok := position[key]; ok {
+				return pos
+			}
+			return len(f.order)
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return rank(pairs[i][0].Value) < rank(pairs[j][0].Value)
+		})
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+	return &node, nil
+}
+
+// Metadata returns the Extra fields as plain values, keyed by name.
+func (f *Frontmatter) Metadata() map[string]any {
+	if len(f.Extra) == 0 {
+		return nil
+	}
+	metadata := make(map[string]any, len(f.Extra))
+	for _, field := range f.Extra {
+		var value any
+		if field.Value != nil && field.Value.Decode(&value) == nil {
+			metadata[field.Key] = value
+		}
+	}
+	return metadata
+}
+
+// Get returns the Extra field with the given key, or nil.
+func (f *Frontmatter) Get(key string) *yaml.Node {
+	for _, field := range f.Extra {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtraFieldsRoundTrip(t *testing.T) {
+	input := `---
+title: Custom
+series: [notes]
+date: 2026-01-10T09:00:00Z
+toc: true
+source: claude
+params:
+  color: blue
+  weight: 3
+---
+
+Body
+`
+	fm, _, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(fm.Extra) != 3 || fm.Extra[0].Key != "series" || fm.Extra[2].Key != "params" {
+		t.Fatalf("Extra = %+v, want series, toc, params", fm.Extra)
+	}
+
+	metadata := fm.Metadata()
+	if metadata["toc"] != true {
+		t.Errorf("Metadata()[toc] = %v, want true", metadata["toc"])
+	}
+	if params, ok := metadata["params"].(map[string]any); !ok || params["color"] != "blue" {
+		t.Errorf("Metadata()[params] = %v", metadata["params"])
+	}
+
+	fm.MessageCount = 4
+	rendered, err := fm.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	got := string(rendered)
+	order := []string{"title:", "series:", "date:", "toc:", "source:", "params:", "    color: blue", "message_count: 4"}
+	last := -1
+	for _, key := range order {
+		i := strings.Index(got, key)
+		if i < 0 || i < last {
+			t.Fatalf("Render() = %q, want keys in order %v", got, order)
+		}
+		last = i
+	}
+
+	again, _, err := Parse(rendered)
+	if err != nil {
+		t.Fatalf("Parse(rendered) error = %v", err)
+	}
+	if len(again.Extra) != 3 || again.Title != "Custom" || again.MessageCount != 4 {
+		t.Errorf("re-parsed = %+v", again)
+	}
+}
+
+func TestExtraFieldsCannotShadowKnown(t *testing.T) {
+	fm := New("Title", SourceClaude)
+	fm.Extra = []Field{{Key: "title", Value: nil}}
+	rendered, err := fm.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Count(string(rendered), "title:") != 1 {
+		t.Errorf("Render() = %q, want one title", rendered)
+	}
+}
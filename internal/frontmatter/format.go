@@ -0,0 +1,398 @@
+package frontmatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a frontmatter syntax supported by Hugo.
+type Format string
+
+// Frontmatter formats
+const (
+	FormatYAML Format = "yaml" // delimited by --- lines
+	FormatTOML Format = "toml" // delimited by +++ lines
+	FormatJSON Format = "json" // a JSON object
+)
+
+// Formats lists the supported frontmatter formats.
+var Formats = []Format{FormatYAML, FormatTOML, FormatJSON}
+
+var (
+	tomlDelimiter = []byte("+++")
+	byteOrderMark = []byte("\xef\xbb\xbf")
+)
+
+// ParseFormat parses a format name. An empty name is YAML.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatYAML, nil
+	}
+	format := Format(strings.ToLower(name))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("invalid frontmatter format %q: must be yaml, toml or json", name)
+	}
+	return format, nil
+}
+
+// Detect returns the frontmatter format of a document, or "" if it does
+// not start with frontmatter.
+func Detect(content []byte) Format {
+	format, _, _, _ := split(content)
+	return format
+}
+
+// split is Split that also reports the format. A UTF-8 byte order mark
+// and blank lines before the frontmatter are kept in the header.
+func split(content []byte) (format Format, header, body []byte, ok bool) {
+	start := preambleLen(content)
+	rest := content[start:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("{")):
+		header, body, ok = splitJSON(rest)
+		format = FormatJSON
+	case bytes.HasPrefix(rest, tomlDelimiter):
+		header, body, ok = splitDelimited(rest, tomlDelimiter)
+		format = FormatTOML
+	default:
+		header, body, ok = splitDelimited(rest, frontmatterDelimiter)
+		format = FormatYAML
+	}
+	if !ok {
+		return "", nil, content, false
+	}
+	return format, content[:start+len(header)], body, true
+}
+
+// preambleLen returns the length of the byte order mark and whitespace
+// that may precede frontmatter.
+func preambleLen(content []byte) int {
+	rest := bytes.TrimPrefix(content, byteOrderMark)
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(content) - len(rest)
+}
+
+// isDelimiterLine reports whether line, without its newline, is the
+// delimiter. Trailing whitespace is allowed.
+func isDelimiterLine(line, delimiter []byte) bool {
+	return bytes.Equal(bytes.TrimRight(line, " \t\r"), delimiter)
+}
+
+// splitDelimited splits a header enclosed by delimiter lines.
+func splitDelimited(content, delimiter []byte) (header, body []byte, ok bool) {
+	first, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || !isDelimiterLine(first, delimiter) {
+		return nil, content, false
+	}
+
+	offset := len(first) + 1
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		lineEnd := offset + len(line)
+		if lineEnd < len(content) {
+			lineEnd++ // include the newline
+		}
+		if isDelimiterLine(line, delimiter) {
+			return content[:lineEnd], content[lineEnd:], true
+		}
+		offset = lineEnd
+		rest = next
+	}
+
+	return nil, content, false
+}
+
+// splitJSON splits a leading JSON object and the rest of its line.
+func splitJSON(content []byte) (header, body []byte, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	var object json.RawMessage
+	if err := dec.Decode(&object); err != nil {
+		return nil, content, false
+	}
+	end := int(dec.InputOffset())
+	if i := bytes.IndexByte(content[end:], '\n'); i >= 0 && len(bytes.TrimSpace(content[end:end+i])) == 0 {
+		end += i + 1
+	} else if len(bytes.TrimSpace(content[end:])) != 0 {
+		return nil, content, false
+	}
+	return content[:end], content[end:], true
+}
+
+// decodeHeader decodes a header returned by split.
+func decodeHeader(format Format, header []byte) (*Frontmatter, error) {
+	node, err := headerNode(format, header)
+	if err != nil {
+		return nil, err
+	}
+
+	fm := &Frontmatter{}
+	if node != nil {
+		if err := node.Decode(fm); err != nil {
+			return nil, err
+		}
+	}
+	fm.Format = format
+	return fm, nil
+}
+
+// headerNode converts a header returned by split to a YAML node, or nil if
+// the header is empty.
+func headerNode(format Format, header []byte) (*yaml.Node, error) {
+	header = header[preambleLen(header):]
+	switch format {
+	case FormatTOML:
+		return tomlToNode(delimitedInner(header))
+	case FormatJSON:
+		return jsonToNode(json.NewDecoder(bytes.NewReader(header)))
+	default:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(delimitedInner(header), &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return nil, nil
+		}
+		return doc.Content[0], nil
+	}
+}
+
+// delimitedInner returns a delimited header without its delimiter lines.
+func delimitedInner(header []byte) []byte {
+	_, inner, _ := bytes.Cut(header, []byte("\n"))
+	inner = bytes.TrimRight(inner, "\r\n")
+	if i := bytes.LastIndexByte(inner, '\n'); i >= 0 {
+		return inner[:i+1]
+	}
+	return nil
+}
+
+// jsonToNode converts the next JSON value to a YAML node, keeping the
+// order of object keys.
+func jsonToNode(dec *json.Decoder) (*yaml.Node, error) {
+	dec.UseNumber()
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := jsonToNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// tomlToNode converts a TOML document to a YAML mapping node, keeping the
+// document order of keys.
+func tomlToNode(data []byte) (*yaml.Node, error) {
+	var doc map[string]any
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, err
+	}
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		order[key.String()] = i
+	}
+	return tomlValueNode(doc, nil, order)
+}
+
+func tomlValueNode(value any, path toml.Key, order map[string]int) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		rank := func(key string) int {
+			if i, ok := order[slices.Concat(path, toml.Key{key}).String()]; ok {
+				return i
+			}
+			return len(order)
+		}
+		slices.SortStableFunc(keys, func(a, b string) int {
+			if ra, rb := rank(a), rank(b); ra != rb {
+				return ra - rb
+			}
+			return strings.Compare(a, b)
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			valueNode, err := tomlValueNode(v[key], slices.Concat(path, toml.Key{key}), order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, valueNode)
+		}
+		return node, nil
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return tomlValueNode(items, path, order)
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			itemNode, err := tomlValueNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+		return node, nil
+	default:
+		var node yaml.Node
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+		return &node, nil
+	}
+}
+
+// renderTOML writes the frontmatter as a +++ delimited TOML block. Tables
+// follow plain keys, as TOML requires.
+func renderTOML(node *yaml.Node) ([]byte, error) {
+	var plain, tables bytes.Buffer
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, valueNode := node.Content[i].Value, node.Content[i+1]
+		var value any
+		if err := valueNode.Decode(&value); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue // TOML has no null
+		}
+		out := &plain
+		if isTOMLTable(value) {
+			out = &tables
+		}
+		if err := toml.NewEncoder(out).Encode(map[string]any{key: value}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s as TOML: %w", key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("+++\n")
+	buf.Write(plain.Bytes())
+	if tables.Len() > 0 {
+		if plain.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(bytes.TrimLeft(tables.Bytes(), "\n"))
+	}
+	buf.WriteString("+++\n")
+	return buf.Bytes(), nil
+}
+
+func isTOMLTable(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return true
+	case []any:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]any); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// renderJSON writes the frontmatter as an indented JSON object followed by
+// a newline, keeping key order.
+func renderJSON(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONNode(&buf, node, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeJSONNode(w io.Writer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close, step := "[", "]", 1
+		if node.Kind == yaml.MappingNode {
+			open, close, step = "{", "}", 2
+		}
+		if len(node.Content) == 0 {
+			_, err := io.WriteString(w, open+close)
+			return err
+		}
+		io.WriteString(w, open+"\n")
+		for i := 0; i < len(node.Content); i += step {
+			io.WriteString(w, indent+"  ")
+			if step == 2 {
+				key, _ := json.Marshal(node.Content[i].Value)
+				w.Write(key)
+				io.WriteString(w, ": ")
+			}
+			if err := writeJSONNode(w, node.Content[i+step-1], indent+"  "); err != nil {
+				return err
+			}
+			if i+step < len(node.Content) {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, "\n")
+		}
+		_, err := io.WriteString(w, indent+close)
+		return err
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
@@ -0,0 +1,93 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFormats(t *testing.T) {
+	date := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		format  Format
+		content string
+	}{
+		{"yaml", FormatYAML, "---\ntitle: Hello\ndate: 2026-01-10T09:00:00Z\nsource: claude\ntags: [go]\ntoc: true\n---\n\nBody\n"},
+		{"toml", FormatTOML, "+++\ntitle = \"Hello\"\ndate = 2026-01-10T09:00:00Z\nsource = \"claude\"\ntags = [\"go\"]\ntoc = true\n+++\n\nBody\n"},
+		{"json", FormatJSON, "{\n  \"title\": \"Hello\",\n  \"date\": \"2026-01-10T09:00:00Z\",\n  \"source\": \"claude\",\n  \"tags\": [\"go\"],\n  \"toc\": true\n}\n\nBody\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.content)); got != tt.format {
+				t.Errorf("Detect() = %q, want %q", got, tt.format)
+			}
+			fm, body, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if fm.Format != tt.format || fm.Title != "Hello" || fm.Source != "claude" || !fm.Date.Equal(date) {
+				t.Errorf("Parse() = %+v", fm)
+			}
+			if len(fm.Tags) != 1 || fm.Tags[0] != "go" {
+				t.Errorf("Tags = %v", fm.Tags)
+			}
+			if fm.Metadata()["toc"] != true {
+				t.Errorf("Metadata() = %v, want toc", fm.Metadata())
+			}
+			if string(body) != "\nBody\n" {
+				t.Errorf("body = %q", body)
+			}
+
+			// Rendering keeps the format, so the document parses the same way
+			rendered, err := fm.RenderWithContent(body)
+			if err != nil {
+				t.Fatalf("RenderWithContent() error = %v", err)
+			}
+			again, againBody, err := Parse(rendered)
+			if err != nil {
+				t.Fatalf("Parse(rendered) error = %v\n%s", err, rendered)
+			}
+			if again.Format != tt.format || again.Title != fm.Title || !again.Date.Equal(date) || again.Metadata()["toc"] != true {
+				t.Errorf("round trip = %+v\n%s", again, rendered)
+			}
+			if string(againBody) != string(body) {
+				t.Errorf("round trip body = %q", againBody)
+			}
+		})
+	}
+}
+
+func TestRenderTOMLTables(t *testing.T) {
+	content := "+++\ntitle = \"Tables\"\nsource = \"claude\"\n\n[params]\ncolor = \"blue\"\n+++\n"
+	fm, _, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	fm.MessageCount = 2
+
+	rendered, err := fm.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	got := string(rendered)
+	if !strings.HasPrefix(got, "+++\n") || !strings.HasSuffix(got, "+++\n") {
+		t.Errorf("Render() = %q, want +++ delimiters", got)
+	}
+	// Plain keys added after parsing must precede the params table
+	if strings.Index(got, "message_count") > strings.Index(got, "[params]") {
+		t.Errorf("Render() = %q, want plain keys before tables", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"", "yaml", "TOML", "json"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) error = nil")
+	}
+}
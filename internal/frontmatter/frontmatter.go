@@ -1,49 +1,54 @@
-// Package frontmatter provides Hugo-compatible YAML frontmatter parsing and generation.
+// Package frontmatter provides Hugo-compatible YAML, TOML and JSON frontmatter
+// parsing and generation.
 package frontmatter
 
 import (
 	"bytes"
 	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"slices"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Source platform identifiers
-const (
-	SourceChatGPT    = "chatgpt"
-	SourceClaude     = "claude"
-	SourceClaudeCode = "claude-code"
-	SourceGemini     = "gemini"
-	SourcePerplexity = "perplexity"
-	SourceCodex      = "codex"
-)
-
 // Frontmatter represents Hugo-compatible YAML frontmatter with ChatHub extensions.
 type Frontmatter struct {
 	// Hugo standard fields
-	Title       string    `yaml:"title"`
-	Date        time.Time `yaml:"date"`
-	LastMod     time.Time `yaml:"lastmod,omitempty"`
-	Draft       bool      `yaml:"draft,omitempty"`
-	Tags        []string  `yaml:"tags,omitempty"`
-	Categories  []string  `yaml:"categories,omitempty"`
-	Author      string    `yaml:"author,omitempty"`
-	Description string    `yaml:"description,omitempty"`
-	Slug        string    `yaml:"slug,omitempty"`
-	Weight      int       `yaml:"weight,omitempty"`
-	Aliases     []string  `yaml:"aliases,omitempty"`
+	Title       string    `yaml:"title" jsonschema:"Conversation title"`
+	Date        time.Time `yaml:"date" jsonschema:"Creation date"`
+	LastMod     time.Time `yaml:"lastmod,omitempty" jsonschema:"Last modification date"`
+	Draft       bool      `yaml:"draft,omitempty" jsonschema:"Exclude from published Hugo builds"`
+	Tags        []string  `yaml:"tags,omitempty" jsonschema:"Hugo tags"`
+	Categories  []string  `yaml:"categories,omitempty" jsonschema:"Hugo categories"`
+	Author      string    `yaml:"author,omitempty" jsonschema:"Author, by default the source platform"`
+	Description string    `yaml:"description,omitempty" jsonschema:"Brief summary"`
+	Slug        string    `yaml:"slug,omitempty" jsonschema:"Last URL path segment"`
+	Weight      int       `yaml:"weight,omitempty" jsonschema:"Hugo ordering weight"`
+	Aliases     []string  `yaml:"aliases,omitempty" jsonschema:"Previous URLs that redirect to this conversation"`
 
 	// ChatHub extension fields
-	Source         string   `yaml:"source"`
-	ConversationID string   `yaml:"conversation_id,omitempty"`
-	Participants   []string `yaml:"participants,omitempty"`
-	MessageCount   int      `yaml:"message_count,omitempty"`
-	Model          string   `yaml:"model,omitempty"`
-	Tokens         int      `yaml:"tokens,omitempty"`
+	Source         string   `yaml:"source" jsonschema:"Source platform ID from the source registry"`
+	ConversationID string   `yaml:"conversation_id,omitempty" jsonschema:"Unique conversation ID"`
+	Participants   []string `yaml:"participants,omitempty" jsonschema:"Conversation participants"`
+	MessageCount   int      `yaml:"message_count,omitempty" jsonschema:"Number of messages"`
+	Model          string   `yaml:"model,omitempty" jsonschema:"AI model used"`
+	Tokens         int      `yaml:"tokens,omitempty" jsonschema:"Token count of the body"`
+	Tokenizer      string   `yaml:"tokenizer,omitempty" jsonschema:"Tokenizer used for the token count"`
+	Cost           float64  `yaml:"cost,omitempty" jsonschema:"Estimated cost in USD of generating the conversation"`
+	MergedFrom     []string `yaml:"merged_from,omitempty" jsonschema:"Conversation IDs of the conversations merged into this one"`
+	SchemaVersion  int      `yaml:"schema_version,omitempty" jsonschema:"Frontmatter schema version"`
+
+	// Format is the syntax Render emits. Parse sets it to the format it
+	// read; empty means YAML.
+	Format Format `yaml:"-"`
+
+	// Extra holds keys not defined above, in their original order. They
+	// are preserved when a parsed document is rendered again.
+	Extra []Field `yaml:"-"`
+
+	// order is the key order of the parsed document
+	order []string
 }
 
 var (
@@ -52,45 +57,57 @@ var (
 
 	// frontmatterDelimiter is the YAML frontmatter delimiter
 	frontmatterDelimiter = []byte("---")
-
-	// slugRegex matches non-alphanumeric characters for slug generation
-	slugRegex = regexp.MustCompile(`[^a-z0-9\s-]`)
-	// whitespaceRegex matches whitespace for slug generation
-	whitespaceRegex = regexp.MustCompile(`[\s_]+`)
 )
 
-// Parse extracts frontmatter from Markdown content.
+// Parse extracts YAML, TOML or JSON frontmatter from Markdown content.
 // Returns the parsed frontmatter, the remaining content, and any error.
+//
+// Delimiters must be on lines of their own, so "---" inside a value or a
+// horizontal rule in the body does not end the frontmatter. A byte order
+// mark, leading blank lines and CRLF line endings are accepted. The body
+// is returned exactly as it appears after the closing delimiter line.
 func Parse(content []byte) (*Frontmatter, []byte, error) {
-	content = bytes.TrimSpace(content)
-
-	// Check for frontmatter delimiter
-	if !bytes.HasPrefix(content, frontmatterDelimiter) {
-		return nil, content, nil // No frontmatter
-	}
-
-	// Find end delimiter
-	rest := content[len(frontmatterDelimiter):]
-	endIdx := bytes.Index(rest, frontmatterDelimiter)
-	if endIdx == -1 {
-		return nil, nil, ErrInvalidFrontmatter
+	format, header, body, ok := split(content)
+	if !ok {
+		// An opening delimiter line without a closing one is malformed
+		rest := content[preambleLen(content):]
+		first, _, _ := bytes.Cut(rest, []byte("\n"))
+		if isDelimiterLine(first, frontmatterDelimiter) || isDelimiterLine(first, tomlDelimiter) {
+			return nil, nil, ErrInvalidFrontmatter
+		}
+		return nil, bytes.TrimPrefix(content, byteOrderMark), nil // No frontmatter
 	}
 
-	// Extract YAML
-	yamlContent := bytes.TrimSpace(rest[:endIdx])
-	remaining := bytes.TrimSpace(rest[endIdx+len(frontmatterDelimiter):])
-
-	// Parse YAML
-	var fm Frontmatter
-	if err := yaml.Unmarshal(yamlContent, &fm); err != nil {
+	fm, err := decodeHeader(format, header)
+	if err != nil {
 		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFrontmatter, err)
 	}
 
-	return &fm, remaining, nil
+	return fm, body, nil
+}
+
+// Split separates a document into its raw frontmatter block, including both
+// delimiter lines, and the remaining body, without modifying either.
+// It returns ok=false if the document does not start with frontmatter.
+func Split(content []byte) (header, body []byte, ok bool) {
+	_, header, body, ok = split(content)
+	return header, body, ok
 }
 
-// Render generates YAML frontmatter bytes.
+// Render generates frontmatter bytes in the frontmatter's Format.
 func (f *Frontmatter) Render() ([]byte, error) {
+	switch f.Format {
+	case FormatTOML, FormatJSON:
+		node, err := f.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		if f.Format == FormatTOML {
+			return renderTOML(node.(*yaml.Node))
+		}
+		return renderJSON(node.(*yaml.Node))
+	}
+
 	yamlBytes, err := yaml.Marshal(f)
 	if err != nil {
 		return nil, err
@@ -105,6 +122,8 @@ func (f *Frontmatter) Render() ([]byte, error) {
 }
 
 // RenderWithContent generates complete Markdown with frontmatter and content.
+// A blank line separates the two unless content already starts with one,
+// so a body returned by Parse is written back unchanged.
 func (f *Frontmatter) RenderWithContent(content []byte) ([]byte, error) {
 	fmBytes, err := f.Render()
 	if err != nil {
@@ -113,35 +132,18 @@ func (f *Frontmatter) RenderWithContent(content []byte) ([]byte, error) {
 
 	var buf bytes.Buffer
 	buf.Write(fmBytes)
-	buf.WriteByte('\n')
+	if !bytes.HasPrefix(content, []byte("\n")) && !bytes.HasPrefix(content, []byte("\r\n")) {
+		buf.WriteByte('\n')
+	}
 	buf.Write(content)
 
 	return buf.Bytes(), nil
 }
 
-// GenerateSlug creates a URL-friendly slug from a title.
-func GenerateSlug(title string) string {
-	slug := strings.ToLower(title)
-	slug = slugRegex.ReplaceAllString(slug, "")
-	slug = whitespaceRegex.ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
-
-	// Limit length
-	if len(slug) > 50 {
-		slug = slug[:50]
-		// Don't end on a hyphen
-		slug = strings.TrimRight(slug, "-")
-	}
-
-	return slug
-}
-
-// GeneratePath creates a file path for a conversation.
+// GeneratePath creates a file path for a conversation using DefaultLayout.
 // Format: {folder}/{source}/{date}_{slug}.md
 func GeneratePath(folder, source, title string, date time.Time) string {
-	slug := GenerateSlug(title)
-	dateStr := date.Format("2006-01-02")
-	return fmt.Sprintf("%s/%s/%s_%s.md", folder, source, dateStr, slug)
+	return defaultLayout.Path(folder, &Frontmatter{Title: title, Source: source, Date: date})
 }
 
 // GenerateConversationID creates a unique conversation ID.
@@ -149,29 +151,66 @@ func GenerateConversationID() string {
 	return fmt.Sprintf("conv_%d", time.Now().UnixNano())
 }
 
-// New creates a new Frontmatter with default values.
+// New creates a new Frontmatter with default values. The author and
+// participants come from the source registry.
 func New(title, source string) *Frontmatter {
 	now := time.Now().UTC()
-	return &Frontmatter{
+	author := source
+	var participants []string
+	if info, ok := LookupSource(source); ok {
+		author = info.Author
+		participants = slices.Clone(info.Participants)
+	}
+	fm := &Frontmatter{
 		Title:          title,
 		Date:           now,
 		LastMod:        now,
 		Draft:          false,
-		Author:         source,
+		Author:         author,
 		Slug:           GenerateSlug(title),
 		Source:         source,
 		ConversationID: GenerateConversationID(),
+		Participants:   participants,
+		SchemaVersion:  SchemaVersion,
 	}
+	if fm.Slug == "" {
+		fm.Slug = idSlug(fm.ConversationID)
+	}
+	return fm
 }
 
-// ValidSource checks if a source is valid.
-func ValidSource(source string) bool {
-	switch source {
-	case SourceChatGPT, SourceClaude, SourceClaudeCode, SourceGemini, SourcePerplexity, SourceCodex:
-		return true
-	default:
-		return false
+// Upgrade fills in fields that New sets but older files may lack, raising
+// the schema version to SchemaVersion, and returns the YAML names of the
+// fields it set. The source must be known.
+func Upgrade(f *Frontmatter) []string {
+	var upgraded []string
+	if f.ConversationID == "" {
+		f.ConversationID = GenerateConversationID()
+		upgraded = append(upgraded, "conversation_id")
+	}
+	if f.Slug == "" {
+		f.Slug = GenerateSlug(f.Title)
+		if f.Slug == "" {
+			f.Slug = idSlug(f.ConversationID)
+		}
+		upgraded = append(upgraded, "slug")
+	}
+	if f.Author == "" && f.Source != "" {
+		f.Author = f.Source
+		if info, ok := LookupSource(f.Source); ok {
+			f.Author = info.Author
+		}
+		upgraded = append(upgraded, "author")
+	}
+	if f.LastMod.IsZero() && !f.Date.IsZero() {
+		f.LastMod = f.Date
+		upgraded = append(upgraded, "lastmod")
+	}
+	if f.SchemaVersion < SchemaVersion {
+		f.SchemaVersion = SchemaVersion
+		upgraded = append(upgraded, "schema_version")
 	}
+	return upgraded
 }
 
 // ExtractDescription extracts a description from content (first non-empty line or first N chars).
@@ -15,7 +15,7 @@ func TestGenerateSlug(t *testing.T) {
 		{"Test!@#$%Special", "testspecial"},
 		{"Multiple   Spaces", "multiple-spaces"},
 		{"", ""},
-		{"A Very Long Title That Should Be Truncated To Fifty Characters Maximum", "a-very-long-title-that-should-be-truncated-to-fift"},
+		{"A Very Long Title That Should Be Truncated To Fifty Characters Maximum", "a-very-long-title-that-should-be-truncated-to"},
 	}
 
 	for _, tt := range tests {
@@ -140,8 +140,18 @@ func TestRenderWithContent(t *testing.T) {
 		t.Errorf("Title = %q, want %q", parsed.Title, fm.Title)
 	}
 
-	if string(body) != string(content) {
-		t.Errorf("body = %q, want %q", string(body), string(content))
+	// The separating blank line is part of the body
+	if string(body) != "\n"+string(content) {
+		t.Errorf("body = %q, want %q", string(body), "\n"+string(content))
+	}
+
+	// Rendering the parsed body again does not add another blank line
+	again, err := parsed.RenderWithContent(body)
+	if err != nil {
+		t.Fatalf("RenderWithContent() error = %v", err)
+	}
+	if string(again) != string(output) {
+		t.Errorf("re-rendered = %q, want %q", again, output)
 	}
 }
 
@@ -178,3 +188,46 @@ func TestExtractDescription(t *testing.T) {
 		t.Error("ExtractDescription() should skip markdown headers")
 	}
 }
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		header string
+		body   string
+		ok     bool
+	}{
+		{"yaml", "---\ntitle: x\n---\n\nbody\n", "---\ntitle: x\n---\n", "\nbody\n", true},
+		{"crlf", "---\r\ntitle: x\r\n---\r\nbody", "---\r\ntitle: x\r\n---\r\n", "body", true},
+		{"no trailing newline", "---\ntitle: x\n---", "---\ntitle: x\n---", "", true},
+		{"dashes in value", "---\ntitle: a---b\n---\nbody", "---\ntitle: a---b\n---\n", "body", true},
+		{"no frontmatter", "# Title\n---\n", "", "# Title\n---\n", false},
+		{"unterminated", "---\ntitle: x\n", "", "---\ntitle: x\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body, ok := Split([]byte(tt.input))
+			if ok != tt.ok || string(header) != tt.header || string(body) != tt.body {
+				t.Errorf("Split(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.input, header, body, ok, tt.header, tt.body, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	date := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	fm := &Frontmatter{Title: "Hello World", Source: "claude", Date: date}
+
+	upgraded := Upgrade(fm)
+	if len(upgraded) != 5 {
+		t.Errorf("Upgrade() = %v, want 5 fields", upgraded)
+	}
+	if fm.ConversationID == "" || fm.Slug != "hello-world" || fm.Author != "claude" || !fm.LastMod.Equal(date) {
+		t.Errorf("Upgrade() left %+v", fm)
+	}
+	if again := Upgrade(fm); len(again) != 0 {
+		t.Errorf("second Upgrade() = %v, want none", again)
+	}
+}
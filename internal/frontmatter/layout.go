@@ -0,0 +1,154 @@
+package frontmatter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultLayout is the path layout used when none is configured.
+const DefaultLayout = "{source}/{date}_{slug}.md"
+
+// LayoutVariables lists the variables a layout template may use.
+var LayoutVariables = []string{"source", "date", "yyyy", "mm", "dd", "slug", "id", "tag", "category"}
+
+var (
+	// layoutVarRegex matches {name} and {name|fallback}
+	layoutVarRegex = regexp.MustCompile(`\{([a-z]+)(?:\|([^{}]*))?\}`)
+	// pathSafeRegex matches characters not allowed in a path segment
+	pathSafeRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+	defaultLayout = MustParseLayout(DefaultLayout)
+)
+
+// Layout maps a conversation's frontmatter to a path relative to the
+// storage folder. Templates use the variables:
+//
+//	{source}    source folder from the source registry
+//	{date}      creation date, YYYY-MM-DD
+//	{yyyy}      creation year
+//	{mm}        creation month, two digits
+//	{dd}        creation day, two digits
+//	{slug}      slug, or one generated from the title or conversation ID
+//	{id}        conversation ID
+//	{tag}       first tag
+//	{category}  first category
+//
+// A variable may give a fallback for empty values, as in
+// {category|general}. Without one, empty values become "untitled",
+// "untagged" or "uncategorized".
+type Layout struct {
+	template string
+}
+
+// ParseLayout parses a layout template. It must produce a relative
+// Markdown path.
+func ParseLayout(template string) (*Layout, error) {
+	if template == "" {
+		template = DefaultLayout
+	}
+	if strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("invalid layout %q: must be relative", template)
+	}
+	if !strings.HasSuffix(template, ".md") {
+		return nil, fmt.Errorf("invalid layout %q: must end in .md", template)
+	}
+	for _, part := range strings.Split(template, "/") {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".") {
+			return nil, fmt.Errorf("invalid layout %q: empty, relative or hidden path segment", template)
+		}
+	}
+	for _, m := range layoutVarRegex.FindAllStringSubmatch(template, -1) {
+		if !validLayoutVariable(m[1]) {
+			return nil, fmt.Errorf("invalid layout %q: unknown variable {%s}", template, m[1])
+		}
+	}
+	if rest := layoutVarRegex.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return nil, fmt.Errorf("invalid layout %q: unbalanced braces", template)
+	}
+	return &Layout{template: template}, nil
+}
+
+// MustParseLayout is like ParseLayout but panics on error.
+func MustParseLayout(template string) *Layout {
+	l, err := ParseLayout(template)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// String returns the layout template.
+func (l *Layout) String() string {
+	return l.template
+}
+
+// Path returns the path of the conversation described by fm under folder.
+func (l *Layout) Path(folder string, fm *Frontmatter) string {
+	rel := layoutVarRegex.ReplaceAllStringFunc(l.template, func(v string) string {
+		m := layoutVarRegex.FindStringSubmatch(v)
+		value := layoutValue(m[1], fm)
+		if value == "" {
+			value = m[2]
+		}
+		if value == "" {
+			value = layoutDefaults[m[1]]
+		}
+		return value
+	})
+	return path.Join(folder, rel)
+}
+
+var layoutDefaults = map[string]string{
+	"source":   "unknown",
+	"slug":     "untitled",
+	"id":       "unknown",
+	"tag":      "untagged",
+	"category": "uncategorized",
+}
+
+func layoutValue(name string, fm *Frontmatter) string {
+	switch name {
+	case "source":
+		return sourceFolder(fm.Source)
+	case "date":
+		return fm.Date.Format("2006-01-02")
+	case "yyyy":
+		return fm.Date.Format("2006")
+	case "mm":
+		return fm.Date.Format("01")
+	case "dd":
+		return fm.Date.Format("02")
+	case "slug":
+		if fm.Slug != "" {
+			if slug := GenerateSlug(fm.Slug); slug != "" {
+				return slug
+			}
+		}
+		if slug := GenerateSlug(fm.Title); slug != "" {
+			return slug
+		}
+		return idSlug(fm.ConversationID)
+	case "id":
+		return strings.TrimLeft(pathSafeRegex.ReplaceAllString(fm.ConversationID, "-"), ".")
+	case "tag":
+		if len(fm.Tags) > 0 {
+			return GenerateSlug(fm.Tags[0])
+		}
+	case "category":
+		if len(fm.Categories) > 0 {
+			return GenerateSlug(fm.Categories[0])
+		}
+	}
+	return ""
+}
+
+func validLayoutVariable(name string) bool {
+	for _, v := range LayoutVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
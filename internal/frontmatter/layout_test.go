@@ -0,0 +1,53 @@
+package frontmatter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLayoutPath(t *testing.T) {
+	fm := &Frontmatter{
+		Title:          "Hello World",
+		Date:           time.Date(2026, 1, 10, 14, 30, 0, 0, time.UTC),
+		Source:         SourceClaude,
+		ConversationID: "conv_123",
+		Tags:           []string{"Go Lang", "mcp"},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultLayout, "conversations/claude/2026-01-10_hello-world.md"},
+		{"{yyyy}/{mm}/{slug}/index.md", "conversations/2026/01/hello-world/index.md"},
+		{"{tag}/{dd}-{id}.md", "conversations/go-lang/10-conv_123.md"},
+		{"{category}/{slug}.md", "conversations/uncategorized/hello-world.md"},
+		{"{category|inbox}/{slug}.md", "conversations/inbox/hello-world.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			layout, err := ParseLayout(tt.template)
+			if err != nil {
+				t.Fatalf("ParseLayout() error = %v", err)
+			}
+			if got := layout.Path("conversations", fm); got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLayoutInvalid(t *testing.T) {
+	for _, template := range []string{
+		"/abs/{slug}.md",
+		"{slug}.txt",
+		"../{slug}.md",
+		".hidden/{slug}.md",
+		"{project}/{slug}.md",
+		"{slug.md",
+	} {
+		if _, err := ParseLayout(template); err == nil {
+			t.Errorf("ParseLayout(%q) succeeded, want error", template)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDelimiters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		title string // empty means no frontmatter
+		body  string
+		err   bool
+	}{
+		{"dashes in value", "---\ntitle: a---b\n---\nbody", "a---b", "body", false},
+		{"dashes in block scalar", "---\ntitle: |\n  x --- y\n---\nbody", "x --- y\n", "body", false},
+		{"horizontal rule in body", "---\ntitle: x\n---\n\nabove\n\n---\n\nbelow\n", "x", "\nabove\n\n---\n\nbelow\n", false},
+		{"byte order mark", "\xef\xbb\xbf---\ntitle: x\n---\nbody", "x", "body", false},
+		{"crlf", "---\r\ntitle: x\r\n---\r\n\r\nbody\r\n", "x", "\r\nbody\r\n", false},
+		{"leading blank lines", "\n\n---\ntitle: x\n---\nbody", "x", "body", false},
+		{"trailing whitespace on delimiter", "--- \ntitle: x\n---\t\nbody", "x", "body", false},
+		{"leading whitespace in body", "---\ntitle: x\n---\n   indented code\n", "x", "   indented code\n", false},
+		{"no trailing newline", "---\ntitle: x\n---", "x", "", false},
+		{"thematic break is not a delimiter", "----\ntitle: x\n----\nbody", "", "----\ntitle: x\n----\nbody", false},
+		{"rule before malformed header", "---\n\n# Heading\n", "", "", true},
+		{"unterminated", "---\ntitle: x\n", "", "", true},
+		{"no frontmatter keeps whitespace", "  # Title\n\ntext\n", "", "  # Title\n\ntext\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := Parse([]byte(tt.input))
+			if tt.err {
+				if !errors.Is(err, ErrInvalidFrontmatter) {
+					t.Fatalf("Parse() error = %v, want ErrInvalidFrontmatter", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if tt.title == "" && fm != nil {
+				t.Errorf("Parse() = %+v, want no frontmatter", fm)
+			}
+			if tt.title != "" && (fm == nil || fm.Title != tt.title) {
+				t.Errorf("Parse() = %+v, want title %q", fm, tt.title)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseAliasInCustomField(t *testing.T) {
+	content := "---\ntitle: &t Anchored\nsource: claude\nseries: *t\n---\nbody"
+	fm, _, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	rendered, err := fm.RenderWithContent([]byte("body"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	again, _, err := Parse(rendered)
+	if err != nil {
+		t.Fatalf("Parse(rendered) error = %v\n%s", err, rendered)
+	}
+	if again.Metadata()["series"] != "Anchored" {
+		t.Errorf("series = %v, want Anchored", again.Metadata()["series"])
+	}
+}
+
+func TestParseAliasBomb(t *testing.T) {
+	// Each level refers to the previous one nine times: 9^9 values
+	var sb strings.Builder
+	sb.WriteString("---\ntitle: Bomb\nsource: claude\na0: &a0 [x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i < 10; i++ {
+		p := fmt.Sprintf("*a%d", i-1)
+		fmt.Fprintf(&sb, "a%d: &a%d [%s]\n", i, i, strings.Repeat(p+", ", 8)+p)
+	}
+	sb.WriteString("---\nbody")
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := Parse([]byte(sb.String()))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrInvalidFrontmatter) || !strings.Contains(err.Error(), ErrTooManyAliases.Error()) {
+			t.Errorf("Parse() of an alias bomb error = %v, want too many aliases", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Parse() of an alias bomb did not return")
+	}
+}
+
+// FuzzParse checks that Split never loses bytes, and that rendering parsed
+// frontmatter yields a document that parses back to the same body.
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"---\ntitle: x\n---\n\nbody\n",
+		"---\r\ntitle: x\r\n---\r\nbody",
+		"\xef\xbb\xbf---\ntitle: x\n---\n",
+		"+++\ntitle = \"x\"\n[params]\na = 1\n+++\nbody",
+		"{\"title\": \"x\", \"tags\": [\"a\"]}\nbody",
+		"---\ntitle: a---b\nextra: {nested: [1, 2]}\n---\n---\n",
+		"# no frontmatter\n---\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		header, body, ok := Split(content)
+		if !bytes.Equal(append(bytes.Clone(header), body...), content) {
+			t.Fatalf("Split(%q) = %q + %q, lost bytes", content, header, body)
+		}
+
+		fm, parsedBody, err := Parse(content)
+		if err != nil || fm == nil {
+			return
+		}
+		if !ok || !bytes.Equal(parsedBody, body) {
+			t.Fatalf("Parse(%q) body = %q, Split body = %q", content, parsedBody, body)
+		}
+
+		rendered, err := fm.Render()
+		if err != nil {
+			return // values YAML cannot represent
+		}
+		again, againBody, err := Parse(append(rendered, body...))
+		if err != nil {
+			t.Fatalf("Parse(Render()) error = %v\nrendered: %q", err, rendered)
+		}
+		if again == nil || !bytes.Equal(againBody, body) {
+			t.Fatalf("round trip body = %q, want %q\nrendered: %q", againBody, body, rendered)
+		}
+	})
+}
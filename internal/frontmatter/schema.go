@@ -0,0 +1,128 @@
+package frontmatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:generate sh -c "go run ../../cmd/chathub schema > ../../schema/frontmatter.schema.json"
+
+// SchemaVersion is the version of the frontmatter schema written by New.
+// Increment it when fields change meaning, and teach Upgrade to convert
+// older files.
+const SchemaVersion = 1
+
+// schemaProperty is one property of the JSON Schema.
+type schemaProperty struct {
+	Type        string          `json:"type"`
+	Description string          `json:"description,omitempty"`
+	AnyOf       []schemaFormat  `json:"anyOf,omitempty"`
+	Pattern     string          `json:"pattern,omitempty"`
+	Examples    []string        `json:"examples,omitempty"`
+	Minimum     *int            `json:"minimum,omitempty"`
+	Maximum     *int            `json:"maximum,omitempty"`
+	Items       *schemaProperty `json:"items,omitempty"`
+	MinLength   int             `json:"minLength,omitempty"`
+}
+
+type schemaFormat struct {
+	Format string `json:"format"`
+}
+
+// schemaConstraints adds Hugo and ChatHub rules the Go types cannot express.
+var schemaConstraints = map[string]func(*schemaProperty){
+	"title": func(p *schemaProperty) { p.MinLength = 1 },
+	"slug":  func(p *schemaProperty) { p.Pattern = `^[^/\s]+$` },
+	"aliases": func(p *schemaProperty) {
+		p.Items.Pattern = `^\S+$`
+	},
+	"source": func(p *schemaProperty) { p.Examples = SourceIDs() },
+	"schema_version": func(p *schemaProperty) {
+		p.Minimum, p.Maximum = intPtr(1), intPtr(SchemaVersion)
+	},
+	"message_count": func(p *schemaProperty) { p.Minimum = intPtr(0) },
+	"tokens":        func(p *schemaProperty) { p.Minimum = intPtr(0) },
+	"tokenizer":     func(p *schemaProperty) { p.Examples = []string{"cl100k_base", "o200k_base", "heuristic"} },
+	"cost":          func(p *schemaProperty) { p.Minimum = intPtr(0) },
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for frontmatter,
+// generated from the Frontmatter type. Unknown keys are allowed, since
+// they are preserved.
+func JSONSchema() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	buf.WriteString(`  "$schema": "https://json-schema.org/draft/2020-12/schema",` + "\n")
+	buf.WriteString(`  "title": "ChatHub conversation frontmatter",` + "\n")
+	buf.WriteString(`  "description": "Hugo-compatible frontmatter with ChatHub extensions, schema version ` + strconv.Itoa(SchemaVersion) + `",` + "\n")
+	buf.WriteString(`  "type": "object",` + "\n")
+
+	// Properties are written in field order, which json.Marshal of a map
+	// would not keep
+	var required []string
+	buf.WriteString(`  "properties": {` + "\n")
+	t := reflect.TypeFor[Frontmatter]()
+	first := true
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if opts != "omitempty" {
+			required = append(required, name)
+		}
+
+		prop := schemaFor(field.Type)
+		prop.Description = field.Tag.Get("jsonschema")
+		if constrain := schemaConstraints[name]; constrain != nil {
+			constrain(&prop)
+		}
+		data, err := json.MarshalIndent(prop, "    ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteString(",\n")
+		}
+		first = false
+		buf.WriteString(`    "` + name + `": `)
+		buf.Write(data)
+	}
+	buf.WriteString("\n  },\n")
+
+	data, err := json.Marshal(required)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(`  "required": ` + strings.ReplaceAll(string(data), ",", ", ") + ",\n")
+	buf.WriteString(`  "additionalProperties": true` + "\n")
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func schemaFor(t reflect.Type) schemaProperty {
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return schemaProperty{Type: "string", AnyOf: []schemaFormat{{"date-time"}, {"date"}}}
+	case t.Kind() == reflect.Bool:
+		return schemaProperty{Type: "boolean"}
+	case t.Kind() == reflect.Int:
+		return schemaProperty{Type: "integer"}
+	case t.Kind() == reflect.Float64:
+		return schemaProperty{Type: "number"}
+	case t.Kind() == reflect.Slice:
+		items := schemaFor(t.Elem())
+		return schemaProperty{Type: "array", Items: &items}
+	default:
+		return schemaProperty{Type: "string"}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
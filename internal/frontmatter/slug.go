@@ -0,0 +1,135 @@
+package frontmatter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-unidecode"
+)
+
+// SlugMode is how GenerateSlug treats characters outside ASCII.
+type SlugMode string
+
+// Slug modes
+const (
+	SlugASCII   SlugMode = "ascii"   // transliterate to ASCII, e.g. "Привет" → "privet"
+	SlugUnicode SlugMode = "unicode" // keep letters in any script, e.g. "Привет" → "привет"
+)
+
+// SlugModes lists the supported slug modes.
+var SlugModes = []SlugMode{SlugASCII, SlugUnicode}
+
+// MaxSlugLength is the maximum length of a generated slug in characters.
+const MaxSlugLength = 50
+
+var (
+	// slugRegex matches non-alphanumeric characters for slug generation
+	slugRegex = regexp.MustCompile(`[^a-z0-9\s-]`)
+	// whitespaceRegex matches whitespace for slug generation
+	whitespaceRegex = regexp.MustCompile(`[\s_]+`)
+
+	// defaultSlugMode is the mode used by GenerateSlug
+	defaultSlugMode atomic.Value
+)
+
+func init() {
+	defaultSlugMode.Store(SlugASCII)
+}
+
+// ParseSlugMode parses a slug mode name. An empty name is SlugASCII.
+func ParseSlugMode(name string) (SlugMode, error) {
+	if name == "" {
+		return SlugASCII, nil
+	}
+	mode := SlugMode(strings.ToLower(name))
+	if !slices.Contains(SlugModes, mode) {
+		return "", fmt.Errorf("invalid slug mode %q: must be ascii or unicode", name)
+	}
+	return mode, nil
+}
+
+// SetSlugMode sets the mode used by GenerateSlug.
+func SetSlugMode(mode SlugMode) {
+	defaultSlugMode.Store(mode)
+}
+
+// CurrentSlugMode returns the mode used by GenerateSlug.
+func CurrentSlugMode() SlugMode {
+	return defaultSlugMode.Load().(SlugMode)
+}
+
+// GenerateSlug creates a URL-friendly slug from a title using the mode set
+// by SetSlugMode. It returns "" if the title has no letters or digits.
+func GenerateSlug(title string) string {
+	return GenerateSlugMode(title, CurrentSlugMode())
+}
+
+// GenerateSlugMode creates a URL-friendly slug from a title. Slugs longer
+// than MaxSlugLength characters are cut at the last word boundary that
+// fits.
+//
+// In SlugASCII mode the title is transliterated, so "日本語" becomes
+// "ri-ben-yu". In SlugUnicode mode letters and digits of any script are
+// kept as lowercase; Hugo percent-encodes them in URLs.
+func GenerateSlugMode(title string, mode SlugMode) string {
+	var slug string
+	if mode == SlugUnicode {
+		slug = unicodeSlug(title)
+	} else {
+		slug = strings.ToLower(unidecode.Unidecode(title))
+		slug = slugRegex.ReplaceAllString(slug, "")
+		slug = whitespaceRegex.ReplaceAllString(slug, "-")
+		slug = strings.Trim(slug, "-")
+	}
+	return truncateSlug(slug, MaxSlugLength)
+}
+
+// unicodeSlug keeps letters, digits and combining marks, and joins words
+// with single hyphens.
+func unicodeSlug(title string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '_' || r == '-':
+			pendingHyphen = true
+		}
+	}
+	return b.String()
+}
+
+// truncateSlug shortens slug to at most max runes, cutting at a hyphen
+// when there is one, so words are not split.
+func truncateSlug(slug string, max int) string {
+	if utf8.RuneCountInString(slug) <= max {
+		return slug
+	}
+	cut := 0
+	for range max {
+		_, size := utf8.DecodeRuneInString(slug[cut:])
+		cut += size
+	}
+	if slug[cut] != '-' {
+		if i := strings.LastIndexByte(slug[:cut], '-'); i > 0 {
+			cut = i
+		}
+	}
+	return strings.TrimRight(slug[:cut], "-")
+}
+
+// idSlug returns a slug for a conversation ID, used when the title has
+// none.
+func idSlug(id string) string {
+	return GenerateSlugMode(id, SlugASCII)
+}
@@ -0,0 +1,97 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerateSlugMode(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  SlugMode
+		want  string
+	}{
+		{"Café au lait", SlugASCII, "cafe-au-lait"},
+		{"Привет, мир", SlugASCII, "privet-mir"},
+		{"日本語のテスト", SlugASCII, "ri-ben-yu-notesuto"},
+		{"Straße und Weg", SlugASCII, "strasse-und-weg"},
+		{"Привет, мир", SlugUnicode, "привет-мир"},
+		{"日本語のテスト", SlugUnicode, "日本語のテスト"},
+		{"Café — au  lait!", SlugUnicode, "café-au-lait"},
+		{"  --Hello_World--  ", SlugUnicode, "hello-world"},
+		{"!!!", SlugUnicode, ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"/"+tt.input, func(t *testing.T) {
+			if got := GenerateSlugMode(tt.input, tt.mode); got != tt.want {
+				t.Errorf("GenerateSlugMode(%q, %s) = %q, want %q", tt.input, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSlugTruncation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		mode  SlugMode
+		want  string
+	}{
+		{"word boundary", strings.Repeat("word ", 20), SlugASCII, strings.TrimSuffix(strings.Repeat("word-", 10), "-")},
+		{"single long word", strings.Repeat("a", 80), SlugASCII, strings.Repeat("a", MaxSlugLength)},
+		{"multibyte without hyphens", strings.Repeat("語", 80), SlugUnicode, strings.Repeat("語", MaxSlugLength)},
+		{"multibyte words", strings.Repeat("привет ", 10), SlugUnicode, strings.TrimSuffix(strings.Repeat("привет-", 7), "-")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateSlugMode(tt.input, tt.mode)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > MaxSlugLength {
+				t.Errorf("%q is not a valid slug of at most %d characters", got, MaxSlugLength)
+			}
+		})
+	}
+}
+
+func TestParseSlugMode(t *testing.T) {
+	for name, want := range map[string]SlugMode{"": SlugASCII, "ascii": SlugASCII, "Unicode": SlugUnicode} {
+		got, err := ParseSlugMode(name)
+		if err != nil || got != want {
+			t.Errorf("ParseSlugMode(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseSlugMode("emoji"); err == nil {
+		t.Error("ParseSlugMode(\"emoji\") should fail")
+	}
+}
+
+func TestSlugFallsBackToConversationID(t *testing.T) {
+	fm := New("!!!", SourceClaude)
+	if fm.Slug == "" || fm.Slug != idSlug(fm.ConversationID) {
+		t.Errorf("New slug = %q, want one derived from %q", fm.Slug, fm.ConversationID)
+	}
+
+	fm = &Frontmatter{Title: "???", Source: SourceClaude, ConversationID: "conv_42"}
+	if path := defaultLayout.Path("conversations", fm); path != "conversations/claude/0001-01-01_conv42.md" {
+		t.Errorf("Path() = %q", path)
+	}
+	Upgrade(fm)
+	if fm.Slug != "conv42" {
+		t.Errorf("Upgrade slug = %q, want conv42", fm.Slug)
+	}
+}
+
+func TestSetSlugMode(t *testing.T) {
+	defer SetSlugMode(CurrentSlugMode())
+	SetSlugMode(SlugUnicode)
+	if got := GenerateSlug("Привет"); got != "привет" {
+		t.Errorf("GenerateSlug() = %q in unicode mode", got)
+	}
+	SetSlugMode(SlugASCII)
+	if got := GenerateSlug("Привет"); got != "privet" {
+		t.Errorf("GenerateSlug() = %q in ascii mode", got)
+	}
+}
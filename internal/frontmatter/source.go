@@ -0,0 +1,177 @@
+package frontmatter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Source platform identifiers
+const (
+	SourceChatGPT    = "chatgpt"
+	SourceClaude     = "claude"
+	SourceClaudeCode = "claude-code"
+	SourceGemini     = "gemini"
+	SourcePerplexity = "perplexity"
+	SourceCodex      = "codex"
+)
+
+// SourceInfo describes a source platform in the source registry.
+type SourceInfo struct {
+	// ID is the value of the source field, e.g. "claude-code".
+	ID string `json:"id"`
+
+	// Name is the display name. Defaults to ID.
+	Name string `json:"name,omitempty"`
+
+	// Author is the default author of new conversations. Defaults to ID.
+	Author string `json:"author,omitempty"`
+
+	// Folder is the value of {source} in path layouts. Defaults to ID.
+	Folder string `json:"folder,omitempty"`
+
+	// Participants are the default participant labels of new
+	// conversations, e.g. ["User", "Claude"].
+	Participants []string `json:"participants,omitempty"`
+}
+
+// sourceIDRegex matches valid source IDs and folders
+var sourceIDRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// builtinSources are registered by default.
+var builtinSources = []SourceInfo{
+	{ID: SourceChatGPT, Name: "ChatGPT", Participants: []string{"User", "ChatGPT"}},
+	{ID: SourceClaude, Name: "Claude", Participants: []string{"User", "Claude"}},
+	{ID: SourceClaudeCode, Name: "Claude Code", Participants: []string{"User", "Claude"}},
+	{ID: SourceGemini, Name: "Gemini", Participants: []string{"User", "Gemini"}},
+	{ID: SourcePerplexity, Name: "Perplexity", Participants: []string{"User", "Perplexity"}},
+	{ID: SourceCodex, Name: "Codex", Participants: []string{"User", "Codex"}},
+}
+
+var registry = struct {
+	sync.RWMutex
+	sources []SourceInfo
+}{sources: withSourceDefaults(builtinSources)}
+
+// RegisterSource adds a source to the registry. If a source with the same
+// ID is registered, such as a built-in, the fields set in info replace
+// its fields and the others are kept, so built-ins can be customized.
+func RegisterSource(info SourceInfo) error {
+	if !sourceIDRegex.MatchString(info.ID) {
+		return fmt.Errorf("invalid source ID %q: use lowercase letters, digits, '.', '_' and '-'", info.ID)
+	}
+	if info.Folder != "" && !sourceIDRegex.MatchString(info.Folder) {
+		return fmt.Errorf("invalid folder %q for source %s", info.Folder, info.ID)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	i := slices.IndexFunc(registry.sources, func(s SourceInfo) bool { return s.ID == info.ID })
+	if i < 0 {
+		registry.sources = append(registry.sources, withSourceDefaults([]SourceInfo{info})[0])
+		return nil
+	}
+	existing := &registry.sources[i]
+	if info.Name != "" {
+		existing.Name = info.Name
+	}
+	if info.Author != "" {
+		existing.Author = info.Author
+	}
+	if info.Folder != "" {
+		existing.Folder = info.Folder
+	}
+	if len(info.Participants) > 0 {
+		existing.Participants = slices.Clone(info.Participants)
+	}
+	return nil
+}
+
+// Sources returns the registered sources, built-ins first.
+func Sources() []SourceInfo {
+	registry.RLock()
+	defer registry.RUnlock()
+	return slices.Clone(registry.sources)
+}
+
+// SourceIDs returns the IDs of the registered sources.
+func SourceIDs() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	ids := make([]string, len(registry.sources))
+	for i, s := range registry.sources {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// LookupSource returns the registered source with the given ID.
+func LookupSource(id string) (SourceInfo, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, s := range registry.sources {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return SourceInfo{}, false
+}
+
+// ResolveSource finds a registered source by ID, display name or folder,
+// ignoring case.
+func ResolveSource(name string) (SourceInfo, bool) {
+	if s, ok := LookupSource(name); ok {
+		return s, true
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, s := range registry.sources {
+		if strings.EqualFold(s.ID, name) || strings.EqualFold(s.Name, name) || strings.EqualFold(s.Folder, name) {
+			return s, true
+		}
+	}
+	return SourceInfo{}, false
+}
+
+// CanonicalSource returns the ID of the source name resolves to, or name
+// unchanged if it is not registered.
+func CanonicalSource(name string) string {
+	if s, ok := ResolveSource(name); ok {
+		return s.ID
+	}
+	return name
+}
+
+// ValidSource checks if a source is registered.
+func ValidSource(source string) bool {
+	_, ok := LookupSource(source)
+	return ok
+}
+
+// sourceFolder returns the {source} layout value for a source ID.
+func sourceFolder(id string) string {
+	if s, ok := LookupSource(id); ok {
+		return s.Folder
+	}
+	return GenerateSlug(id)
+}
+
+func withSourceDefaults(sources []SourceInfo) []SourceInfo {
+	out := make([]SourceInfo, len(sources))
+	for i, s := range sources {
+		if s.Name == "" {
+			s.Name = s.ID
+		}
+		if s.Author == "" {
+			s.Author = s.ID
+		}
+		if s.Folder == "" {
+			s.Folder = s.ID
+		}
+		s.Participants = slices.Clone(s.Participants)
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package frontmatter
+
+import (
+	"testing"
+	"time"
+)
+
+// resetSources restores the built-in source registry.
+func resetSources() {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.sources = withSourceDefaults(builtinSources)
+}
+
+func TestRegisterSource(t *testing.T) {
+	t.Cleanup(resetSources)
+
+	if err := RegisterSource(SourceInfo{
+		ID:           "ollama",
+		Name:         "Ollama",
+		Author:       "local-llm",
+		Folder:       "local",
+		Participants: []string{"Me", "Llama"},
+	}); err != nil {
+		t.Fatalf("RegisterSource() error = %v", err)
+	}
+	if !ValidSource("ollama") {
+		t.Error("ValidSource(ollama) = false after registering")
+	}
+
+	fm := New("Hi", "ollama")
+	if fm.Author != "local-llm" || len(fm.Participants) != 2 || fm.Participants[1] != "Llama" {
+		t.Errorf("New() = %+v, want registry defaults", fm)
+	}
+
+	fm.Date = time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	if got := defaultLayout.Path("c", fm); got != "c/local/2026-01-10_hi.md" {
+		t.Errorf("Path() = %q, want the source folder", got)
+	}
+
+	for _, name := range []string{"ollama", "Ollama", "LOCAL"} {
+		if info, ok := ResolveSource(name); !ok || info.ID != "ollama" {
+			t.Errorf("ResolveSource(%q) = %v, %v", name, info, ok)
+		}
+	}
+	if got := CanonicalSource("Claude Code"); got != SourceClaudeCode {
+		t.Errorf("CanonicalSource(Claude Code) = %q", got)
+	}
+}
+
+func TestRegisterSourceOverridesBuiltin(t *testing.T) {
+	t.Cleanup(resetSources)
+
+	if err := RegisterSource(SourceInfo{ID: SourceClaude, Name: "Anthropic Claude"}); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := LookupSource(SourceClaude)
+	if info.Name != "Anthropic Claude" || info.Author != SourceClaude || info.Folder != SourceClaude {
+		t.Errorf("LookupSource(claude) = %+v", info)
+	}
+	if len(info.Participants) != 2 || info.Participants[1] != "Claude" {
+		t.Errorf("participants = %v, want the built-in participants kept", info.Participants)
+	}
+
+	if err := RegisterSource(SourceInfo{ID: SourceClaude, Folder: "anthropic"}); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = LookupSource(SourceClaude)
+	if info.Name != "Anthropic Claude" || info.Folder != "anthropic" || len(info.Participants) != 2 {
+		t.Errorf("LookupSource(claude) after a second override = %+v, want both overrides", info)
+	}
+	if len(SourceIDs()) != len(builtinSources) {
+		t.Errorf("SourceIDs() = %v, want no new source", SourceIDs())
+	}
+}
+
+func TestRegisterSourceInvalid(t *testing.T) {
+	t.Cleanup(resetSources)
+
+	for _, info := range []SourceInfo{{ID: ""}, {ID: "Le Chat"}, {ID: "ok", Folder: "../x"}, {ID: ".hidden"}} {
+		if err := RegisterSource(info); err == nil {
+			t.Errorf("RegisterSource(%+v) error = nil", info)
+		}
+	}
+}
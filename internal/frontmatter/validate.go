@@ -0,0 +1,192 @@
+package frontmatter
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how serious a validation issue is.
+type Severity string
+
+// Issue severities
+const (
+	SeverityError   Severity = "error"   // the file is invalid or breaks Hugo
+	SeverityWarning Severity = "warning" // the file works but should be fixed
+)
+
+// Issue is a problem found by Validate.
+type Issue struct {
+	Field    string   `json:"field,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Field == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+// fieldTypes maps the YAML keys of Frontmatter to their Go types.
+var fieldTypes = func() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	t := reflect.TypeFor[Frontmatter]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			types[name] = t.Field(i).Type
+		}
+	}
+	return types
+}()
+
+// Validate checks a document's frontmatter against the schema and against
+// what Hugo accepts. It returns the frontmatter if it could be decoded.
+func Validate(content []byte) (*Frontmatter, []Issue) {
+	format, header, _, ok := split(content)
+	if !ok {
+		rest := content[preambleLen(content):]
+		first, _, _ := bytes.Cut(rest, []byte("\n"))
+		if isDelimiterLine(first, frontmatterDelimiter) || isDelimiterLine(first, tomlDelimiter) {
+			return nil, []Issue{{Severity: SeverityError, Message: "frontmatter has no closing delimiter"}}
+		}
+		return nil, []Issue{{Severity: SeverityError, Message: "no frontmatter"}}
+	}
+
+	node, err := headerNode(format, header)
+	if err != nil {
+		return nil, []Issue{{Severity: SeverityError, Message: fmt.Sprintf("invalid %s: %v", strings.ToUpper(string(format)), err)}}
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, []Issue{{Severity: SeverityError, Message: "frontmatter is not a mapping"}}
+	}
+
+	var issues []Issue
+	add := func(field string, severity Severity, format string, args ...any) {
+		issues = append(issues, Issue{Field: field, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Check each key's type, so one bad value is reported by name rather
+	// than as a decoding error for the whole header
+	seen := make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, value := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		if keyNode.Kind != yaml.ScalarNode || keyNode.ShortTag() != "!!str" {
+			add(key, SeverityWarning, "key is not a string; Hugo ignores it")
+			continue
+		}
+		if other, ok := seen[strings.ToLower(key)]; ok {
+			add(key, SeverityError, "duplicates %q; Hugo treats keys case-insensitively", other)
+		}
+		seen[strings.ToLower(key)] = key
+
+		t, known := fieldTypes[key]
+		if !known {
+			continue
+		}
+		if err := value.Decode(reflect.New(t).Interface()); err != nil {
+			add(key, SeverityError, "%s", typeMessage(t, value))
+		}
+	}
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return nil, issues
+		}
+	}
+
+	fm := &Frontmatter{}
+	if err := node.Decode(fm); err != nil {
+		return nil, append(issues, Issue{Severity: SeverityError, Message: err.Error()})
+	}
+	fm.Format = format
+
+	if strings.TrimSpace(fm.Title) == "" {
+		add("title", SeverityError, "missing title")
+	}
+	if fm.Date.IsZero() {
+		add("date", SeverityError, "missing date")
+	} else if fm.Date.After(time.Now().Add(24 * time.Hour)) {
+		add("date", SeverityWarning, "%s is in the future; Hugo does not publish it by default", fm.Date.Format(time.RFC3339))
+	}
+	if !fm.LastMod.IsZero() && fm.LastMod.Before(fm.Date) {
+		add("lastmod", SeverityWarning, "is before date")
+	}
+	switch {
+	case fm.Source == "":
+		add("source", SeverityError, "missing source")
+	case !ValidSource(fm.Source):
+		add("source", SeverityError, "unknown source %q", fm.Source)
+	}
+	if fm.ConversationID == "" {
+		add("conversation_id", SeverityWarning, "missing conversation_id")
+	}
+	switch {
+	case fm.SchemaVersion == 0:
+		add("schema_version", SeverityWarning, "missing; run chathub migrate to upgrade")
+	case fm.SchemaVersion > SchemaVersion:
+		add("schema_version", SeverityError, "version %d is newer than the supported version %d", fm.SchemaVersion, SchemaVersion)
+	case fm.SchemaVersion < SchemaVersion:
+		add("schema_version", SeverityWarning, "version %d is older than %d; run chathub migrate to upgrade", fm.SchemaVersion, SchemaVersion)
+	}
+	if strings.ContainsAny(fm.Slug, "/ \t\n") {
+		add("slug", SeverityError, "%q contains a slash or whitespace, which Hugo cannot use in a URL", fm.Slug)
+	}
+	for _, alias := range fm.Aliases {
+		if alias == "" || strings.ContainsAny(alias, " \t\n") {
+			add("aliases", SeverityError, "%q is not a valid URL path", alias)
+		}
+	}
+	for _, list := range []struct {
+		field  string
+		values []string
+	}{{"tags", fm.Tags}, {"categories", fm.Categories}} {
+		for _, v := range list.values {
+			if strings.TrimSpace(v) == "" {
+				add(list.field, SeverityWarning, "contains an empty value")
+			}
+		}
+	}
+	if fm.MessageCount < 0 {
+		add("message_count", SeverityError, "is negative")
+	}
+	if fm.Tokens < 0 {
+		add("tokens", SeverityError, "is negative")
+	}
+	if fm.Cost < 0 {
+		add("cost", SeverityError, "is negative")
+	}
+
+	return fm, issues
+}
+
+// typeMessage describes the value a field of type t requires.
+func typeMessage(t reflect.Type, value *yaml.Node) string {
+	got := value.Value
+	if value.Kind != yaml.ScalarNode {
+		got = "a " + map[yaml.Kind]string{yaml.MappingNode: "mapping", yaml.SequenceNode: "list", yaml.AliasNode: "alias"}[value.Kind]
+	} else {
+		got = fmt.Sprintf("%q", got)
+	}
+
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return "invalid date " + got
+	case t.Kind() == reflect.Bool:
+		return "must be true or false, got " + got
+	case t.Kind() == reflect.Int:
+		return "must be an integer, got " + got
+	case t.Kind() == reflect.Float64:
+		return "must be a number, got " + got
+	case t.Kind() == reflect.Slice:
+		return "must be a list of strings, got " + got
+	default:
+		return "must be a string, got " + got
+	}
+}
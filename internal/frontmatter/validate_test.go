@@ -0,0 +1,78 @@
+package frontmatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := "---\ntitle: x\ndate: 2026-01-10T09:00:00Z\nsource: claude\nconversation_id: conv_1\nschema_version: 1\n---\nbody"
+
+	tests := []struct {
+		name    string
+		content string
+		field   string // field of the expected issue; empty means valid
+		sev     Severity
+	}{
+		{"valid", valid, "", ""},
+		{"missing title", "---\ndate: 2026-01-10\nsource: claude\nconversation_id: c\nschema_version: 1\n---\n", "title", SeverityError},
+		{"invalid date", "---\ntitle: x\ndate: yesterday\nsource: claude\n---\n", "date", SeverityError},
+		{"unknown source", "---\ntitle: x\ndate: 2026-01-10\nsource: bard\nconversation_id: c\nschema_version: 1\n---\n", "source", SeverityError},
+		{"tags not a list", "---\ntitle: x\ndate: 2026-01-10\nsource: claude\ntags: {a: b}\n---\n", "tags", SeverityError},
+		{"slug with slash", "---\ntitle: x\ndate: 2026-01-10\nsource: claude\nslug: a/b\nconversation_id: c\nschema_version: 1\n---\n", "slug", SeverityError},
+		{"case-insensitive duplicate", "---\ntitle: x\nTitle: y\ndate: 2026-01-10\nsource: claude\n---\n", "Title", SeverityError},
+		{"newer schema", "---\ntitle: x\ndate: 2026-01-10\nsource: claude\nconversation_id: c\nschema_version: 99\n---\n", "schema_version", SeverityError},
+		{"old schema", "---\ntitle: x\ndate: 2026-01-10\nsource: claude\nconversation_id: c\n---\n", "schema_version", SeverityWarning},
+		{"future date", "---\ntitle: x\ndate: 2999-01-10\nsource: claude\nconversation_id: c\nschema_version: 1\n---\n", "date", SeverityWarning},
+		{"toml", "+++\ntitle = \"x\"\ndate = 2026-01-10\nsource = \"claude\"\nconversation_id = \"c\"\nschema_version = 1\n+++\n", "", ""},
+		{"unterminated", "---\ntitle: x\n", "", SeverityError},
+		{"no frontmatter", "# Title\n", "", SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := Validate([]byte(tt.content))
+			if tt.sev == "" {
+				if len(issues) != 0 {
+					t.Errorf("Validate() = %v, want no issues", issues)
+				}
+				return
+			}
+			for _, issue := range issues {
+				if issue.Field == tt.field && issue.Severity == tt.sev {
+					return
+				}
+			}
+			t.Errorf("Validate() = %v, want %s on %q", issues, tt.sev, tt.field)
+		})
+	}
+}
+
+func TestNewIsValid(t *testing.T) {
+	content, err := New("Hello", SourceClaude).RenderWithContent([]byte("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, issues := Validate(content); len(issues) != 0 {
+		t.Errorf("Validate(New()) = %v, want no issues", issues)
+	}
+}
+
+func TestJSONSchemaUpToDate(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	if !json.Valid(schema) {
+		t.Fatalf("JSONSchema() is not valid JSON:\n%s", schema)
+	}
+	published, err := os.ReadFile("../../schema/frontmatter.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(schema, published) {
+		t.Error("schema/frontmatter.schema.json is out of date; run go generate ./internal/frontmatter")
+	}
+}
@@ -0,0 +1,187 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Built-in detector names
+const (
+	DetectorAWSAccessKey = "aws_access_key"
+	DetectorAWSSecretKey = "aws_secret_key"
+	DetectorGitHubToken  = "github_token"
+	DetectorAPIKey       = "api_key"
+	DetectorSlackToken   = "slack_token"
+	DetectorJWT          = "jwt"
+	DetectorPrivateKey   = "private_key"
+	DetectorEnvSecret    = "env_secret"
+	DetectorEmail        = "email"
+	DetectorHighEntropy  = "high_entropy"
+)
+
+// Detector finds sensitive values in text.
+type Detector interface {
+	// Name identifies the detector in reports and placeholders.
+	Name() string
+	// Find returns the [start, end) byte offsets of each sensitive value.
+	Find(text string) [][2]int
+}
+
+// builtinPatterns maps built-in detector names to their regexes. If a
+// pattern has a capture group, only the first group is treated as sensitive.
+var builtinPatterns = []struct {
+	name    string
+	pattern string
+}{
+	{DetectorPrivateKey, `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{DetectorAWSAccessKey, `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{DetectorAWSSecretKey, `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`},
+	{DetectorGitHubToken, `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`},
+	{DetectorAPIKey, `\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`},
+	{DetectorSlackToken, `\bxox[abprs]-[A-Za-z0-9-]{10,}\b`},
+	{DetectorJWT, `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{DetectorEnvSecret, `(?m)^[ \t]*(?:export[ \t]+)?[A-Za-z_][A-Za-z0-9_]*(?i:secret|token|passw(?:or)?d|api_?key|private_?key|access_?key|credentials?)[A-Za-z0-9_]*[ \t]*=[ \t]*["']?([^\s"'#]+)`},
+	{DetectorEmail, `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+}
+
+// BuiltinDetectorNames returns the names of all built-in detectors.
+func BuiltinDetectorNames() []string {
+	names := make([]string, 0, len(builtinPatterns)+1)
+	for _, p := range builtinPatterns {
+		names = append(names, p.name)
+	}
+	return append(names, DetectorHighEntropy)
+}
+
+// BuiltinDetectors returns the named built-in detectors, or all of them if
+// no names are given.
+func BuiltinDetectors(names ...string) ([]Detector, error) {
+	all := make(map[string]Detector, len(builtinPatterns)+1)
+	for _, p := range builtinPatterns {
+		all[p.name] = &patternDetector{name: p.name, re: regexp.MustCompile(p.pattern)}
+	}
+	all[DetectorHighEntropy] = NewEntropyDetector(DefaultEntropyMinLength, DefaultEntropyThreshold)
+
+	if len(names) == 0 {
+		names = BuiltinDetectorNames()
+	}
+
+	detectors := make([]Detector, 0, len(names))
+	for _, name := range names {
+		d, ok := all[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction detector: %s", name)
+		}
+		detectors = append(detectors, d)
+	}
+	return detectors, nil
+}
+
+// patternDetector matches a regular expression.
+type patternDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// NewPatternDetector creates a detector from a regular expression. If the
+// pattern has a capture group, only the first group is treated as sensitive.
+func NewPatternDetector(name, pattern string) (Detector, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction pattern %s: %w", name, err)
+	}
+	return &patternDetector{name: name, re: re}, nil
+}
+
+func (d *patternDetector) Name() string {
+	return d.name
+}
+
+func (d *patternDetector) Find(text string) [][2]int {
+	var locs [][2]int
+	for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+		// Prefer the first capture group when present and matched
+		if len(m) >= 4 && m[2] >= 0 {
+			locs = append(locs, [2]int{m[2], m[3]})
+			continue
+		}
+		locs = append(locs, [2]int{m[0], m[1]})
+	}
+	return locs
+}
+
+// Entropy detector defaults
+const (
+	DefaultEntropyMinLength = 32
+	DefaultEntropyThreshold = 4.2
+)
+
+// entropyTokenRegex matches candidate secret tokens. Slashes are excluded so
+// file paths and URLs are not flagged.
+var entropyTokenRegex = regexp.MustCompile(`[A-Za-z0-9+=_-]+`)
+
+// entropyDetector flags long tokens with high Shannon entropy.
+type entropyDetector struct {
+	minLength int
+	threshold float64
+}
+
+// NewEntropyDetector creates a detector for tokens of at least minLength
+// characters whose Shannon entropy is at least threshold bits per character.
+func NewEntropyDetector(minLength int, threshold float64) Detector {
+	return &entropyDetector{minLength: minLength, threshold: threshold}
+}
+
+func (d *entropyDetector) Name() string {
+	return DetectorHighEntropy
+}
+
+func (d *entropyDetector) Find(text string) [][2]int {
+	var locs [][2]int
+	for _, loc := range entropyTokenRegex.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		if len(token) < d.minLength || !mixedClasses(token) {
+			continue
+		}
+		if ShannonEntropy(token) >= d.threshold {
+			locs = append(locs, [2]int{loc[0], loc[1]})
+		}
+	}
+	return locs
+}
+
+// ShannonEntropy returns the entropy of s in bits per character.
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+	var entropy float64
+	for _, count := range freq {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// mixedClasses reports whether s contains both letters and digits, which
+// filters out long words and identifiers.
+func mixedClasses(s string) bool {
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			letter = true
+		}
+	}
+	return letter && digit
+}
@@ -0,0 +1,210 @@
+// Package redact detects and removes secrets and personal data from conversation content.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mode controls what happens when sensitive content is detected.
+type Mode string
+
+// Redaction modes
+const (
+	ModeOff    Mode = "off"
+	ModeWarn   Mode = "warn"
+	ModeRedact Mode = "redact"
+	ModeBlock  Mode = "block"
+)
+
+// ErrBlocked indicates that content was rejected because it contains sensitive data.
+var ErrBlocked = errors.New("content contains sensitive data")
+
+// ValidMode checks if a mode is valid.
+func ValidMode(mode Mode) bool {
+	switch mode {
+	case ModeOff, ModeWarn, ModeRedact, ModeBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// Finding records one piece of sensitive content. It never includes the
+// matched value itself.
+type Finding struct {
+	Detector string `json:"detector"`
+	Line     int    `json:"line"`
+}
+
+// Report summarizes the findings for one piece of content.
+type Report struct {
+	Mode     Mode      `json:"mode"`
+	Findings []Finding `json:"findings"`
+}
+
+// Empty reports whether nothing sensitive was found.
+func (r Report) Empty() bool {
+	return len(r.Findings) == 0
+}
+
+// Counts returns the number of findings per detector.
+func (r Report) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Detector]++
+	}
+	return counts
+}
+
+// String renders a short human-readable summary such as "email x2, jwt x1".
+func (r Report) String() string {
+	counts := r.Counts()
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s x%d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Redactor runs a set of detectors over content. A nil Redactor leaves
+// content unchanged.
+type Redactor struct {
+	mode      Mode
+	detectors []Detector
+}
+
+// New creates a Redactor with the given mode and detectors.
+func New(mode Mode, detectors ...Detector) *Redactor {
+	return &Redactor{
+		mode:      mode,
+		detectors: detectors,
+	}
+}
+
+// NewFromConfig creates a Redactor from a mode, the names of built-in
+// detectors to enable (all if empty), and custom name-to-regex patterns.
+func NewFromConfig(mode Mode, builtins []string, patterns map[string]string) (*Redactor, error) {
+	if !ValidMode(mode) {
+		return nil, fmt.Errorf("invalid redaction mode: %s", mode)
+	}
+
+	detectors, err := BuiltinDetectors(builtins...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		d, err := NewPatternDetector(name, patterns[name])
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, d)
+	}
+
+	return New(mode, detectors...), nil
+}
+
+// Mode returns the redaction mode.
+func (r *Redactor) Mode() Mode {
+	if r == nil {
+		return ModeOff
+	}
+	return r.mode
+}
+
+// Apply scans content and returns it with sensitive values handled
+// according to the mode. In ModeBlock, any finding returns ErrBlocked.
+func (r *Redactor) Apply(content string) (string, Report, error) {
+	if r == nil || r.mode == ModeOff {
+		return content, Report{Mode: ModeOff}, nil
+	}
+
+	matches := r.scan(content)
+	report := Report{
+		Mode:     r.mode,
+		Findings: make([]Finding, 0, len(matches)),
+	}
+	for _, m := range matches {
+		report.Findings = append(report.Findings, Finding{
+			Detector: m.detector,
+			Line:     strings.Count(content[:m.start], "\n") + 1,
+		})
+	}
+
+	if report.Empty() {
+		return content, report, nil
+	}
+
+	switch r.mode {
+	case ModeBlock:
+		return content, report, fmt.Errorf("%w: %s", ErrBlocked, report.String())
+	case ModeRedact:
+		return replace(content, matches), report, nil
+	default:
+		return content, report, nil
+	}
+}
+
+type match struct {
+	detector   string
+	start, end int
+}
+
+// scan runs every detector and returns non-overlapping matches in order.
+// When matches overlap, the earliest (and then longest) one wins.
+func (r *Redactor) scan(content string) []match {
+	var all []match
+	for _, d := range r.detectors {
+		for _, loc := range d.Find(content) {
+			all = append(all, match{detector: d.Name(), start: loc[0], end: loc[1]})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].start != all[j].start {
+			return all[i].start < all[j].start
+		}
+		return all[i].end > all[j].end
+	})
+
+	var merged []match
+	for _, m := range all {
+		if len(merged) > 0 && m.start < merged[len(merged)-1].end {
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+func replace(content string, matches []match) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(content[last:m.start])
+		sb.WriteString(Placeholder(m.detector))
+		last = m.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
+
+// Placeholder returns the text substituted for a redacted value.
+func Placeholder(detector string) string {
+	return "[REDACTED:" + detector + "]"
+}
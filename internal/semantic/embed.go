@@ -0,0 +1,78 @@
+// Package semantic finds conversations by meaning rather than exact
+// words. Conversations are split into per-message chunks, embedded as
+// vectors by a pluggable Embedder, cached in a local index, and ranked by
+// combining vector similarity with keyword (BM25) scores.
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Embedder turns texts into vectors. Vectors from one embedder all have
+// the same length and are compared by cosine similarity.
+type Embedder interface {
+	// Name identifies the embedder and its model, so vectors cached for
+	// one are never compared with another's.
+	Name() string
+
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Embedder kinds
+const (
+	EmbedderHashing = "hashing" // built-in, offline
+	EmbedderOpenAI  = "openai"  // OpenAI-compatible /embeddings endpoint
+)
+
+// NewEmbedder returns the embedder of a kind. An empty kind is
+// EmbedderHashing, which uses concepts; EmbedderOpenAI needs the
+// endpoint's base URL and model.
+func NewEmbedder(kind, url, model, apiKey string, concepts [][]string) (Embedder, error) {
+	switch strings.ToLower(kind) {
+	case "", EmbedderHashing:
+		return NewHashingEmbedder(0, concepts), nil
+	case EmbedderOpenAI:
+		if url == "" || model == "" {
+			return nil, fmt.Errorf("the %s embedder needs a URL and a model", EmbedderOpenAI)
+		}
+		return NewOpenAIEmbedder(url, model, apiKey), nil
+	}
+	return nil, fmt.Errorf("invalid embedder %q: must be %s or %s", kind, EmbedderHashing, EmbedderOpenAI)
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// lengths differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// normalize scales v to unit length in place.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
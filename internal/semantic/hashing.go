@@ -0,0 +1,145 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+// DefaultDimensions is the vector length of the hashing embedder.
+const DefaultDimensions = 512
+
+// Feature weights of the hashing embedder
+const (
+	wordWeight    = 1.0
+	conceptWeight = 1.5
+	bigramWeight  = 0.5
+	trigramWeight = 0.3
+)
+
+// HashingEmbedder embeds texts offline by hashing their words, word
+// pairs, character trigrams and concepts into a fixed number of
+// dimensions. It needs no model or network, and vectors only depend on
+// the text.
+//
+// Concepts are groups of words used for the same thing, such as "auth"
+// and "login". Words of a group share a feature, so "auth bug" and "login
+// failure" have similar vectors.
+type HashingEmbedder struct {
+	dims     int
+	concepts map[string]int // word and its stem to concept group
+	name     string
+}
+
+// NewHashingEmbedder returns a hashing embedder with the given number of
+// dimensions, or DefaultDimensions if it is not positive, and concept
+// groups, which may be nil.
+func NewHashingEmbedder(dims int, concepts [][]string) *HashingEmbedder {
+	if dims <= 0 {
+		dims = DefaultDimensions
+	}
+	e := &HashingEmbedder{
+		dims:     dims,
+		concepts: make(map[string]int),
+		name:     fmt.Sprintf("%s-v2-%d", EmbedderHashing, dims),
+	}
+	h := fnv.New64a()
+	for i, group := range concepts {
+		for _, w := range group {
+			w = strings.ToLower(strings.TrimSpace(w))
+			if w == "" {
+				continue
+			}
+			e.concepts[w] = i
+			e.concepts[stem(w)] = i
+			fmt.Fprintf(h, "%d:%s\n", i, w)
+		}
+	}
+	if len(e.concepts) > 0 {
+		// Vectors depend on the concepts, so they are part of the name
+		e.name += fmt.Sprintf("-c%08x", uint32(h.Sum64()))
+	}
+	return e
+}
+
+// Name identifies the embedder.
+func (e *HashingEmbedder) Name() string {
+	return e.name
+}
+
+// Embed returns the vectors of texts.
+func (e *HashingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashingEmbedder) embed(text string) []float32 {
+	features := make(map[string]float64)
+	words := Terms(text)
+	for i, w := range words {
+		features["w:"+w] += wordWeight
+		if c, ok := e.concepts[w]; ok {
+			features[fmt.Sprintf("c:%d", c)] += conceptWeight
+		}
+		if i > 0 {
+			features["b:"+words[i-1]+" "+w] += bigramWeight
+		}
+		padded := []rune("^" + w + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			features["t:"+string(padded[j:j+3])] += trigramWeight
+		}
+	}
+
+	v := make([]float32, e.dims)
+	for feature, weight := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		// Sublinear weights, so repeating a word does not drown out the rest
+		x := float32(1 + math.Log(weight))
+		if weight < 1 {
+			x = float32(weight)
+		}
+		if sum>>63 == 1 {
+			x = -x
+		}
+		v[sum%uint64(e.dims)] += x
+	}
+	normalize(v)
+	return v
+}
+
+// Terms returns the stemmed content words of text, used both for
+// embedding and keyword scoring.
+func Terms(text string) []string {
+	words := conversation.ContentWords(text)
+	for i, w := range words {
+		words[i] = stem(w)
+	}
+	return words
+}
+
+// stem strips common English suffixes, so "failing", "failed" and
+// "fails" match. It is deliberately light; words stay recognizable.
+func stem(w string) string {
+	switch {
+	case len(w) < 5 || strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "ing") && len(w) >= 7:
+		return strings.TrimSuffix(w, "ing")
+	case strings.HasSuffix(w, "ed") && len(w) >= 6:
+		return strings.TrimSuffix(w, "ed")
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "shes"), strings.HasSuffix(w, "ches"), strings.HasSuffix(w, "xes"):
+		return strings.TrimSuffix(w, "es")
+	case strings.HasSuffix(w, "s"):
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
@@ -0,0 +1,182 @@
+package semantic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Index caches the vectors of chunks, keyed by a hash of their text, so
+// only new or changed chunks are embedded. With a file the vectors persist
+// across restarts. The chunks of each document are also kept in memory
+// until the document changes, so unchanged documents are not chunked again.
+type Index struct {
+	embedder Embedder
+	store    string
+	file     string
+
+	mu      sync.Mutex
+	loaded  bool
+	dirty   bool
+	vectors map[string][]float32
+	docs    map[string]*docChunks // by document path
+}
+
+// indexFile is the persisted form of an index.
+type indexFile struct {
+	Embedder string
+	Store    string
+	Vectors  map[string][]float32
+}
+
+// NewIndex returns an index of the conversations in store, which
+// identifies their backend, location and folder, for an embedder. Vectors
+// are persisted in a file in dir, named after the embedder and store, so
+// stores sharing dir keep separate caches; an empty dir keeps them in
+// memory.
+func NewIndex(embedder Embedder, dir, store string) *Index {
+	ix := &Index{embedder: embedder, store: store, vectors: make(map[string][]float32)}
+	if dir != "" {
+		sum := sha256.Sum256([]byte(embedder.Name() + "\x00" + store))
+		ix.file = filepath.Join(dir, "vectors-"+hex.EncodeToString(sum[:8])+".gob")
+	}
+	return ix
+}
+
+// Embedder returns the index's embedder.
+func (ix *Index) Embedder() Embedder {
+	return ix.embedder
+}
+
+// Len returns the number of cached vectors.
+func (ix *Index) Len() int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return len(ix.vectors)
+}
+
+func chunkKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Vectors returns the vectors of texts, embedding those not cached. Cached
+// vectors not among texts are stale for the index's store and dropped, so
+// callers should pass the texts of every chunk in the store.
+func (ix *Index) Vectors(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = chunkKey(text)
+	}
+	return ix.lookup(ctx, keys, texts)
+}
+
+// entryVectors returns the vectors of chunk entries, as Vectors does.
+func (ix *Index) entryVectors(ctx context.Context, entries []chunkEntry) ([][]float32, error) {
+	keys := make([]string, len(entries))
+	texts := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i], texts[i] = e.key, e.text
+	}
+	return ix.lookup(ctx, keys, texts)
+}
+
+// lookup returns the vectors of texts, given their keys.
+func (ix *Index) lookup(ctx context.Context, keys, texts []string) ([][]float32, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := ix.load(); err != nil {
+		return nil, err
+	}
+
+	var missing, missingKeys []string
+	seen := make(map[string]bool, len(texts))
+	for i, key := range keys {
+		if _, ok := ix.vectors[key]; !ok && !seen[key] {
+			missing = append(missing, texts[i])
+			missingKeys = append(missingKeys, key)
+		}
+		seen[key] = true
+	}
+	if len(missing) > 0 {
+		embedded, err := ix.embedder.Embed(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %d chunk(s) with %s: %w", len(missing), ix.embedder.Name(), err)
+		}
+		for i, key := range missingKeys {
+			ix.vectors[key] = embedded[i]
+		}
+		ix.dirty = true
+	}
+	for key := range ix.vectors {
+		if !seen[key] {
+			delete(ix.vectors, key)
+			ix.dirty = true
+		}
+	}
+
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		vectors[i] = ix.vectors[key]
+	}
+	return vectors, ix.save()
+}
+
+// load reads the index file once. A missing file, or one written for
+// another embedder or store, starts an empty index.
+func (ix *Index) load() error {
+	if ix.loaded || ix.file == "" {
+		return nil
+	}
+	ix.loaded = true
+	f, err := os.Open(ix.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer f.Close()
+
+	var data indexFile
+	if err := gob.NewDecoder(f).Decode(&data); err != nil || data.Embedder != ix.embedder.Name() || data.Store != ix.store {
+		// A corrupt or foreign index is rebuilt rather than fatal
+		return nil
+	}
+	ix.vectors = data.Vectors
+	return nil
+}
+
+// save writes the index file if vectors changed, replacing it atomically.
+func (ix *Index) save() error {
+	if !ix.dirty || ix.file == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.file), 0o700); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ix.file), strings.TrimSuffix(filepath.Base(ix.file), ".gob")+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(indexFile{Embedder: ix.embedder.Name(), Store: ix.store, Vectors: ix.vectors}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ix.file); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	ix.dirty = false
+	return nil
+}
@@ -0,0 +1,276 @@
+package semantic
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+// ChunkBytes is the largest chunk embedded; longer messages are split.
+const ChunkBytes = 2000
+
+// DefaultKeywordWeight is the share of the keyword score in hybrid ranking.
+const DefaultKeywordWeight = 0.3
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Document is a conversation to search.
+type Document struct {
+	Path   string
+	Title  string
+	Source string
+	Body   []byte
+}
+
+// Chunk is part of a document's body: a message, or part of a long one.
+type Chunk struct {
+	Message int    // number of the message, from 1; 0 is text before the first
+	Speaker string // speaker of the message
+	Text    string
+}
+
+// Chunks splits a body into per-message chunks of at most ChunkBytes,
+// cutting long messages at line boundaries. Blank chunks are skipped.
+func Chunks(body []byte) []Chunk {
+	messages := conversation.Messages(body)
+	base := 1
+	if len(messages) > 0 && messages[0].Speaker == "" {
+		base = 0
+	}
+
+	var chunks []Chunk
+	for i, m := range messages {
+		for start := m.Start; start < m.End; {
+			cut, _, err := conversation.Fit(body, messages, start, m.End, conversation.Budget{MaxBytes: ChunkBytes})
+			if err != nil || cut <= start {
+				cut = m.End
+			}
+			if text := strings.TrimSpace(string(body[start:cut])); text != "" {
+				chunks = append(chunks, Chunk{Message: i + base, Speaker: m.Speaker, Text: text})
+			}
+			start = cut
+		}
+	}
+	return chunks
+}
+
+// Query is a semantic search.
+type Query struct {
+	Text string
+
+	// KeywordWeight is the share of the keyword score, from 0 (by meaning
+	// only) to 1 (by keywords only).
+	KeywordWeight float64
+
+	// Filter, if set, limits results to the documents it accepts. All
+	// documents are still indexed.
+	Filter func(Document) bool
+
+	// Limit is the maximum number of results; 0 is unlimited.
+	Limit int
+}
+
+// Hit is a document matching a query, with its best chunk.
+type Hit struct {
+	Path     string
+	Title    string
+	Chunk    Chunk
+	Score    float64 // combined score
+	Semantic float64 // cosine similarity of the chunk and query
+	Keyword  float64 // BM25 score of the chunk, relative to the best chunk
+}
+
+// Search ranks documents by how well their best chunk matches a query.
+// Every document is chunked and embedded, reusing the chunks of unchanged
+// documents and cached vectors, so docs should be the whole collection;
+// use Query.Filter to narrow results.
+func (ix *Index) Search(ctx context.Context, docs []Document, q Query) ([]Hit, error) {
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, errors.New("query is required")
+	}
+	if q.KeywordWeight < 0 || q.KeywordWeight > 1 {
+		return nil, errors.New("keyword weight must be between 0 and 1")
+	}
+
+	entries := ix.chunkDocuments(docs)
+	vectors, err := ix.entryVectors(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	queryVectors, err := ix.embedder.Embed(ctx, []string{q.Text})
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([][]string, len(entries))
+	for i, e := range entries {
+		terms[i] = e.terms
+	}
+	keyword := bm25(terms, Terms(q.Text))
+	best := slices.Max(append(keyword, 0))
+
+	hits := make(map[int]Hit)
+	for i, e := range entries {
+		doc := docs[e.doc]
+		if q.Filter != nil && !q.Filter(doc) {
+			continue
+		}
+		hit := Hit{Path: doc.Path, Title: doc.Title, Chunk: e.chunk, Semantic: Cosine(vectors[i], queryVectors[0])}
+		if best > 0 {
+			hit.Keyword = keyword[i] / best
+		}
+		hit.Score = (1-q.KeywordWeight)*hit.Semantic + q.KeywordWeight*hit.Keyword
+		if prev, ok := hits[e.doc]; !ok || hit.Score > prev.Score {
+			hits[e.doc] = hit
+		}
+	}
+
+	results := make([]Hit, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Score > 0 {
+			results = append(results, hit)
+		}
+	}
+	slices.SortFunc(results, func(a, b Hit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// DocumentVectors returns a vector per document, the normalized mean of
+// its chunk vectors, or nil for a document without text. As with Search,
+// docs should be the whole collection.
+func (ix *Index) DocumentVectors(ctx context.Context, docs []Document) ([][]float32, error) {
+	entries := ix.chunkDocuments(docs)
+	vectors, err := ix.entryVectors(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	means := make([][]float32, len(docs))
+	for i, e := range entries {
+		if means[e.doc] == nil {
+			means[e.doc] = make([]float32, len(vectors[i]))
+		}
+		for j, x := range vectors[i] {
+			means[e.doc][j] += x
+		}
+	}
+	for _, v := range means {
+		normalize(v)
+	}
+	return means, nil
+}
+
+// chunkEntry is a chunk of a document, with the text embedded for it,
+// which is prefixed with the document title for context.
+type chunkEntry struct {
+	doc   int
+	chunk Chunk
+	text  string
+	key   string
+	terms []string
+}
+
+// docChunks are the chunks of a document as of a hash of its title and
+// body.
+type docChunks struct {
+	hash    string
+	entries []chunkEntry
+}
+
+// chunkDocuments chunks documents. Documents unchanged since the last call
+// reuse their chunks, keys and terms; documents no longer passed are
+// forgotten.
+func (ix *Index) chunkDocuments(docs []Document) []chunkEntry {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	cached := ix.docs
+	ix.docs = make(map[string]*docChunks, len(docs))
+	var entries []chunkEntry
+	for i, doc := range docs {
+		h := sha256.New()
+		h.Write([]byte(doc.Title))
+		h.Write([]byte{0})
+		h.Write(doc.Body)
+		hash := hex.EncodeToString(h.Sum(nil))
+
+		dc, ok := cached[doc.Path]
+		if !ok || dc.hash != hash {
+			dc = &docChunks{hash: hash}
+			for _, c := range Chunks(doc.Body) {
+				text := c.Text
+				if doc.Title != "" {
+					text = doc.Title + "\n\n" + text
+				}
+				dc.entries = append(dc.entries, chunkEntry{chunk: c, text: text, key: chunkKey(text), terms: Terms(text)})
+			}
+		}
+		ix.docs[doc.Path] = dc
+		for _, e := range dc.entries {
+			e.doc = i
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// bm25 scores each document, given as its terms, against the query terms.
+func bm25(docs [][]string, query []string) []float64 {
+	scores := make([]float64, len(docs))
+	if len(docs) == 0 || len(query) == 0 {
+		return scores
+	}
+
+	df := make(map[string]int)
+	total := 0
+	for _, terms := range docs {
+		total += len(terms)
+		seen := make(map[string]bool)
+		for _, t := range terms {
+			if !seen[t] {
+				df[t]++
+				seen[t] = true
+			}
+		}
+	}
+	avg := float64(total) / float64(len(docs))
+	if avg == 0 {
+		return scores
+	}
+
+	query = slices.Compact(slices.Sorted(slices.Values(query)))
+	for i, terms := range docs {
+		tf := make(map[string]int)
+		for _, t := range terms {
+			tf[t]++
+		}
+		for _, t := range query {
+			if tf[t] == 0 {
+				continue
+			}
+			n := float64(df[t])
+			idf := math.Log(1 + (float64(len(docs))-n+0.5)/(n+0.5))
+			f := float64(tf[t])
+			scores[i] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(len(terms))/avg))
+		}
+	}
+	return scores
+}
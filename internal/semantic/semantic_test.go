@@ -0,0 +1,302 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testConcepts groups the words the tests search by.
+var testConcepts = [][]string{
+	{"auth", "authentication", "login", "signin", "password", "session", "token"},
+	{"bug", "error", "failure", "crash", "issue"},
+}
+
+func TestHashingEmbedderConcepts(t *testing.T) {
+	e := NewHashingEmbedder(0, testConcepts)
+	vectors, err := e.Embed(context.Background(), []string{
+		"auth bug",
+		"login failure after the upgrade",
+		"sourdough bread recipe",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	related := Cosine(vectors[0], vectors[1])
+	unrelated := Cosine(vectors[0], vectors[2])
+	if related <= unrelated+0.2 {
+		t.Errorf("Cosine(auth bug, login failure) = %.3f, want well above unrelated %.3f", related, unrelated)
+	}
+	if got := Cosine(vectors[0], vectors[0]); got < 0.999 {
+		t.Errorf("Cosine(v, v) = %.3f, want 1", got)
+	}
+
+	// Without concepts the phrases share no features
+	plain, err := NewHashingEmbedder(0, nil).Embed(context.Background(), []string{"auth bug", "login failure after the upgrade"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Cosine(plain[0], plain[1]); got >= related {
+		t.Errorf("Cosine without concepts = %.3f, want below %.3f", got, related)
+	}
+	if e.Name() == NewHashingEmbedder(0, nil).Name() {
+		t.Error("embedders with and without concepts share a name")
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"failing":  "fail",
+		"failed":   "fail",
+		"fails":    "fail",
+		"issues":   "issue",
+		"crashes":  "crash",
+		"class":    "class",
+		"bus":      "bus",
+		"tokens":   "token",
+		"sing":     "sing",
+		"embedded": "embedd",
+	}
+	for in, want := range tests {
+		if got := stem(in); got != want {
+			t.Errorf("stem(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChunks(t *testing.T) {
+	long := strings.Repeat("a line of text that repeats\n", 200)
+	body := "# Title\n\n**User:** short question\n\n**Assistant:** " + long
+	chunks := Chunks([]byte(body))
+	if chunks[0].Message != 0 || chunks[0].Text != "# Title" {
+		t.Errorf("chunks[0] = %+v, want the preamble", chunks[0])
+	}
+	if chunks[1].Message != 1 || chunks[1].Speaker != "User" {
+		t.Errorf("chunks[1] = %+v, want message 1 by User", chunks[1])
+	}
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks, want the long message split", len(chunks))
+	}
+	for _, c := range chunks[2:] {
+		if c.Message != 2 || len(c.Text) > ChunkBytes {
+			t.Errorf("chunk of message %d has %d bytes", c.Message, len(c.Text))
+		}
+	}
+}
+
+func TestSearchHybrid(t *testing.T) {
+	docs := []Document{
+		{Path: "a.md", Title: "Login failure", Body: []byte("**User:** Users get a login failure after the password reset.\n\n**Assistant:** Check the session token expiry.\n")},
+		{Path: "b.md", Title: "Bread", Body: []byte("**User:** How long should sourdough proof?\n\n**Assistant:** About twelve hours in the fridge.\n")},
+		{Path: "c.md", Title: "Kubernetes", Body: []byte("**User:** My pod keeps restarting.\n\n**Assistant:** Look at the container memory limit.\n")},
+	}
+	ix := NewIndex(NewHashingEmbedder(0, testConcepts), "", "")
+	ctx := context.Background()
+
+	hits, err := ix.Search(ctx, docs, Query{Text: "auth bug", KeywordWeight: DefaultKeywordWeight})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) == 0 || hits[0].Path != "a.md" {
+		t.Fatalf("Search(auth bug) = %+v, want a.md first", hits)
+	}
+	if hits[0].Keyword != 0 {
+		t.Errorf("keyword score = %v, want 0 without shared words", hits[0].Keyword)
+	}
+
+	hits, err = ix.Search(ctx, docs, Query{Text: "sourdough", KeywordWeight: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Path != "b.md" || hits[0].Keyword != 1 {
+		t.Fatalf("Search(sourdough) = %+v, want only b.md by keyword", hits)
+	}
+
+	hits, err = ix.Search(ctx, docs, Query{Text: "memory", Filter: func(d Document) bool { return d.Path != "c.md" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hits {
+		if h.Path == "c.md" {
+			t.Errorf("filtered document c.md returned")
+		}
+	}
+
+	if _, err := ix.Search(ctx, docs, Query{Text: " "}); err == nil {
+		t.Error("Search with an empty query succeeded")
+	}
+	if _, err := ix.Search(ctx, docs, Query{Text: "x", KeywordWeight: 2}); err == nil {
+		t.Error("Search with keyword weight 2 succeeded")
+	}
+}
+
+// countingEmbedder counts the texts it embeds.
+type countingEmbedder struct {
+	*HashingEmbedder
+	embedded int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	return e.HashingEmbedder.Embed(ctx, texts)
+}
+
+func TestIndexCachesAndPersists(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	e := &countingEmbedder{HashingEmbedder: NewHashingEmbedder(64, nil)}
+
+	ix := NewIndex(e, dir, "notes")
+	if _, err := ix.Vectors(ctx, []string{"one", "two"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.Vectors(ctx, []string{"one", "two", "three"}); err != nil {
+		t.Fatal(err)
+	}
+	if e.embedded != 3 {
+		t.Errorf("embedded %d texts, want 3", e.embedded)
+	}
+
+	// A new index reads the file and drops vectors no longer used
+	reopened := NewIndex(e, dir, "notes")
+	if _, err := reopened.Vectors(ctx, []string{"three"}); err != nil {
+		t.Fatal(err)
+	}
+	if e.embedded != 3 {
+		t.Errorf("embedded %d texts after reopening, want 3", e.embedded)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 after pruning", reopened.Len())
+	}
+
+	// Another embedder uses its own file
+	other := NewIndex(NewHashingEmbedder(32, nil), dir, "notes")
+	if _, err := other.Vectors(ctx, []string{"three"}); err != nil {
+		t.Fatal(err)
+	}
+	if reopened.file == other.file {
+		t.Error("embedders share an index file")
+	}
+}
+
+func TestIndexSeparatesStores(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	e := &countingEmbedder{HashingEmbedder: NewHashingEmbedder(64, nil)}
+
+	notes := NewIndex(e, dir, "github repo=notes")
+	if _, err := notes.Vectors(ctx, []string{"one", "two"}); err != nil {
+		t.Fatal(err)
+	}
+	// Indexing another store in the same directory leaves these vectors alone
+	work := NewIndex(e, dir, "github repo=work")
+	if _, err := work.Vectors(ctx, []string{"three"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewIndex(e, dir, "github repo=notes")
+	if _, err := reopened.Vectors(ctx, []string{"one", "two"}); err != nil {
+		t.Fatal(err)
+	}
+	if e.embedded != 3 {
+		t.Errorf("embedded %d texts, want 3 with no re-embedding", e.embedded)
+	}
+}
+
+func TestIndexReusesDocumentChunks(t *testing.T) {
+	ctx := context.Background()
+	e := &countingEmbedder{HashingEmbedder: NewHashingEmbedder(64, nil)}
+	ix := NewIndex(e, "", "")
+	docs := []Document{
+		{Path: "a.md", Title: "A", Body: []byte("**User:** first question\n")},
+		{Path: "b.md", Title: "B", Body: []byte("**User:** second question\n")},
+	}
+
+	if _, err := ix.DocumentVectors(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	a, b := ix.docs["a.md"], ix.docs["b.md"]
+
+	// Only the changed document is chunked again, and a removed one is dropped
+	docs[1].Body = []byte("**User:** second question, edited\n")
+	if _, err := ix.Search(ctx, docs[1:], Query{Text: "question"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ix.docs["a.md"]; ok {
+		t.Error("removed document is still cached")
+	}
+	if ix.docs["b.md"] == b {
+		t.Error("changed document reused its chunks")
+	}
+	if _, err := ix.Search(ctx, docs, Query{Text: "question"}); err != nil {
+		t.Fatal(err)
+	}
+	if ix.docs["a.md"] == a || ix.docs["b.md"] == b {
+		t.Error("documents were not chunked again")
+	}
+	b = ix.docs["b.md"]
+	if _, err := ix.Search(ctx, docs, Query{Text: "question"}); err != nil {
+		t.Fatal(err)
+	}
+	if ix.docs["b.md"] != b {
+		t.Error("unchanged document was chunked again")
+	}
+	if e.embedded != 7 {
+		t.Errorf("embedded %d texts, want 7 (a, b, edited b, a again and three queries)", e.embedded)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic" {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		type datum struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []datum
+		// Reply out of order, as the index decides the position
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, datum{Index: i, Embedding: []float32{float32(len(req.Input[i])), 0, 0}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	e, err := NewEmbedder(EmbedderOpenAI, server.URL+"/v1/", "nomic", "key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := e.Embed(context.Background(), []string{"a", "bb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][0] != 1 {
+		t.Errorf("Embed() = %v, want two unit vectors", vectors)
+	}
+
+	bad := NewOpenAIEmbedder(server.URL, "nomic", "wrong")
+	if _, err := bad.Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Embed() with a bad key error = %v, want the HTTP status", err)
+	}
+
+	if _, err := NewEmbedder(EmbedderOpenAI, "", "", "", nil); err == nil {
+		t.Error("NewEmbedder(openai) without a URL succeeded")
+	}
+	if _, err := NewEmbedder("bogus", "", "", "", nil); err == nil {
+		t.Error("NewEmbedder(bogus) succeeded")
+	}
+}
@@ -0,0 +1,212 @@
+// Package similar detects near-duplicate conversations with MinHash and
+// SimHash fingerprints of their text.
+package similar
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+// Fingerprint sizes
+const (
+	NumHashes    = 128 // MinHash signature length
+	ShingleWords = 3   // words per shingle
+	bands        = 32  // LSH bands; NumHashes/bands rows each
+)
+
+// Thresholds at which two conversations are considered duplicates
+const (
+	DuplicateJaccard  = 0.8 // estimated Jaccard similarity of shingles
+	DuplicateDistance = 3   // SimHash Hamming distance
+)
+
+// Fingerprint summarizes the text of a conversation for similarity
+// estimates. Speaker labels and Markdown formatting are ignored, so the
+// same chat saved from different clients has nearly the same fingerprint.
+type Fingerprint struct {
+	MinHash []uint64
+	SimHash uint64
+	Words   int
+}
+
+// seeds are the MinHash permutations, fixed so fingerprints are stable.
+var seeds = func() []uint64 {
+	s := make([]uint64, NumHashes)
+	for i := range s {
+		s[i] = mix(uint64(i+1) * 0x9e3779b97f4a7c15)
+	}
+	return s
+}()
+
+// Words returns the lowercase words of a conversation body, without
+// speaker labels.
+func Words(body []byte) []string {
+	var words []string
+	for _, m := range conversation.Messages(body) {
+		words = append(words, strings.FieldsFunc(strings.ToLower(m.Text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return words
+}
+
+// NewFingerprint returns the fingerprint of a conversation body.
+func NewFingerprint(body []byte) Fingerprint {
+	words := Words(body)
+	fp := Fingerprint{MinHash: make([]uint64, NumHashes), Words: len(words)}
+	for i := range fp.MinHash {
+		fp.MinHash[i] = ^uint64(0)
+	}
+
+	shingles := make(map[uint64]bool)
+	for i := 0; i < max(len(words)-ShingleWords+1, min(len(words), 1)); i++ {
+		shingles[hash(strings.Join(words[i:min(i+ShingleWords, len(words))], " "))] = true
+	}
+	for s := range shingles {
+		for i, seed := range seeds {
+			if h := mix(s ^ seed); h < fp.MinHash[i] {
+				fp.MinHash[i] = h
+			}
+		}
+	}
+
+	// SimHash over shingles rather than words, so common words do not
+	// make unrelated texts look alike
+	var weights [64]int
+	for h := range shingles {
+		for bit := range weights {
+			if h&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	for bit, weight := range weights {
+		if weight > 0 {
+			fp.SimHash |= 1 << bit
+		}
+	}
+	return fp
+}
+
+// Jaccard estimates the Jaccard similarity of the shingles of two
+// fingerprints, from 0 to 1.
+func Jaccard(a, b Fingerprint) float64 {
+	if a.Words == 0 || b.Words == 0 || len(a.MinHash) != len(b.MinHash) {
+		return 0
+	}
+	same := 0
+	for i := range a.MinHash {
+		if a.MinHash[i] == b.MinHash[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a.MinHash))
+}
+
+// Distance returns the Hamming distance of the SimHashes of two
+// fingerprints, from 0 (same word distribution) to 64.
+func Distance(a, b Fingerprint) int {
+	return bits.OnesCount64(a.SimHash ^ b.SimHash)
+}
+
+// Duplicate reports whether two fingerprints are of near-duplicate texts.
+func Duplicate(a, b Fingerprint) bool {
+	if a.Words == 0 || b.Words == 0 {
+		return false
+	}
+	return Jaccard(a, b) >= DuplicateJaccard || Distance(a, b) <= DuplicateDistance
+}
+
+// Groups returns the groups of near-duplicates among fingerprints, as
+// sorted indexes, in order of their first member. Candidate pairs are found
+// by locality-sensitive hashing of the MinHash signatures, then checked
+// with Duplicate, so the cost is roughly linear in the number of
+// fingerprints.
+func Groups(fps []Fingerprint) [][]int {
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Fingerprints are candidates if they share a band of their MinHash
+	// signature or, as SimHashes within DuplicateDistance bits agree on at
+	// least one of DuplicateDistance+1 blocks, a block of their SimHash
+	rows := NumHashes / bands
+	blockBits := 64 / (DuplicateDistance + 1)
+	buckets := make(map[[2]uint64][]int)
+	for i, fp := range fps {
+		if fp.Words == 0 {
+			continue
+		}
+		for band := range bands {
+			h := fnv.New64a()
+			for _, v := range fp.MinHash[band*rows : (band+1)*rows] {
+				h.Write(binary.LittleEndian.AppendUint64(nil, v))
+			}
+			key := [2]uint64{uint64(band), h.Sum64()}
+			buckets[key] = append(buckets[key], i)
+		}
+		for block := range DuplicateDistance + 1 {
+			key := [2]uint64{uint64(bands + block), fp.SimHash >> (block * blockBits) & (1<<blockBits - 1)}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	checked := make(map[[2]int]bool)
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if checked[pair] {
+					continue
+				}
+				checked[pair] = true
+				if Duplicate(fps[pair[0]], fps[pair[1]]) {
+					parent[find(pair[1])] = find(pair[0])
+				}
+			}
+		}
+	}
+
+	byRoot := make(map[int][]int)
+	for i := range fps {
+		byRoot[find(i)] = append(byRoot[find(i)], i)
+	}
+	var groups [][]int
+	for _, members := range byRoot {
+		if len(members) > 1 {
+			slices.Sort(members)
+			groups = append(groups, members)
+		}
+	}
+	slices.SortFunc(groups, func(a, b []int) int { return a[0] - b[0] })
+	return groups
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix(h.Sum64())
+}
+
+// mix is the splitmix64 finalizer, spreading the bits of x.
+func mix(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,58 @@
+package similar
+
+import (
+	"strings"
+	"testing"
+)
+
+const chat = `**User:** How do I rotate the signing keys for our OAuth provider without logging everyone out?
+
+**Assistant:** Publish the new key in the JWKS endpoint first, keep signing with the old key until every
+client has refreshed its cache, then switch signing to the new key and remove the old one after the
+longest token lifetime has passed. Clients that pin a single key need to be updated before the switch.
+`
+
+func TestFingerprintIgnoresFormatting(t *testing.T) {
+	// The same chat saved by another client, with other speaker labels
+	other := strings.NewReplacer("**User:**", "**You:**", "**Assistant:**", "**ChatGPT:**").Replace(chat)
+	a, b := NewFingerprint([]byte(chat)), NewFingerprint([]byte(other))
+	if got := Jaccard(a, b); got != 1 {
+		t.Errorf("Jaccard() = %v, want 1", got)
+	}
+	if !Duplicate(a, b) {
+		t.Error("Duplicate() = false for a reformatted copy")
+	}
+}
+
+func TestDuplicateNearAndUnrelated(t *testing.T) {
+	base := NewFingerprint([]byte(chat))
+	edited := NewFingerprint([]byte(chat + "\n**User:** Thanks!\n"))
+	unrelated := NewFingerprint([]byte("**User:** What is a good sourdough hydration for a beginner?\n\n**Assistant:** Start around 70 percent; wetter doughs are harder to shape.\n"))
+
+	if !Duplicate(base, edited) {
+		t.Errorf("Duplicate(base, edited) = false; jaccard %.2f, distance %d", Jaccard(base, edited), Distance(base, edited))
+	}
+	if Duplicate(base, unrelated) {
+		t.Errorf("Duplicate(base, unrelated) = true; jaccard %.2f, distance %d", Jaccard(base, unrelated), Distance(base, unrelated))
+	}
+	if Jaccard(base, unrelated) > 0.1 {
+		t.Errorf("Jaccard(base, unrelated) = %.2f, want near 0", Jaccard(base, unrelated))
+	}
+	if Duplicate(NewFingerprint(nil), NewFingerprint(nil)) {
+		t.Error("empty conversations are duplicates")
+	}
+}
+
+func TestGroups(t *testing.T) {
+	fps := []Fingerprint{
+		NewFingerprint([]byte("**User:** What is a good sourdough hydration for a beginner baker at home?")),
+		NewFingerprint([]byte(chat)),
+		NewFingerprint([]byte(strings.ReplaceAll(chat, "**Assistant:**", "**Claude:**"))),
+		NewFingerprint(nil),
+		NewFingerprint([]byte(chat + "\n**User:** Thanks!\n")),
+	}
+	groups := Groups(fps)
+	if len(groups) != 1 || len(groups[0]) != 3 || groups[0][0] != 1 || groups[0][2] != 4 {
+		t.Errorf("Groups() = %v, want [[1 2 4]]", groups)
+	}
+}
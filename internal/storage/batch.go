@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grokify/omnistorage"
+	ghbackend "github.com/grokify/omnistorage-github/backend/github"
+
+	"github.com/grokify/chathub/internal/encrypt"
+)
+
+// ErrBatchCommitted is returned when a batch is committed twice.
+var ErrBatchCommitted = errors.New("batch already committed")
+
+// BatchOp is one write or delete in a batch.
+type BatchOp struct {
+	Path    string
+	Content []byte
+	Delete  bool
+}
+
+// Batcher is implemented by backends that can apply a batch as a unit.
+type Batcher interface {
+	ApplyBatch(ctx context.Context, message string, ops []BatchOp) error
+}
+
+// Batch accumulates writes and deletes and applies them together. On the
+// GitHub backend a batch becomes a single commit made through the Git Data
+// API; other backends apply the operations one by one.
+//
+// Deleting a path that does not exist is not an error. When a batch touches
+// the same path more than once, the last operation wins. Operations queued
+// after Commit are never applied.
+type Batch struct {
+	store   *Storage
+	message string
+
+	mu        sync.Mutex
+	ops       []BatchOp
+	index     map[string]int
+	committed bool
+}
+
+// Begin starts a batch. The message is used as the commit message where
+// the backend records one.
+func (s *Storage) Begin(message string) *Batch {
+	return &Batch{
+		store:   s,
+		message: message,
+		index:   make(map[string]int),
+	}
+}
+
+// Put queues a write of content to filePath.
+func (b *Batch) Put(filePath string, content []byte) {
+	b.add(BatchOp{Path: filePath, Content: content})
+}
+
+// Delete queues the removal of filePath.
+func (b *Batch) Delete(filePath string) {
+	b.add(BatchOp{Path: filePath, Delete: true})
+}
+
+func (b *Batch) add(op BatchOp) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if i, ok := b.index[op.Path]; ok {
+		b.ops[i] = op
+		return
+	}
+	b.index[op.Path] = len(b.ops)
+	b.ops = append(b.ops, op)
+}
+
+// Len returns the number of queued operations.
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.ops)
+}
+
+// Commit applies the queued operations. A batch can only be committed once.
+func (b *Batch) Commit(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.committed {
+		return ErrBatchCommitted
+	}
+	b.committed = true
+	if len(b.ops) == 0 {
+		return nil
+	}
+
+	backend := b.store.backend
+	ops := b.ops
+	if enc, ok := backend.(*encrypt.Backend); ok {
+		// Encrypt here so the layers below receive ciphertext
+		ops = make([]BatchOp, len(b.ops))
+		for i, op := range b.ops {
+			ops[i] = op
+			if op.Delete {
+				continue
+			}
+			sealed, err := b.store.keyring.Encrypt(op.Content, b.store.encryptionMode)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", op.Path, err)
+			}
+			ops[i].Content = sealed
+		}
+		backend = enc.Inner()
+	}
+
+	if err := applyBatch(ctx, backend, b.message, ops); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return nil
+}
+
+// applyBatch applies ops to backend as a unit if it supports batches, and
+// one by one otherwise.
+func applyBatch(ctx context.Context, backend omnistorage.Backend, message string, ops []BatchOp) error {
+	switch b := backend.(type) {
+	case Batcher:
+		return b.ApplyBatch(ctx, message, ops)
+	case *ghbackend.Backend:
+		batch, err := b.NewBatch(ctx, message)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if op.Delete {
+				err = batch.Delete(op.Path)
+			} else {
+				err = batch.Write(op.Path, op.Content)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", op.Path, err)
+			}
+		}
+		return batch.Commit()
+	}
+
+	for _, op := range ops {
+		var err error
+		if op.Delete {
+			if err = backend.Delete(ctx, op.Path); omnistorage.IsNotFound(err) {
+				err = nil
+			}
+		} else {
+			err = writeAll(ctx, backend, op.Path, op.Content)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op.Path, err)
+		}
+	}
+	return nil
+}
+
+// ApplyBatch applies ops to every backend, the primary as a unit where it
+// supports batches. Replicas that fail are retried one operation at a time.
+// Operations on paths that a newer write has started on are left out.
+func (rb *ReplicatedBackend) ApplyBatch(ctx context.Context, message string, ops []BatchOp) error {
+	gen := rb.gen.Add(1)
+	paths := make([]string, len(ops))
+	for i, op := range ops {
+		paths[i] = op.Path
+	}
+	for _, m := range rb.members {
+		m.start(gen, paths...)
+	}
+
+	errs := make([]error, len(rb.members))
+	var wg sync.WaitGroup
+	for i, m := range rb.members {
+		wg.Add(1)
+		go func(i int, m *replica) {
+			defer wg.Done()
+			unlock := m.lock(paths...)
+			defer unlock()
+			current := slices.DeleteFunc(slices.Clone(ops), func(op BatchOp) bool {
+				return m.superseded(gen, op.Path)
+			})
+			if len(current) == 0 {
+				return
+			}
+			if errs[i] = applyBatch(ctx, m.backend, message, current); errs[i] != nil {
+				m.recordFailure(errs[i])
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, m := range rb.members {
+		for _, op := range ops {
+			if errs[i] == nil {
+				m.clearPending(op.Path, gen)
+			} else if i > 0 {
+				rb.scheduleRetry(m, &pendingOp{path: op.Path, content: op.Content, delete: op.Delete, gen: gen})
+			}
+		}
+		if errs[i] == nil {
+			succeeded++
+		}
+	}
+
+	if errs[0] != nil {
+		return fmt.Errorf("primary %s: %w", rb.primary().name, errs[0])
+	}
+	if succeeded < rb.cfg.Quorum {
+		return fmt.Errorf("replication quorum not met for batch: %d of %d required backends succeeded: %w",
+			succeeded, rb.cfg.Quorum, errors.Join(errs...))
+	}
+	return nil
+}
+
+// ApplyBatch applies ops to the wrapped backend. Batches of writes are
+// journaled if the backend is unavailable, or if earlier writes to any of
+// their paths are still queued; batches containing deletes are not, and
+// once applied supersede queued writes to the paths they touch.
+func (o *Outbox) ApplyBatch(ctx context.Context, message string, ops []BatchOp) error {
+	hasDelete, overlaps := false, false
+	for _, op := range ops {
+		hasDelete = hasDelete || op.Delete
+		if _, ok := o.queued(op.Path); ok {
+			overlaps = true
+		}
+	}
+
+	var cause error
+	switch {
+	case hasDelete:
+		paths := make([]string, len(ops))
+		for i, op := range ops {
+			paths[i] = op.Path
+		}
+		held := o.hold(paths...)
+		err := applyBatch(ctx, o.inner, message, ops)
+		o.release(held, err == nil)
+		return err
+	case !overlaps:
+		cause = applyBatch(ctx, o.inner, message, ops)
+		if cause == nil || !IsTransient(cause) {
+			return cause
+		}
+	}
+
+	var first *OutboxEntry
+	for _, op := range ops {
+		entry, err := o.enqueue(op.Path, op.Content)
+		if err != nil {
+			return errors.Join(cause, err)
+		}
+		if first == nil {
+			first = entry
+		}
+	}
+	o.Kick()
+	return &QueuedError{ID: first.ID, Path: first.Path, Err: cause}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/encrypt"
+)
+
+// batchingBackend records the batches applied to it.
+type batchingBackend struct {
+	*memory.Backend
+	batches [][]BatchOp
+}
+
+func (b *batchingBackend) ApplyBatch(ctx context.Context, message string, ops []BatchOp) error {
+	b.batches = append(b.batches, ops)
+	return applyBatch(ctx, b.Backend, message, ops)
+}
+
+func TestBatchCommit(t *testing.T) {
+	ctx := context.Background()
+	backend := &batchingBackend{Backend: memory.New()}
+	store := New(backend, "conversations")
+	mustSave(t, store, "old.md", "old")
+
+	batch := store.Begin("test")
+	batch.Put(store.AbsPath("a.md"), []byte("a1"))
+	batch.Put(store.AbsPath("b.md"), []byte("b"))
+	batch.Put(store.AbsPath("a.md"), []byte("a2"))
+	batch.Delete(store.AbsPath("old.md"))
+	batch.Delete(store.AbsPath("missing.md"))
+	if batch.Len() != 4 {
+		t.Errorf("Len() = %d, want 4 (repeated path collapsed)", batch.Len())
+	}
+	if err := batch.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if len(backend.batches) != 1 {
+		t.Fatalf("backend received %d batches, want 1", len(backend.batches))
+	}
+	if got := mustRead(t, store, "a.md"); got != "a2" {
+		t.Errorf("a.md = %q, want last write a2", got)
+	}
+	if exists, _ := store.Exists(ctx, store.AbsPath("old.md")); exists {
+		t.Error("old.md was not deleted")
+	}
+	if err := batch.Commit(ctx); !errors.Is(err, ErrBatchCommitted) {
+		t.Errorf("second Commit() error = %v, want ErrBatchCommitted", err)
+	}
+}
+
+func TestBatchEncryptsAndReplicates(t *testing.T) {
+	ctx := context.Background()
+	mirror := memory.New()
+	rb := NewReplicatedBackend(
+		Replica{Name: "primary", Backend: memory.New()},
+		[]Replica{{Name: "mirror", Backend: mirror}},
+		ReplicationConfig{Quorum: 2},
+	)
+	spec, err := encrypt.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keyring, err := encrypt.ParseKeyring(spec)
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	store := New(rb, "conversations", WithEncryption(keyring, encrypt.ModeFull))
+	defer store.Close()
+
+	batch := store.Begin("test")
+	batch.Put(store.AbsPath("a.md"), []byte("secret"))
+	if err := batch.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if got := mustRead(t, store, "a.md"); got != "secret" {
+		t.Errorf("Read() = %q, want decrypted content", got)
+	}
+	stored := string(mustReadBackend(t, mirror, "conversations/a.md"))
+	if strings.Contains(stored, "secret") || !encrypt.IsEncrypted([]byte(stored)) {
+		t.Errorf("replica holds plaintext: %q", stored)
+	}
+}
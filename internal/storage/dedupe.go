@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/similar"
+)
+
+// DedupeOptions configures Dedupe.
+type DedupeOptions struct {
+	// Merge keeps one copy of each group of duplicates, adding the tags,
+	// categories, participants and URLs of the others to it, and moves
+	// the others to the trash.
+	Merge bool
+
+	// DryRun reports what Merge would do without changing anything.
+	DryRun bool
+}
+
+// DedupeCopy is a duplicate of the kept conversation of a group.
+type DedupeCopy struct {
+	Path     string  `json:"path"`
+	Title    string  `json:"title,omitempty"`
+	Source   string  `json:"source,omitempty"`
+	Jaccard  float64 `json:"jaccard" jsonschema:"Estimated shingle overlap with the kept copy"`
+	Distance int     `json:"simhash_distance" jsonschema:"SimHash Hamming distance from the kept copy"`
+	Trashed  bool    `json:"trashed,omitempty"`
+}
+
+// DedupeGroup is a set of near-duplicate conversations.
+type DedupeGroup struct {
+	Keep       string       `json:"keep" jsonschema:"The most complete copy, kept when merging"`
+	Title      string       `json:"title,omitempty"`
+	Source     string       `json:"source,omitempty"`
+	Duplicates []DedupeCopy `json:"duplicates"`
+}
+
+// DedupeSkip is a conversation left out of a dedupe run, and why.
+type DedupeSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// DedupeResult summarizes a dedupe run.
+type DedupeResult struct {
+	Groups  []DedupeGroup `json:"groups,omitempty"`
+	Skipped []DedupeSkip  `json:"skipped,omitempty"`
+	Checked int           `json:"checked"`
+	Trashed int           `json:"trashed"`
+	DryRun  bool          `json:"dry_run,omitempty"`
+}
+
+// dedupeDoc is a conversation considered by Dedupe.
+type dedupeDoc struct {
+	path    string
+	content []byte
+	fm      *frontmatter.Frontmatter
+	body    []byte
+	fp      similar.Fingerprint
+}
+
+// Dedupe finds groups of near-duplicate conversations, such as the same
+// chat saved from two clients, and optionally merges each group into its
+// most complete copy: the one with the most words, then the most recently
+// modified. Every member of a group is a near-duplicate of the kept copy,
+// not only of another member. Files whose frontmatter cannot be parsed are
+// skipped and reported.
+func Dedupe(ctx context.Context, s *Storage, opts DedupeOptions) (*DedupeResult, error) {
+	files, err := s.ListConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DedupeResult{DryRun: opts.DryRun}
+	var docs []dedupeDoc
+	for _, filePath := range files {
+		if !strings.HasSuffix(filePath, ".md") {
+			continue
+		}
+		content, err := s.Read(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		fm, body, err := frontmatter.Parse(content)
+		if err != nil {
+			result.Skipped = append(result.Skipped, DedupeSkip{Path: filePath, Reason: "invalid frontmatter: " + err.Error()})
+			continue
+		}
+		docs = append(docs, dedupeDoc{path: filePath, content: content, fm: fm, body: body, fp: similar.NewFingerprint(body)})
+	}
+	result.Checked = len(docs)
+
+	fps := make([]similar.Fingerprint, len(docs))
+	for i, d := range docs {
+		fps[i] = d.fp
+	}
+
+	for _, members := range similar.Groups(fps) {
+		slices.SortFunc(members, func(a, b int) int {
+			if c := cmp.Compare(docs[b].fp.Words, docs[a].fp.Words); c != 0 {
+				return c
+			}
+			if c := docs[b].modified().Compare(docs[a].modified()); c != 0 {
+				return c
+			}
+			return cmp.Compare(docs[a].path, docs[b].path)
+		})
+
+		// Grouping is transitive, so a chain of similar copies can hold
+		// members unlike the kept one; those form groups of their own
+		for len(members) > 1 {
+			keep := docs[members[0]]
+			group := DedupeGroup{Keep: keep.path}
+			if keep.fm != nil {
+				group.Title, group.Source = keep.fm.Title, keep.fm.Source
+			}
+			var dupes []dedupeDoc
+			var rest []int
+			for _, i := range members[1:] {
+				d := docs[i]
+				if !similar.Duplicate(keep.fp, d.fp) {
+					rest = append(rest, i)
+					continue
+				}
+				c := DedupeCopy{
+					Path:     d.path,
+					Jaccard:  similar.Jaccard(keep.fp, d.fp),
+					Distance: similar.Distance(keep.fp, d.fp),
+				}
+				if d.fm != nil {
+					c.Title, c.Source = d.fm.Title, d.fm.Source
+				}
+				group.Duplicates = append(group.Duplicates, c)
+				dupes = append(dupes, d)
+			}
+			members = rest
+			if len(dupes) == 0 {
+				continue
+			}
+
+			if opts.Merge && !opts.DryRun {
+				if err := mergeDuplicates(ctx, s, keep, dupes); err != nil {
+					result.Groups = append(result.Groups, group)
+					return result, fmt.Errorf("failed to merge duplicates of %s: %w", keep.path, err)
+				}
+				for i := range group.Duplicates {
+					group.Duplicates[i].Trashed = true
+					result.Trashed++
+				}
+			}
+			result.Groups = append(result.Groups, group)
+		}
+	}
+	return result, nil
+}
+
+// modified returns when a conversation last changed.
+func (d dedupeDoc) modified() time.Time {
+	if d.fm == nil {
+		return time.Time{}
+	}
+	if !d.fm.LastMod.IsZero() {
+		return d.fm.LastMod
+	}
+	return d.fm.Date
+}
+
+// mergeDuplicates adds the metadata of duplicates to the kept copy, so
+// nothing but the repeated text is lost, and moves the duplicates to the
+// trash, all in one batch.
+func mergeDuplicates(ctx context.Context, s *Storage, keep dedupeDoc, dupes []dedupeDoc) error {
+	batch := s.Begin("Merge duplicates of " + s.RelPath(keep.path))
+	if keep.fm != nil {
+		changed := false
+		add := func(list []string, values ...string) []string {
+			for _, v := range values {
+				if v != "" && !slices.Contains(list, v) {
+					list = append(list, v)
+					changed = true
+				}
+			}
+			return list
+		}
+		for _, d := range dupes {
+			if d.fm == nil {
+				continue
+			}
+			keep.fm.Tags = add(keep.fm.Tags, d.fm.Tags...)
+			keep.fm.Categories = add(keep.fm.Categories, d.fm.Categories...)
+			keep.fm.Participants = add(keep.fm.Participants, d.fm.Participants...)
+			keep.fm.Aliases = add(keep.fm.Aliases, d.fm.Aliases...)
+			if url := HugoURL(d.path, d.fm); url != HugoURL(keep.path, keep.fm) {
+				keep.fm.Aliases = add(keep.fm.Aliases, url)
+			}
+		}
+		if changed {
+			keep.fm.LastMod = time.Now().UTC()
+			content, err := keep.fm.RenderWithContent(keep.body)
+			if err != nil {
+				return err
+			}
+			batch.Put(keep.path, content)
+		}
+	}
+	for _, d := range dupes {
+		if _, err := batch.Trash(ctx, d.path, d.content); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
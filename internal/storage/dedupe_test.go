@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func TestDedupe(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+
+	body := "**User:** How do I rotate the signing keys for our OAuth provider without logging everyone out?\n\n" +
+		"**Assistant:** Publish the new key in the JWKS endpoint first, keep signing with the old key until every client has refreshed, then switch.\n"
+	doc := "---\ntitle: %s\ndate: 2026-01-10T09:00:00Z\nsource: %s\ntags: [%s]\n---\n"
+	fill := func(title, source, tags string) string {
+		return strings.NewReplacer("title: %s", "title: "+title, "source: %s", "source: "+source, "[%s]", "["+tags+"]").Replace(doc)
+	}
+	mustSave(t, store, "claude/keys.md", fill("Key rotation", "claude", "oauth")+body+"**User:** Thanks, and the refresh tokens?\n")
+	mustSave(t, store, "chatgpt/keys.md", fill("Rotating keys", "chatgpt", "jwt")+strings.ReplaceAll(body, "**Assistant:**", "**ChatGPT:**"))
+	mustSave(t, store, "claude/bread.md", fill("Bread", "claude", "baking")+"**User:** What is a good sourdough hydration for a beginner?\n")
+
+	dry, err := Dedupe(ctx, store, DedupeOptions{Merge: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dedupe(dry run) error = %v", err)
+	}
+	if dry.Checked != 3 || len(dry.Groups) != 1 || dry.Trashed != 0 {
+		t.Fatalf("Dedupe(dry run) = %+v, want 1 group and nothing trashed", dry)
+	}
+	group := dry.Groups[0]
+	if group.Keep != store.AbsPath("claude/keys.md") || len(group.Duplicates) != 1 || group.Duplicates[0].Path != store.AbsPath("chatgpt/keys.md") {
+		t.Fatalf("group = %+v, want the longer Claude copy kept", group)
+	}
+
+	result, err := Dedupe(ctx, store, DedupeOptions{Merge: true})
+	if err != nil {
+		t.Fatalf("Dedupe() error = %v", err)
+	}
+	if result.Trashed != 1 || !result.Groups[0].Duplicates[0].Trashed {
+		t.Fatalf("Dedupe() = %+v, want the duplicate trashed", result)
+	}
+	if exists, _ := store.Exists(ctx, store.AbsPath("chatgpt/keys.md")); exists {
+		t.Error("duplicate still exists")
+	}
+	fm, _, err := frontmatter.Parse([]byte(mustRead(t, store, "claude/keys.md")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fm.Tags, ",") != "oauth,jwt" {
+		t.Errorf("tags = %v, want the duplicate's tags added", fm.Tags)
+	}
+	if len(fm.Aliases) != 1 || fm.Aliases[0] != "/conversations/chatgpt/keys/" {
+		t.Errorf("aliases = %v, want the duplicate's URL", fm.Aliases)
+	}
+
+	again, err := Dedupe(ctx, store, DedupeOptions{})
+	if err != nil || len(again.Groups) != 0 {
+		t.Errorf("Dedupe() after merging = %+v, %v, want no groups", again, err)
+	}
+}
+
+func TestDedupeChain(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+
+	// b is a near-duplicate of a and c, but a and c are too different
+	text := func(replaced int) string {
+		words := make([]string, 400)
+		for i := range words {
+			words[i] = fmt.Sprintf("word%d", i)
+			if i < replaced {
+				words[i] = fmt.Sprintf("other%d", i)
+			}
+		}
+		return "**User:** " + strings.Join(words, " ") + "\n"
+	}
+	doc := "---\ntitle: Chain\ndate: 2026-01-10T09:00:00Z\nlastmod: %s\nsource: claude\n---\n"
+	mustSave(t, store, "a.md", fmt.Sprintf(doc, "2026-01-03T00:00:00Z")+text(0))
+	mustSave(t, store, "b.md", fmt.Sprintf(doc, "2026-01-02T00:00:00Z")+text(35))
+	mustSave(t, store, "c.md", fmt.Sprintf(doc, "2026-01-01T00:00:00Z")+text(70))
+	mustSave(t, store, "broken.md", "---\ntitle: [unclosed\n---\nbody\n")
+
+	result, err := Dedupe(ctx, store, DedupeOptions{Merge: true})
+	if err != nil {
+		t.Fatalf("Dedupe() error = %v", err)
+	}
+	if len(result.Groups) != 1 || result.Groups[0].Keep != store.AbsPath("a.md") ||
+		len(result.Groups[0].Duplicates) != 1 || result.Groups[0].Duplicates[0].Path != store.AbsPath("b.md") {
+		t.Fatalf("Dedupe() groups = %+v, want only b merged into a", result.Groups)
+	}
+	if exists, _ := store.Exists(ctx, store.AbsPath("c.md")); !exists {
+		t.Error("c.md was trashed, but it is not a duplicate of a.md")
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != store.AbsPath("broken.md") {
+		t.Errorf("Dedupe() skipped = %+v, want broken.md", result.Skipped)
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	// Paths limits the report to these files. Duplicate IDs are still
+	// detected across the whole store.
+	Paths []string
+}
+
+// LintFile lists the issues found in one file.
+type LintFile struct {
+	Path   string              `json:"path"`
+	Issues []frontmatter.Issue `json:"issues"`
+}
+
+// LintResult summarizes a lint run. Files only includes files with issues.
+type LintResult struct {
+	Files    []LintFile `json:"files,omitempty"`
+	Checked  int        `json:"checked"`
+	Errors   int        `json:"errors"`
+	Warnings int        `json:"warnings"`
+}
+
+// Lint validates the frontmatter of every conversation and reports
+// conversation IDs used by more than one file. A file that cannot be read
+// is reported as an error in that file.
+func Lint(ctx context.Context, s *Storage, opts LintOptions) (*LintResult, error) {
+	files, err := s.ListConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range opts.Paths {
+		if !slices.Contains(files, p) {
+			if exists, err := s.Exists(ctx, p); err != nil {
+				return nil, err
+			} else if !exists {
+				return nil, fmt.Errorf("conversation not found: %s", p)
+			}
+			files = append(files, p)
+		}
+	}
+
+	reports := make(map[string][]frontmatter.Issue)
+	ids := make(map[string][]string)
+	var checked []string
+	for _, filePath := range files {
+		if !strings.HasSuffix(filePath, ".md") {
+			continue
+		}
+		if len(opts.Paths) == 0 || slices.Contains(opts.Paths, filePath) {
+			checked = append(checked, filePath)
+		}
+		content, err := s.Read(ctx, filePath)
+		if err != nil {
+			reports[filePath] = []frontmatter.Issue{{
+				Severity: frontmatter.SeverityError,
+				Message:  "cannot read file: " + err.Error(),
+			}}
+			continue
+		}
+		fm, issues := frontmatter.Validate(content)
+		reports[filePath] = issues
+		if fm != nil && fm.ConversationID != "" {
+			ids[fm.ConversationID] = append(ids[fm.ConversationID], filePath)
+		}
+	}
+
+	for id, paths := range ids {
+		if len(paths) < 2 {
+			continue
+		}
+		for _, p := range paths {
+			others := slices.DeleteFunc(slices.Clone(paths), func(other string) bool { return other == p })
+			reports[p] = append(reports[p], frontmatter.Issue{
+				Field:    "conversation_id",
+				Severity: frontmatter.SeverityError,
+				Message:  fmt.Sprintf("%q is also used by %s", id, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	result := &LintResult{Checked: len(checked)}
+	for _, p := range checked {
+		issues := reports[p]
+		if len(issues) == 0 {
+			continue
+		}
+		result.Files = append(result.Files, LintFile{Path: p, Issues: issues})
+		for _, issue := range issues {
+			if issue.Severity == frontmatter.SeverityError {
+				result.Errors++
+			} else {
+				result.Warnings++
+			}
+		}
+	}
+	return result, nil
+}
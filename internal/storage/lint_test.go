@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func TestLint(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+
+	doc := "---\ntitle: %s\ndate: 2026-01-10T09:00:00Z\nsource: claude\nconversation_id: %s\nschema_version: 1\n---\nbody"
+	mustSave(t, store, "claude/a.md", strings.NewReplacer("%s", "A").Replace(doc))
+	mustSave(t, store, "claude/b.md", strings.Replace(strings.Replace(doc, "%s", "B", 1), "%s", "conv_dup", 1))
+	mustSave(t, store, "claude/c.md", strings.Replace(strings.Replace(doc, "%s", "C", 1), "%s", "conv_dup", 1))
+	mustSave(t, store, "claude/ok.md", strings.Replace(strings.Replace(doc, "%s", "OK", 1), "%s", "conv_ok", 1))
+
+	result, err := Lint(ctx, store, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if result.Checked != 4 || result.Errors != 2 || len(result.Files) != 2 {
+		t.Fatalf("Lint() = %+v, want 2 duplicate ID errors in 4 files", result)
+	}
+	if !strings.Contains(result.Files[0].Issues[0].Message, "claude/c.md") {
+		t.Errorf("issue = %v, want it to name the other file", result.Files[0].Issues[0])
+	}
+
+	// A single file is still checked against the whole store
+	single, err := Lint(ctx, store, LintOptions{Paths: []string{store.AbsPath("claude/b.md")}})
+	if err != nil {
+		t.Fatalf("Lint(path) error = %v", err)
+	}
+	if single.Checked != 1 || single.Errors != 1 {
+		t.Errorf("Lint(path) = %+v, want 1 error", single)
+	}
+
+	if _, err := Lint(ctx, store, LintOptions{Paths: []string{"conversations/missing.md"}}); err == nil {
+		t.Error("Lint(missing) error = nil")
+	}
+}
+
+func TestLintUnreadableFile(t *testing.T) {
+	ctx := context.Background()
+	store := New(&unreadableBackend{Backend: memory.New(), path: "conversations/claude/locked.md"}, "conversations")
+	mustSave(t, store, "claude/ok.md", "---\ntitle: OK\ndate: 2026-01-10T09:00:00Z\nsource: claude\nconversation_id: conv_ok\nschema_version: 1\n---\nbody")
+	mustSave(t, store, "claude/locked.md", "anything")
+
+	result, err := Lint(ctx, store, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if result.Checked != 2 || result.Errors != 1 || len(result.Files) != 1 || result.Files[0].Path != store.AbsPath("claude/locked.md") {
+		t.Fatalf("Lint() = %+v, want one read error in locked.md", result)
+	}
+	if !strings.Contains(result.Files[0].Issues[0].Message, "permission denied") {
+		t.Errorf("issue = %v, want the read error", result.Files[0].Issues[0])
+	}
+}
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grokify/omnistorage"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// MigrateFolder is the internal area holding migration progress.
+const MigrateFolder = ".migrate"
+
+// DefaultMigrateBatchSize is the number of files committed together.
+const DefaultMigrateBatchSize = 50
+
+// MigrateOptions configures Migrate.
+type MigrateOptions struct {
+	// Layout is the target path layout. Nil uses the storage layout.
+	Layout *frontmatter.Layout
+
+	// Aliases adds the old Hugo URL of each file whose URL changes, by a
+	// move or a new slug, to its aliases so existing links keep working.
+	Aliases bool
+
+	// DryRun reports the planned changes without applying them.
+	DryRun bool
+
+	// BatchSize is the number of files moved per commit. Progress is saved
+	// with each batch, so an interrupted migration resumes where it left
+	// off.
+	BatchSize int
+
+	// Restart ignores saved progress.
+	Restart bool
+}
+
+// MigrateChange describes one migrated file. Paths are relative to the
+// storage folder.
+type MigrateChange struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Upgraded []string `json:"upgraded,omitempty"`
+	Alias    string   `json:"alias,omitempty"`
+}
+
+// Moved reports whether the file changes path.
+func (c MigrateChange) Moved() bool {
+	return c.From != c.To
+}
+
+// MigrateSkip records a file that could not be migrated.
+type MigrateSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// MigrateResult summarizes a migration.
+type MigrateResult struct {
+	Changes   []MigrateChange `json:"changes"`
+	Skipped   []MigrateSkip   `json:"skipped,omitempty"`
+	Unchanged int             `json:"unchanged"`
+	Resumed   int             `json:"resumed"` // completed by an earlier run
+	DryRun    bool            `json:"dry_run"`
+}
+
+// Moved returns the number of files that change path.
+func (r *MigrateResult) Moved() int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Moved() {
+			n++
+		}
+	}
+	return n
+}
+
+// Upgraded returns the number of files whose frontmatter was upgraded.
+func (r *MigrateResult) Upgraded() int {
+	n := 0
+	for _, c := range r.Changes {
+		if len(c.Upgraded) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// migrateState records the files a migration has finished, by new path.
+type migrateState struct {
+	Layout    string          `json:"layout"`
+	StartedAt time.Time       `json:"started_at"`
+	Done      map[string]bool `json:"done"`
+}
+
+// Migrate moves conversations to the paths given by a layout and upgrades
+// their frontmatter to the current schema, leaving bodies untouched.
+func Migrate(ctx context.Context, s *Storage, opts MigrateOptions) (*MigrateResult, error) {
+	layout := opts.Layout
+	if layout == nil {
+		layout = s.layout
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultMigrateBatchSize
+	}
+
+	state, err := loadMigrateState(ctx, s, layout.String())
+	if err != nil {
+		return nil, err
+	}
+	if opts.Restart {
+		state = migrateState{Layout: layout.String(), Done: make(map[string]bool)}
+	}
+	if state.StartedAt.IsZero() {
+		state.StartedAt = time.Now().UTC()
+	}
+
+	files, err := s.ListConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrateResult{DryRun: opts.DryRun}
+	taken := make(map[string]bool, len(files))
+	for _, f := range files {
+		taken[f] = true
+	}
+
+	// Versions follow their conversation when it moves
+	versions := make(map[string][]string)
+	if !opts.DryRun {
+		versionFiles, err := s.List(ctx, path.Join(s.folder, VersionsFolder)+"/")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range versionFiles {
+			versions[path.Dir(f)] = append(versions[path.Dir(f)], f)
+		}
+	}
+
+	batch := s.Begin("Migrate conversations to layout " + layout.String())
+	pending := 0
+	saved := len(state.Done) > 0
+	flush := func() error {
+		if pending == 0 || opts.DryRun {
+			return nil
+		}
+		saved = true
+		if err := putMigrateState(batch, s, state); err != nil {
+			return err
+		}
+		if err := batch.Commit(ctx); err != nil {
+			return err
+		}
+		batch = s.Begin("Migrate conversations to layout " + layout.String())
+		pending = 0
+		return nil
+	}
+
+	for _, filePath := range files {
+		rel := s.RelPath(filePath)
+		if !strings.HasSuffix(filePath, ".md") {
+			continue
+		}
+		if state.Done[rel] {
+			result.Resumed++
+			continue
+		}
+
+		content, err := s.Read(ctx, filePath)
+		if err != nil {
+			return result, err
+		}
+		change, updated, reason := planMigration(s, layout, filePath, content, taken, opts)
+		if reason != "" {
+			result.Skipped = append(result.Skipped, MigrateSkip{Path: rel, Reason: reason})
+			continue
+		}
+		if change == nil {
+			result.Unchanged++
+			continue
+		}
+		result.Changes = append(result.Changes, *change)
+
+		newPath := s.AbsPath(change.To)
+		if change.Moved() {
+			taken[newPath] = true
+			delete(taken, filePath)
+		}
+		if opts.DryRun {
+			continue
+		}
+
+		batch.Put(newPath, updated)
+		if change.Moved() {
+			batch.Delete(filePath)
+			if err := batch.moveVersions(ctx, newPath, versions[s.versionDir(filePath)]); err != nil {
+				return result, err
+			}
+		}
+		state.Done[change.To] = true
+		if pending++; pending >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	if saved && !opts.DryRun {
+		// Finished: clear progress so the next migration starts fresh
+		if err := s.Delete(ctx, migrateStatePath(s)); err != nil && !omnistorage.IsNotFound(err) {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// planMigration works out the new path and content of one file. It returns
+// a nil change if the file is already migrated, or a reason if it cannot
+// be migrated.
+func planMigration(s *Storage, layout *frontmatter.Layout, filePath string, content []byte, taken map[string]bool, opts MigrateOptions) (*MigrateChange, []byte, string) {
+	header, body, ok := frontmatter.Split(content)
+	if !ok {
+		return nil, nil, "no frontmatter"
+	}
+	fm, _, err := frontmatter.Parse(header)
+	if err != nil || fm == nil {
+		return nil, nil, "invalid frontmatter"
+	}
+
+	// Hugo served the file at its old URL before any upgrade
+	oldURL := HugoURL(filePath, fm)
+
+	// Files saved under the original layout carry the source in the path
+	var upgraded []string
+	if fm.Source == "" {
+		if dir, _, found := strings.Cut(s.RelPath(filePath), "/"); found {
+			if info, ok := frontmatter.ResolveSource(dir); ok {
+				fm.Source = info.ID
+				upgraded = append(upgraded, "source")
+			}
+		}
+	}
+	if fm.Source == "" {
+		return nil, nil, "no source"
+	}
+	upgraded = append(upgraded, frontmatter.Upgrade(fm)...)
+
+	change := &MigrateChange{From: s.RelPath(filePath), Upgraded: upgraded}
+	newPath := layout.Path(s.folder, fm)
+	if newPath != filePath {
+		newPath = uniquePath(newPath, filePath, taken)
+	}
+	change.To = s.RelPath(newPath)
+
+	// A new slug changes the URL even if the file stays where it is
+	if opts.Aliases && oldURL != HugoURL(newPath, fm) && !slices.Contains(fm.Aliases, oldURL) {
+		fm.Aliases = append(fm.Aliases, oldURL)
+		change.Alias = oldURL
+	}
+
+	if !change.Moved() && len(change.Upgraded) == 0 {
+		return nil, nil, ""
+	}
+
+	rendered, err := fm.Render()
+	if err != nil {
+		return nil, nil, "failed to render frontmatter: " + err.Error()
+	}
+	return change, append(rendered, body...), ""
+}
+
+// uniquePath returns p, or p with a numeric suffix if it is taken by a file
+// other than self. A file suffixed by an interrupted run keeps its path.
+func uniquePath(p, self string, taken map[string]bool) string {
+	free := func(candidate string) bool {
+		return candidate == self || !taken[candidate]
+	}
+	if free(p) {
+		return p
+	}
+
+	// Page bundles are disambiguated by directory
+	dir, base := path.Split(p)
+	if base == "index.md" || base == "_index.md" {
+		for i := 2; ; i++ {
+			candidate := path.Join(strings.TrimSuffix(dir, "/")+"-"+strconv.Itoa(i), base)
+			if free(candidate) {
+				return candidate
+			}
+		}
+	}
+	stem := strings.TrimSuffix(p, ".md")
+	for i := 2; ; i++ {
+		candidate := stem + "-" + strconv.Itoa(i) + ".md"
+		if free(candidate) {
+			return candidate
+		}
+	}
+}
+
+// HugoURL returns the URL Hugo serves a content file at, honoring slug.
+func HugoURL(filePath string, fm *frontmatter.Frontmatter) string {
+	dir := path.Dir(filePath)
+	name := strings.TrimSuffix(path.Base(filePath), ".md")
+	if name == "index" || name == "_index" {
+		name = path.Base(dir)
+		dir = path.Dir(dir)
+	}
+	if fm.Slug != "" {
+		name = fm.Slug
+	}
+	return "/" + path.Join(dir, name) + "/"
+}
+
+func migrateStatePath(s *Storage) string {
+	return path.Join(s.folder, MigrateFolder, "state.json")
+}
+
+func loadMigrateState(ctx context.Context, s *Storage, layout string) (migrateState, error) {
+	state := migrateState{Layout: layout, Done: make(map[string]bool)}
+
+	statePath := migrateStatePath(s)
+	exists, err := s.Exists(ctx, statePath)
+	if err != nil || !exists {
+		return state, err
+	}
+	data, err := s.Read(ctx, statePath)
+	if err != nil {
+		return state, err
+	}
+
+	var saved migrateState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return state, fmt.Errorf("failed to decode migration state %s: %w", statePath, err)
+	}
+	// Progress towards a different layout does not apply
+	if saved.Layout != layout || saved.Done == nil {
+		return state, nil
+	}
+	return saved, nil
+}
+
+func putMigrateState(batch *Batch, s *Storage, state migrateState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode migration state: %w", err)
+	}
+	batch.Put(migrateStatePath(s), data)
+	return nil
+}
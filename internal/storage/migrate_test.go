@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+const legacyConversation = `---
+title: Hello World
+date: 2026-01-10T09:00:00Z
+---
+
+**User:** hi
+`
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+	mustSave(t, store, "claude/2026-01-10_hello-world.md", legacyConversation)
+	mustSave(t, store, "notes.md", "no frontmatter")
+
+	layout := frontmatter.MustParseLayout("{yyyy}/{mm}/{source}-{slug}.md")
+	opts := MigrateOptions{Layout: layout, Aliases: true}
+
+	dry, err := Migrate(ctx, store, MigrateOptions{Layout: layout, Aliases: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate(dry run) error = %v", err)
+	}
+	if dry.Moved() != 1 || len(dry.Skipped) != 1 {
+		t.Errorf("dry run = %+v, want 1 move and 1 skip", dry)
+	}
+	if exists, _ := store.Exists(ctx, store.AbsPath("2026/01/claude-hello-world.md")); exists {
+		t.Error("dry run moved a file")
+	}
+
+	result, err := Migrate(ctx, store, opts)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(result.Changes) != 1 {
+		t.Fatalf("Migrate() changes = %+v, want 1", result.Changes)
+	}
+	change := result.Changes[0]
+	if change.To != "2026/01/claude-hello-world.md" {
+		t.Errorf("moved to %q", change.To)
+	}
+	if change.Alias != "/conversations/claude/2026-01-10_hello-world/" {
+		t.Errorf("alias = %q", change.Alias)
+	}
+	if exists, _ := store.Exists(ctx, store.AbsPath(change.From)); exists {
+		t.Error("old path still exists")
+	}
+
+	content := mustRead(t, store, change.To)
+	fm, _, err := frontmatter.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if fm.Source != "claude" || fm.ConversationID == "" || fm.Slug != "hello-world" {
+		t.Errorf("frontmatter not upgraded: %+v", fm)
+	}
+	if len(fm.Aliases) != 1 || fm.Aliases[0] != change.Alias {
+		t.Errorf("aliases = %v", fm.Aliases)
+	}
+	if !strings.HasSuffix(content, "---\n\n**User:** hi\n") {
+		t.Errorf("body changed: %q", content)
+	}
+
+	again, err := Migrate(ctx, store, opts)
+	if err != nil {
+		t.Fatalf("Migrate() again error = %v", err)
+	}
+	if len(again.Changes) != 0 || again.Unchanged != 1 {
+		t.Errorf("second migration = %+v, want no changes", again)
+	}
+	if exists, _ := store.Exists(ctx, migrateStatePath(store)); exists {
+		t.Error("migration state left behind")
+	}
+}
+
+func TestMigrateAliasesNewSlug(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+	mustSave(t, store, "claude/2026-01-10_hello-world.md", legacyConversation)
+
+	// The file stays where it is, but the slug it gains changes its URL
+	result, err := Migrate(ctx, store, MigrateOptions{Layout: frontmatter.MustParseLayout("{source}/{date}_{slug}.md"), Aliases: true})
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Moved() {
+		t.Fatalf("Migrate() changes = %+v, want one upgrade in place", result.Changes)
+	}
+	if want := "/conversations/claude/2026-01-10_hello-world/"; result.Changes[0].Alias != want {
+		t.Errorf("alias = %q, want %q", result.Changes[0].Alias, want)
+	}
+	fm, _, err := frontmatter.Parse([]byte(mustRead(t, store, "claude/2026-01-10_hello-world.md")))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if fm.Slug != "hello-world" || len(fm.Aliases) != 1 {
+		t.Errorf("slug = %q, aliases = %v, want the old URL kept as an alias", fm.Slug, fm.Aliases)
+	}
+}
+
+func TestMigrateResumes(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+	layout := frontmatter.MustParseLayout("{source}/{slug}.md")
+
+	// An interrupted run moved a.md and saved its progress
+	mustSave(t, store, "claude/a.md", strings.Replace(legacyConversation, "Hello World", "A", 1))
+	mustSave(t, store, "claude/2026-01-10_b.md", strings.Replace(legacyConversation, "Hello World", "B", 1))
+	batch := store.Begin("state")
+	if err := putMigrateState(batch, store, migrateState{Layout: layout.String(), Done: map[string]bool{"claude/a.md": true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Migrate(ctx, store, MigrateOptions{Layout: layout})
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result.Resumed != 1 || len(result.Changes) != 1 || result.Changes[0].To != "claude/b.md" {
+		t.Errorf("Migrate() = %+v, want a.md resumed and b.md moved", result)
+	}
+}
+
+func TestUniquePath(t *testing.T) {
+	taken := map[string]bool{"c/a.md": true, "c/a-2.md": true, "c/x/index.md": true}
+	tests := []struct {
+		path, self, want string
+	}{
+		{"c/b.md", "", "c/b.md"},
+		{"c/a.md", "", "c/a-3.md"},
+		{"c/a.md", "c/a-2.md", "c/a-2.md"},
+		{"c/x/index.md", "", "c/x-2/index.md"},
+	}
+	for _, tt := range tests {
+		if got := uniquePath(tt.path, tt.self, taken); got != tt.want {
+			t.Errorf("uniquePath(%q, %q) = %q, want %q", tt.path, tt.self, got, tt.want)
+		}
+	}
+}
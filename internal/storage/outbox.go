@@ -0,0 +1,603 @@
+package storage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grokify/omnistorage"
+)
+
+// Outbox defaults
+const (
+	DefaultOutboxRetryBackoff = 5 * time.Second
+	DefaultOutboxMaxBackoff   = 5 * time.Minute
+)
+
+// ErrQueued indicates that a write could not reach the backend and was
+// journaled to the outbox for later delivery.
+var ErrQueued = errors.New("write queued")
+
+// QueuedError reports a write that was journaled instead of written. It
+// matches ErrQueued with errors.Is and unwraps to the backend error.
+type QueuedError struct {
+	ID   string
+	Path string
+	Err  error
+}
+
+func (e *QueuedError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s queued as %s", e.Path, e.ID)
+	}
+	return fmt.Sprintf("%s queued as %s: %v", e.Path, e.ID, e.Err)
+}
+
+// Is reports whether target is ErrQueued.
+func (e *QueuedError) Is(target error) bool {
+	return target == ErrQueued
+}
+
+func (e *QueuedError) Unwrap() error {
+	return e.Err
+}
+
+// OutboxConfig configures an Outbox.
+type OutboxConfig struct {
+	// Dir is the local directory holding the journal.
+	Dir string
+
+	// Target identifies the store the journal delivers to. Entries queued
+	// for another target are left in the journal and never replayed.
+	Target string
+
+	// RetryBackoff is the delay before the first replay attempt; it doubles
+	// with each failed attempt up to MaxBackoff.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+// OutboxEntry is one journaled write.
+type OutboxEntry struct {
+	ID          string    `json:"id"`
+	Seq         uint64    `json:"seq"`
+	Target      string    `json:"target,omitempty"`
+	Path        string    `json:"path"`
+	Content     []byte    `json:"content,omitempty"`
+	Size        int       `json:"size"`
+	QueuedAt    time.Time `json:"queued_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+
+	// file is the journal file name, unique across processes sharing Dir
+	file string
+}
+
+// OutboxStatus reports the journaled writes of an Outbox.
+type OutboxStatus struct {
+	Dir     string        `json:"dir"`
+	Pending []OutboxEntry `json:"pending"`
+	Failed  []OutboxEntry `json:"failed,omitempty"`
+}
+
+// Outbox is an omnistorage.Backend that journals writes the wrapped backend
+// cannot accept because it is unreachable or rate-limited, and replays them
+// in order with backoff once it recovers. Journaled writes are visible to
+// reads, existence checks and listings until they are delivered.
+//
+// Only errors for which IsTransient reports true are queued; others are
+// returned to the caller.
+type Outbox struct {
+	inner omnistorage.Backend
+	cfg   OutboxConfig
+
+	mu      sync.Mutex
+	pending []*OutboxEntry
+	failed  []*OutboxEntry
+	nextSeq uint64
+
+	kick   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOutbox wraps inner with a write journal in cfg.Dir, loading any
+// entries left by a previous run, and starts replaying them.
+func NewOutbox(inner omnistorage.Backend, cfg OutboxConfig) (*Outbox, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("outbox directory is required")
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultOutboxRetryBackoff
+	}
+	if cfg.MaxBackoff < cfg.RetryBackoff {
+		cfg.MaxBackoff = max(DefaultOutboxMaxBackoff, cfg.RetryBackoff)
+	}
+	if err := os.MkdirAll(filepath.Join(cfg.Dir, "failed"), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	o := &Outbox{
+		inner:   inner,
+		cfg:     cfg,
+		nextSeq: 1,
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	var err error
+	if o.pending, err = o.load(""); err != nil {
+		return nil, err
+	}
+	if o.failed, err = o.load("failed"); err != nil {
+		return nil, err
+	}
+	for _, e := range append(o.pending, o.failed...) {
+		o.nextSeq = max(o.nextSeq, e.Seq+1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	go o.replayLoop(ctx)
+	o.Kick()
+	return o, nil
+}
+
+// NewWriter returns a writer that writes through to the wrapped backend
+// when closed. If that fails transiently, or earlier writes to the same
+// path are still queued, the content is journaled and Close returns a
+// *QueuedError.
+func (o *Outbox) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	return &outboxWriter{ctx: ctx, outbox: o, path: path, opts: opts}, nil
+}
+
+// NewReader serves the latest queued content for path, if any, and
+// otherwise reads from the wrapped backend.
+func (o *Outbox) NewReader(ctx context.Context, path string, opts ...omnistorage.ReaderOption) (io.ReadCloser, error) {
+	if content, ok := o.queued(path); ok {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return o.inner.NewReader(ctx, path, opts...)
+}
+
+// Exists reports true for queued paths.
+func (o *Outbox) Exists(ctx context.Context, path string) (bool, error) {
+	if _, ok := o.queued(path); ok {
+		return true, nil
+	}
+	return o.inner.Exists(ctx, path)
+}
+
+// Delete deletes path from the wrapped backend and then discards queued
+// writes to it. Queued writes are held back meanwhile and kept if the
+// delete fails, since they may be the only copy of their content.
+func (o *Outbox) Delete(ctx context.Context, path string) error {
+	held := o.hold(path)
+	err := o.inner.Delete(ctx, path)
+	if len(held) > 0 && omnistorage.IsNotFound(err) {
+		err = nil
+	}
+	o.release(held, err == nil)
+	return err
+}
+
+// List merges queued paths into the wrapped backend's listing.
+func (o *Outbox) List(ctx context.Context, prefix string) ([]string, error) {
+	files, err := o.inner.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, e := range o.pending {
+		if strings.HasPrefix(e.Path, prefix) && !seen[e.Path] {
+			seen[e.Path] = true
+			files = append(files, e.Path)
+		}
+	}
+	return files, nil
+}
+
+// Close stops replaying and closes the wrapped backend. Queued writes stay
+// in the journal for the next run.
+func (o *Outbox) Close() error {
+	o.cancel()
+	<-o.done
+	return o.inner.Close()
+}
+
+// Inner returns the wrapped backend.
+func (o *Outbox) Inner() omnistorage.Backend {
+	return o.inner
+}
+
+// Kick triggers an immediate replay attempt, ignoring backoff.
+func (o *Outbox) Kick() {
+	o.mu.Lock()
+	for _, e := range o.pending {
+		e.NextAttempt = time.Time{}
+	}
+	o.mu.Unlock()
+	select {
+	case o.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of writes awaiting delivery.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// Status returns the queued and failed writes, without their content.
+func (o *Outbox) Status() OutboxStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	status := OutboxStatus{Dir: o.cfg.Dir, Pending: []OutboxEntry{}}
+	for _, e := range o.pending {
+		entry := *e
+		entry.Content = nil
+		status.Pending = append(status.Pending, entry)
+	}
+	for _, e := range o.failed {
+		entry := *e
+		entry.Content = nil
+		status.Failed = append(status.Failed, entry)
+	}
+	return status
+}
+
+// Flush replays queued writes in order until the journal is empty or a
+// write fails.
+func (o *Outbox) Flush(ctx context.Context) error {
+	for {
+		o.mu.Lock()
+		if len(o.pending) == 0 {
+			o.mu.Unlock()
+			return nil
+		}
+		head := o.pending[0]
+		o.mu.Unlock()
+
+		if err := o.deliver(ctx, head); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *Outbox) write(ctx context.Context, path string, content []byte, opts []omnistorage.WriterOption) error {
+	// Keep per-path order: queue behind earlier writes to the same path
+	if _, ok := o.queued(path); ok {
+		entry, err := o.enqueue(path, content)
+		if err != nil {
+			return err
+		}
+		o.Kick()
+		return &QueuedError{ID: entry.ID, Path: path}
+	}
+
+	err := writeAll(ctx, o.inner, path, content, opts...)
+	if err == nil || !IsTransient(err) {
+		return err
+	}
+
+	entry, qerr := o.enqueue(path, content)
+	if qerr != nil {
+		return errors.Join(err, qerr)
+	}
+	log.Printf("outbox: queued %s as %s: %v", path, entry.ID, err)
+	return &QueuedError{ID: entry.ID, Path: path, Err: err}
+}
+
+func (o *Outbox) enqueue(path string, content []byte) (*OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	suffix := strings.ToLower(rand.Text()[:8])
+	entry := &OutboxEntry{
+		ID:       fmt.Sprintf("q%06d-%s", o.nextSeq, suffix),
+		Seq:      o.nextSeq,
+		Target:   o.cfg.Target,
+		Path:     path,
+		Content:  content,
+		Size:     len(content),
+		QueuedAt: time.Now().UTC(),
+		file:     fmt.Sprintf("%020d-%s.json", o.nextSeq, suffix),
+	}
+	if err := o.persist(entry); err != nil {
+		return nil, err
+	}
+	o.nextSeq++
+	o.pending = append(o.pending, entry)
+	return entry, nil
+}
+
+// queued returns the latest queued content for path.
+func (o *Outbox) queued(path string) ([]byte, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.pending) - 1; i >= 0; i-- {
+		if o.pending[i].Path == path {
+			return o.pending[i].Content, true
+		}
+	}
+	return nil, false
+}
+
+// hold takes the queued writes to paths out of the queue, so they are not
+// replayed, without removing them from the journal.
+func (o *Outbox) hold(paths ...string) []*OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var held []*OutboxEntry
+	kept := o.pending[:0]
+	for _, e := range o.pending {
+		if slices.Contains(paths, e.Path) {
+			held = append(held, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	o.pending = kept
+	return held
+}
+
+// release discards held writes from the journal if they were superseded,
+// and otherwise puts them back in the queue in their original order.
+func (o *Outbox) release(held []*OutboxEntry, superseded bool) {
+	if len(held) == 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if superseded {
+		for _, e := range held {
+			o.remove(e, "")
+		}
+		return
+	}
+	o.pending = append(o.pending, held...)
+	slices.SortFunc(o.pending, func(a, b *OutboxEntry) int {
+		return cmp.Or(cmp.Compare(a.Seq, b.Seq), strings.Compare(a.file, b.file))
+	})
+}
+
+func (o *Outbox) replayLoop(ctx context.Context) {
+	defer close(o.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for ctx.Err() == nil {
+		o.mu.Lock()
+		wait := time.Hour
+		if len(o.pending) > 0 {
+			wait = max(time.Until(o.pending[0].NextAttempt), 0)
+		}
+		o.mu.Unlock()
+
+		if wait == 0 {
+			// On failure the head's next attempt is pushed back
+			_ = o.Flush(ctx)
+			continue
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+		case <-o.kick:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// deliver writes entry to the wrapped backend. On success or permanent
+// failure the entry leaves the queue; on transient failure it stays at the
+// head with its next attempt pushed back, and the error is returned.
+func (o *Outbox) deliver(ctx context.Context, entry *OutboxEntry) error {
+	o.mu.Lock()
+	if len(o.pending) == 0 || o.pending[0] != entry {
+		// Discarded or delivered concurrently
+		o.mu.Unlock()
+		return nil
+	}
+	if wait := time.Until(entry.NextAttempt); wait > 0 {
+		o.mu.Unlock()
+		return fmt.Errorf("outbox: next attempt in %s", wait.Round(time.Second))
+	}
+	o.mu.Unlock()
+
+	err := writeAll(ctx, o.inner, entry.Path, entry.Content)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) == 0 || o.pending[0] != entry {
+		return nil
+	}
+
+	switch {
+	case err == nil:
+		o.pending = o.pending[1:]
+		o.remove(entry, "")
+		return nil
+
+	case !IsTransient(err):
+		log.Printf("outbox: giving up on %s (%s): %v", entry.Path, entry.ID, err)
+		entry.Attempts++
+		entry.LastError = err.Error()
+		o.pending = o.pending[1:]
+		o.remove(entry, "failed")
+		o.failed = append(o.failed, entry)
+		return nil
+
+	default:
+		entry.Attempts++
+		entry.LastError = err.Error()
+		backoff := o.cfg.RetryBackoff << min(entry.Attempts-1, 16)
+		entry.NextAttempt = time.Now().Add(min(backoff, o.cfg.MaxBackoff))
+		if perr := o.persist(entry); perr != nil {
+			log.Printf("outbox: %v", perr)
+		}
+		return err
+	}
+}
+
+func (o *Outbox) entryPath(entry *OutboxEntry, sub string) string {
+	return filepath.Join(o.cfg.Dir, sub, entry.file)
+}
+
+// persist atomically writes entry to the journal.
+func (o *Outbox) persist(entry *OutboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox entry: %w", err)
+	}
+
+	target := o.entryPath(entry, "")
+	tmp, err := os.CreateTemp(o.cfg.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to journal %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// remove deletes entry from the journal, or moves it into sub if sub is
+// not empty.
+func (o *Outbox) remove(entry *OutboxEntry, sub string) {
+	var err error
+	if sub == "" {
+		err = os.Remove(o.entryPath(entry, ""))
+	} else {
+		if err = o.persist(entry); err == nil {
+			err = os.Rename(o.entryPath(entry, ""), o.entryPath(entry, sub))
+		}
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("outbox: failed to update journal for %s: %v", entry.ID, err)
+	}
+}
+
+// load reads the journal entries in sub that belong to the configured
+// target, leaving others in place.
+func (o *Outbox) load(sub string) ([]*OutboxEntry, error) {
+	entries, err := loadOutboxEntries(filepath.Join(o.cfg.Dir, sub))
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Target != o.cfg.Target {
+			log.Printf("outbox: skipping %s, queued for %q", e.ID, e.Target)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, nil
+}
+
+func loadOutboxEntries(dir string) ([]*OutboxEntry, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	entries := make([]*OutboxEntry, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outbox entry: %w", err)
+		}
+		var entry OutboxEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox entry %s: %w", filepath.Base(name), err)
+		}
+		entry.file = filepath.Base(name)
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// IsTransient reports whether a backend error may succeed on retry: any
+// error other than not-found, permission, invalid-path, closed-backend,
+// unsupported or cancellation errors.
+func IsTransient(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, omnistorage.ErrNotFound),
+		errors.Is(err, omnistorage.ErrPermissionDenied),
+		errors.Is(err, omnistorage.ErrInvalidPath),
+		errors.Is(err, omnistorage.ErrBackendClosed),
+		errors.Is(err, omnistorage.ErrNotSupported):
+		return false
+	}
+	return true
+}
+
+type outboxWriter struct {
+	ctx    context.Context
+	outbox *Outbox
+	path   string
+	opts   []omnistorage.WriterOption
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *outboxWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, omnistorage.ErrWriterClosed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *outboxWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.outbox.write(w.ctx, w.path, w.buf.Bytes(), w.opts)
+}
+
+var _ omnistorage.Backend = (*Outbox)(nil)
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func TestOutboxQueueAndReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	remote := &flakyBackend{Backend: memory.New()}
+	remote.down.Store(true)
+
+	outbox, err := NewOutbox(remote, OutboxConfig{Dir: dir, RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	store := New(outbox, "conversations")
+
+	err = store.Save(ctx, "conversations/a.md", []byte("a1"))
+	var queued *QueuedError
+	if !errors.Is(err, ErrQueued) || !errors.As(err, &queued) {
+		t.Fatalf("Save() error = %v, want ErrQueued", err)
+	}
+	if queued.Path != "conversations/a.md" {
+		t.Errorf("queued path = %q", queued.Path)
+	}
+
+	// A second write to the same path queues behind the first
+	if err := store.Save(ctx, "conversations/a.md", []byte("a2")); !errors.Is(err, ErrQueued) {
+		t.Fatalf("second Save() error = %v, want ErrQueued", err)
+	}
+	if err := store.Save(ctx, "conversations/b.md", []byte("b")); !errors.Is(err, ErrQueued) {
+		t.Fatalf("Save(b) error = %v, want ErrQueued", err)
+	}
+
+	// Queued writes are visible before delivery
+	if got := mustRead(t, store, "a.md"); got != "a2" {
+		t.Errorf("Read() = %q, want queued content a2", got)
+	}
+	files, err := store.ListConversations(ctx)
+	if err != nil || len(files) != 2 {
+		t.Errorf("ListConversations() = %v, %v; want 2 queued files", files, err)
+	}
+
+	// The journal survives a restart
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if entries, err := loadOutboxEntries(dir); err != nil || len(entries) != 3 {
+		t.Fatalf("journal after close = %d entries, %v; want 3", len(entries), err)
+	}
+	remote = &flakyBackend{Backend: memory.New()}
+	outbox, err = NewOutbox(remote, OutboxConfig{Dir: dir, RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("reopen NewOutbox() error = %v", err)
+	}
+	defer outbox.Close()
+
+	// Reopening kicks a replay, which delivers in order
+	deadline := time.Now().Add(2 * time.Second)
+	for outbox.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if outbox.Len() != 0 {
+		t.Fatalf("outbox not drained: %+v", outbox.Status())
+	}
+	if got := string(mustReadBackend(t, remote, "conversations/a.md")); got != "a2" {
+		t.Errorf("delivered a.md = %q, want a2", got)
+	}
+	if got := string(mustReadBackend(t, remote, "conversations/b.md")); got != "b" {
+		t.Errorf("delivered b.md = %q, want b", got)
+	}
+}
+
+func TestOutboxDeleteDiscardsQueuedWrites(t *testing.T) {
+	ctx := context.Background()
+	remote := &flakyBackend{Backend: memory.New()}
+	remote.down.Store(true)
+
+	outbox, err := NewOutbox(remote, OutboxConfig{Dir: t.TempDir(), RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	defer outbox.Close()
+	store := New(outbox, "conversations")
+
+	if err := store.Save(ctx, "conversations/a.md", []byte("a")); !errors.Is(err, ErrQueued) {
+		t.Fatalf("Save() error = %v, want ErrQueued", err)
+	}
+	if err := store.Delete(ctx, "conversations/a.md"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if outbox.Len() != 0 {
+		t.Errorf("Delete() left %d queued writes", outbox.Len())
+	}
+	if exists, _ := store.Exists(ctx, "conversations/a.md"); exists {
+		t.Error("deleted path still exists")
+	}
+}
+
+// stuckDeleteBackend is a flakyBackend whose deletes fail while it is down.
+type stuckDeleteBackend struct {
+	*flakyBackend
+}
+
+func (b stuckDeleteBackend) Delete(ctx context.Context, path string) error {
+	if b.down.Load() {
+		return errDown
+	}
+	return b.flakyBackend.Delete(ctx, path)
+}
+
+func TestOutboxFailedDeleteKeepsQueuedWrites(t *testing.T) {
+	ctx := context.Background()
+	remote := stuckDeleteBackend{&flakyBackend{Backend: memory.New()}}
+	remote.down.Store(true)
+
+	outbox, err := NewOutbox(remote, OutboxConfig{Dir: t.TempDir(), RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	defer outbox.Close()
+	store := New(outbox, "conversations")
+
+	if err := store.Save(ctx, "conversations/a.md", []byte("a")); !errors.Is(err, ErrQueued) {
+		t.Fatalf("Save() error = %v, want ErrQueued", err)
+	}
+	if err := store.Delete(ctx, "conversations/a.md"); err == nil {
+		t.Fatal("Delete() error = nil, want the backend error")
+	}
+	batch := store.Begin("delete a")
+	batch.Delete("conversations/a.md")
+	if err := batch.Commit(ctx); err == nil {
+		t.Fatal("Commit() error = nil, want the backend error")
+	}
+
+	if outbox.Len() != 1 {
+		t.Errorf("queued writes = %d, want the write kept", outbox.Len())
+	}
+	if got := mustRead(t, store, "a.md"); got != "a" {
+		t.Errorf("Read() = %q, want queued content a", got)
+	}
+}
+
+func TestOutboxSkipsOtherTargets(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	down := &flakyBackend{Backend: memory.New()}
+	down.down.Store(true)
+
+	outbox, err := NewOutbox(down, OutboxConfig{Dir: dir, Target: "github repo=notes", RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	if err := New(outbox, "conversations").Save(ctx, "conversations/a.md", []byte("a")); !errors.Is(err, ErrQueued) {
+		t.Fatalf("Save() error = %v, want ErrQueued", err)
+	}
+	outbox.Close()
+
+	// Another store sharing the directory neither sees nor replays the write
+	other := memory.New()
+	outbox, err = NewOutbox(other, OutboxConfig{Dir: dir, Target: "github repo=work", RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	if err := outbox.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if exists, _ := outbox.Exists(ctx, "conversations/a.md"); exists || outbox.Len() != 0 {
+		t.Errorf("write queued for another target was picked up: %+v", outbox.Status())
+	}
+	outbox.Close()
+
+	// Its own store still delivers it
+	remote := memory.New()
+	outbox, err = NewOutbox(remote, OutboxConfig{Dir: dir, Target: "github repo=notes", RetryBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	defer outbox.Close()
+	if err := outbox.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := string(mustReadBackend(t, remote, "conversations/a.md")); got != "a" {
+		t.Errorf("delivered a.md = %q, want a", got)
+	}
+}
+
+func TestOutboxSharedDirectory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	remote := &flakyBackend{Backend: memory.New()}
+	remote.down.Store(true)
+
+	// Two processes journaling into one directory start from the same
+	// sequence number
+	var outboxes []*Outbox
+	for range 2 {
+		outbox, err := NewOutbox(remote, OutboxConfig{Dir: dir, RetryBackoff: time.Hour})
+		if err != nil {
+			t.Fatalf("NewOutbox() error = %v", err)
+		}
+		defer outbox.Close()
+		outboxes = append(outboxes, outbox)
+	}
+	for i, outbox := range outboxes {
+		path := fmt.Sprintf("conversations/%d.md", i)
+		if err := New(outbox, "conversations").Save(ctx, path, []byte(path)); !errors.Is(err, ErrQueued) {
+			t.Fatalf("Save(%s) error = %v, want ErrQueued", path, err)
+		}
+	}
+
+	entries, err := loadOutboxEntries(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("journal = %d entries, %v; want both writes", len(entries), err)
+	}
+	if entries[0].ID == entries[1].ID {
+		t.Errorf("both writes queued as %s", entries[0].ID)
+	}
+}
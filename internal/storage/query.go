@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ConversationQuery selects conversations for listing.
+type ConversationQuery struct {
+	// Folder limits results to conversations under the storage folder,
+	// excluding internal areas such as the trash.
+	Folder string
+	Source string
+	Limit  int
+	Offset int
+}
+
+// ConversationRecord holds the frontmatter fields used in listings.
+type ConversationRecord struct {
+	Path        string
+	Title       string
+	Source      string
+	Date        time.Time
+	Tags        []string
+	Categories  []string
+	Description string
+	Model       string
+	Messages    int
+	Tokens      int
+	Cost        float64
+}
+
+// SearchQuery is a full-text search over conversations.
+type SearchQuery struct {
+	Folder string
+	Source string
+	Query  string
+	Limit  int
+}
+
+// SearchHit is one full-text search result. Higher scores rank first.
+type SearchHit struct {
+	ConversationRecord
+	Snippet string
+	Score   float64
+}
+
+// Querier is implemented by backends that index frontmatter and content
+// and can answer listings and searches without reading every file.
+type Querier interface {
+	// QueryConversations returns one page of matching conversations,
+	// ordered by path, and the total number of matches.
+	QueryConversations(ctx context.Context, q ConversationQuery) ([]ConversationRecord, int, error)
+
+	// SearchConversations returns the best matches for q.Query.
+	SearchConversations(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+}
+
+// Querier returns the backend's query interface, or nil if it has none.
+// It is nil when storage is encrypted, replicated or has an outbox, since
+// the index would then be incomplete or hold only ciphertext.
+func (s *Storage) Querier() Querier {
+	q, _ := s.backend.(Querier)
+	return q
+}
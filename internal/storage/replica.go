@@ -0,0 +1,634 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grokify/omnistorage"
+)
+
+// Replication defaults
+const (
+	DefaultReplicaMaxRetries   = 5
+	DefaultReplicaRetryBackoff = 2 * time.Second
+)
+
+// BackendSpec names an omnistorage backend and its configuration.
+type BackendSpec struct {
+	Name   string
+	Config map[string]string
+}
+
+// ReplicationConfig configures write-through replication.
+type ReplicationConfig struct {
+	// Replicas are opened in addition to the primary backend.
+	Replicas []BackendSpec
+
+	// Quorum is the number of backends, including the primary, that must
+	// accept a write for it to succeed. Values below 1 mean 1.
+	Quorum int
+
+	// MaxRetries is how many times a failed replica write is retried in the
+	// background before the path is reported as diverged.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry; it doubles with
+	// each attempt.
+	RetryBackoff time.Duration
+}
+
+// ReplicaStatus reports the state of one backend.
+type ReplicaStatus struct {
+	Name      string        `json:"name"`
+	Primary   bool          `json:"primary"`
+	Healthy   bool          `json:"healthy"`
+	Latency   time.Duration `json:"latency"`
+	Pending   []string      `json:"pending,omitempty"`
+	Failed    []string      `json:"failed,omitempty"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
+// Divergence describes a path whose content differs between backends.
+type Divergence struct {
+	Path    string   `json:"path"`
+	Missing []string `json:"missing,omitempty"`
+	Differs []string `json:"differs,omitempty"`
+}
+
+// ReplicatedBackend is an omnistorage.Backend that writes through to a
+// primary and its replicas. Writes and deletes fan out to every backend and
+// succeed once the primary and a quorum have accepted them; failed replica
+// writes are retried in the background. Reads are served by the fastest
+// healthy backend that is not behind on the requested path.
+//
+// Retries are kept in memory only: writes still pending when the process
+// exits are not retried on restart. Divergence finds the paths they leave
+// behind, and syncing the primary to the replica repairs them.
+type ReplicatedBackend struct {
+	members []*replica // members[0] is the primary
+	cfg     ReplicationConfig
+	gen     atomic.Uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type replica struct {
+	name    string
+	backend omnistorage.Backend
+
+	mu      sync.Mutex
+	healthy bool
+	latency time.Duration
+	lastErr error
+	pending map[string]*pendingOp
+
+	// latest is the generation of the newest op started on each path, and
+	// locks serialize applying ops to a path, so an older op never lands
+	// after a newer one
+	latest map[string]uint64
+	locks  map[string]*pathLock
+}
+
+type pathLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// pendingOp is a replica write or delete awaiting retry. Ops are numbered
+// by generation in the order they start.
+type pendingOp struct {
+	path     string
+	content  []byte
+	delete   bool
+	gen      uint64
+	attempts int
+	failed   bool
+}
+
+// errSuperseded is returned for an op not applied because a newer op on
+// the same path has started.
+var errSuperseded = errors.New("superseded by a newer write")
+
+// Replica pairs a name with an opened backend.
+type Replica struct {
+	Name    string
+	Backend omnistorage.Backend
+}
+
+// NewReplicatedBackend creates a ReplicatedBackend from opened backends.
+func NewReplicatedBackend(primary Replica, replicas []Replica, cfg ReplicationConfig) *ReplicatedBackend {
+	if cfg.Quorum < 1 {
+		cfg.Quorum = 1
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = DefaultReplicaMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultReplicaRetryBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rb := &ReplicatedBackend{cfg: cfg, ctx: ctx, cancel: cancel}
+	for _, r := range append([]Replica{primary}, replicas...) {
+		rb.members = append(rb.members, &replica{
+			name:    r.Name,
+			backend: r.Backend,
+			healthy: true,
+			pending: make(map[string]*pendingOp),
+			latest:  make(map[string]uint64),
+			locks:   make(map[string]*pathLock),
+		})
+	}
+	return rb
+}
+
+// OpenReplicated opens the replicas in cfg and combines them with an
+// already-opened primary backend.
+func OpenReplicated(primarySpec BackendSpec, primary omnistorage.Backend, cfg ReplicationConfig) (*ReplicatedBackend, error) {
+	names := map[string]int{primarySpec.Name: 1}
+	replicas := make([]Replica, 0, len(cfg.Replicas))
+	for _, spec := range cfg.Replicas {
+		backend, err := omnistorage.Open(spec.Name, spec.Config)
+		if err != nil {
+			for _, r := range replicas {
+				r.Backend.Close()
+			}
+			return nil, fmt.Errorf("failed to open replica %s: %w", spec.Name, err)
+		}
+
+		// Disambiguate replicas of the same backend type
+		name := spec.Name
+		names[spec.Name]++
+		if n := names[spec.Name]; n > 1 {
+			name = fmt.Sprintf("%s#%d", spec.Name, n)
+		}
+		replicas = append(replicas, Replica{Name: name, Backend: backend})
+	}
+
+	if cfg.Quorum > len(replicas)+1 {
+		return nil, fmt.Errorf("replication quorum %d exceeds %d backends", cfg.Quorum, len(replicas)+1)
+	}
+
+	return NewReplicatedBackend(Replica{Name: primarySpec.Name, Backend: primary}, replicas, cfg), nil
+}
+
+// NewWriter returns a writer that fans out to all backends when closed.
+func (rb *ReplicatedBackend) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	return &replicatedWriter{ctx: ctx, rb: rb, path: path, opts: opts}, nil
+}
+
+// NewReader reads from the fastest healthy backend that is current for path.
+func (rb *ReplicatedBackend) NewReader(ctx context.Context, path string, opts ...omnistorage.ReaderOption) (io.ReadCloser, error) {
+	var firstErr error
+	for _, m := range rb.readOrder(path) {
+		start := time.Now()
+		content, err := readAll(ctx, m.backend, path, opts...)
+		if err != nil {
+			if !omnistorage.IsNotFound(err) {
+				m.recordFailure(err)
+			}
+			if firstErr == nil || (omnistorage.IsNotFound(firstErr) && !omnistorage.IsNotFound(err)) {
+				firstErr = err
+			}
+			continue
+		}
+		m.recordSuccess(time.Since(start))
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return nil, firstErr
+}
+
+// Exists checks the primary.
+func (rb *ReplicatedBackend) Exists(ctx context.Context, path string) (bool, error) {
+	return rb.primary().backend.Exists(ctx, path)
+}
+
+// Delete removes path from all backends.
+func (rb *ReplicatedBackend) Delete(ctx context.Context, path string) error {
+	return rb.fanOut(ctx, &pendingOp{path: path, delete: true}, nil)
+}
+
+// List lists paths from the primary.
+func (rb *ReplicatedBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	return rb.primary().backend.List(ctx, prefix)
+}
+
+// Close stops background retries and closes every backend.
+func (rb *ReplicatedBackend) Close() error {
+	rb.cancel()
+	rb.wg.Wait()
+
+	var errs []error
+	for _, m := range rb.members {
+		if err := m.backend.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Status reports the health and pending work of each backend.
+func (rb *ReplicatedBackend) Status() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(rb.members))
+	for i, m := range rb.members {
+		m.mu.Lock()
+		status := ReplicaStatus{
+			Name:    m.name,
+			Primary: i == 0,
+			Healthy: m.healthy,
+			Latency: m.latency,
+		}
+		if m.lastErr != nil {
+			status.LastError = m.lastErr.Error()
+		}
+		for p, op := range m.pending {
+			if op.failed {
+				status.Failed = append(status.Failed, p)
+			} else {
+				status.Pending = append(status.Pending, p)
+			}
+		}
+		m.mu.Unlock()
+
+		sort.Strings(status.Pending)
+		sort.Strings(status.Failed)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Divergence compares every backend under prefix and reports paths that
+// are missing from, or differ on, any replica relative to the primary.
+func (rb *ReplicatedBackend) Divergence(ctx context.Context, prefix string) ([]Divergence, error) {
+	hashes := make([]map[string][32]byte, len(rb.members))
+	for i, m := range rb.members {
+		paths, err := m.backend.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", m.name, err)
+		}
+		hashes[i] = make(map[string][32]byte, len(paths))
+		for _, p := range paths {
+			content, err := readAll(ctx, m.backend, p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from %s: %w", p, m.name, err)
+			}
+			hashes[i][p] = sha256.Sum256(content)
+		}
+	}
+
+	all := make(map[string]bool)
+	for _, h := range hashes {
+		for p := range h {
+			all[p] = true
+		}
+	}
+
+	var result []Divergence
+	for p := range all {
+		want, inPrimary := hashes[0][p]
+		d := Divergence{Path: p}
+		if !inPrimary {
+			d.Missing = append(d.Missing, rb.members[0].name)
+		}
+		for i := 1; i < len(rb.members); i++ {
+			got, ok := hashes[i][p]
+			switch {
+			case !ok:
+				d.Missing = append(d.Missing, rb.members[i].name)
+			case inPrimary && got != want:
+				d.Differs = append(d.Differs, rb.members[i].name)
+			}
+		}
+		if len(d.Missing) > 0 || len(d.Differs) > 0 {
+			result = append(result, d)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+func (rb *ReplicatedBackend) primary() *replica {
+	return rb.members[0]
+}
+
+// readOrder returns backends to try for path: healthy, current backends by
+// latency first (the primary wins ties), then everything else.
+func (rb *ReplicatedBackend) readOrder(path string) []*replica {
+	type candidate struct {
+		m       *replica
+		rank    int
+		latency time.Duration
+		index   int
+	}
+
+	candidates := make([]candidate, 0, len(rb.members))
+	for i, m := range rb.members {
+		m.mu.Lock()
+		_, behind := m.pending[path]
+		c := candidate{m: m, latency: m.latency, index: i}
+		switch {
+		case behind:
+			c.rank = 2
+		case !m.healthy:
+			c.rank = 1
+		}
+		m.mu.Unlock()
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.latency != b.latency && a.latency > 0 && b.latency > 0 {
+			return a.latency < b.latency
+		}
+		return a.index < b.index
+	})
+
+	order := make([]*replica, len(candidates))
+	for i, c := range candidates {
+		order[i] = c.m
+	}
+	return order
+}
+
+// fanOut applies op to every backend concurrently. The primary must
+// succeed and at least Quorum backends must succeed; failed replicas are
+// queued for retry.
+func (rb *ReplicatedBackend) fanOut(ctx context.Context, op *pendingOp, opts []omnistorage.WriterOption) error {
+	op.gen = rb.gen.Add(1)
+	for _, m := range rb.members {
+		m.start(op.gen, op.path)
+	}
+
+	errs := make([]error, len(rb.members))
+	var wg sync.WaitGroup
+	for i, m := range rb.members {
+		wg.Add(1)
+		go func(i int, m *replica) {
+			defer wg.Done()
+			unlock := m.lock(op.path)
+			defer unlock()
+			if m.superseded(op.gen, op.path) {
+				errs[i] = errSuperseded
+				return
+			}
+			errs[i] = m.apply(ctx, op, opts)
+		}(i, m)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, m := range rb.members {
+		if errs[i] == nil {
+			succeeded++
+			m.clearPending(op.path, op.gen)
+			continue
+		}
+		// A newer write to the path is applied after this one would have
+		// been, so this one is done
+		if errors.Is(errs[i], errSuperseded) {
+			succeeded++
+			errs[i] = nil
+			continue
+		}
+		// A delete of something already absent is not a failure
+		if op.delete && omnistorage.IsNotFound(errs[i]) {
+			succeeded++
+			m.clearPending(op.path, op.gen)
+			errs[i] = nil
+			continue
+		}
+		if i > 0 {
+			rb.scheduleRetry(m, &pendingOp{path: op.path, content: op.content, delete: op.delete, gen: op.gen})
+		}
+	}
+
+	if errs[0] != nil {
+		return fmt.Errorf("primary %s: %w", rb.primary().name, errs[0])
+	}
+	if succeeded < rb.cfg.Quorum {
+		return fmt.Errorf("replication quorum not met for %s: %d of %d required backends succeeded: %w",
+			op.path, succeeded, rb.cfg.Quorum, errors.Join(errs...))
+	}
+	return nil
+}
+
+// scheduleRetry queues op for a replica, replacing any older op for the
+// same path, and retries it with exponential backoff. A retry is dropped
+// once a newer op on the path starts.
+func (rb *ReplicatedBackend) scheduleRetry(m *replica, op *pendingOp) {
+	m.mu.Lock()
+	if m.latest[op.path] > op.gen {
+		m.mu.Unlock()
+		return
+	}
+	m.pending[op.path] = op
+	m.mu.Unlock()
+
+	rb.wg.Add(1)
+	go func() {
+		defer rb.wg.Done()
+		backoff := rb.cfg.RetryBackoff
+		for {
+			select {
+			case <-rb.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			m.mu.Lock()
+			current := m.pending[op.path] == op
+			m.mu.Unlock()
+			if !current {
+				return // superseded by a newer write
+			}
+
+			err := m.retry(rb.ctx, op)
+			if err == nil || errors.Is(err, errSuperseded) || (op.delete && omnistorage.IsNotFound(err)) {
+				m.mu.Lock()
+				if m.pending[op.path] == op {
+					delete(m.pending, op.path)
+				}
+				m.mu.Unlock()
+				return
+			}
+
+			m.mu.Lock()
+			op.attempts++
+			if op.attempts >= rb.cfg.MaxRetries {
+				op.failed = true
+				m.mu.Unlock()
+				log.Printf("replica %s diverged on %s after %d retries: %v", m.name, op.path, op.attempts, err)
+				return
+			}
+			m.mu.Unlock()
+			backoff *= 2
+		}
+	}()
+}
+
+// retry applies a pending op unless a newer op on its path has started.
+func (m *replica) retry(ctx context.Context, op *pendingOp) error {
+	unlock := m.lock(op.path)
+	defer unlock()
+	if m.superseded(op.gen, op.path) {
+		return errSuperseded
+	}
+	return m.apply(ctx, op, nil)
+}
+
+func (m *replica) apply(ctx context.Context, op *pendingOp, opts []omnistorage.WriterOption) error {
+	start := time.Now()
+	var err error
+	if op.delete {
+		err = m.backend.Delete(ctx, op.path)
+	} else {
+		err = writeAll(ctx, m.backend, op.path, op.content, opts...)
+	}
+	if err != nil && !(op.delete && omnistorage.IsNotFound(err)) {
+		m.recordFailure(err)
+		return err
+	}
+	m.recordSuccess(time.Since(start))
+	return err
+}
+
+// start records that an op of generation gen has started on paths.
+func (m *replica) start(gen uint64, paths ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range paths {
+		m.latest[p] = max(m.latest[p], gen)
+	}
+}
+
+// superseded reports whether an op newer than gen has started on path.
+func (m *replica) superseded(gen uint64, path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latest[path] > gen
+}
+
+// lock acquires the apply locks of paths, in order so that ops locking
+// several paths cannot deadlock, and returns a function releasing them.
+func (m *replica) lock(paths ...string) (unlock func()) {
+	paths = slices.Compact(slices.Sorted(slices.Values(paths)))
+	locks := make([]*pathLock, len(paths))
+	m.mu.Lock()
+	for i, p := range paths {
+		l := m.locks[p]
+		if l == nil {
+			l = &pathLock{}
+			m.locks[p] = l
+		}
+		l.refs++
+		locks[i] = l
+	}
+	m.mu.Unlock()
+
+	for _, l := range locks {
+		l.mu.Lock()
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, l := range locks {
+			l.mu.Unlock()
+			if l.refs--; l.refs == 0 {
+				delete(m.locks, paths[i])
+			}
+		}
+	}
+}
+
+// clearPending removes the pending op on path if it is not newer than gen.
+func (m *replica) clearPending(path string, gen uint64) {
+	m.mu.Lock()
+	if op, ok := m.pending[path]; ok && op.gen <= gen {
+		delete(m.pending, path)
+	}
+	m.mu.Unlock()
+}
+
+func (m *replica) recordSuccess(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthy = true
+	if m.latency == 0 {
+		m.latency = d
+	} else {
+		// Exponentially weighted moving average
+		m.latency = (m.latency*4 + d) / 5
+	}
+}
+
+func (m *replica) recordFailure(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthy = false
+	m.lastErr = err
+}
+
+// replicatedWriter buffers content until Close, then fans it out.
+type replicatedWriter struct {
+	ctx    context.Context
+	rb     *ReplicatedBackend
+	path   string
+	opts   []omnistorage.WriterOption
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *replicatedWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, omnistorage.ErrWriterClosed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *replicatedWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.rb.fanOut(w.ctx, &pendingOp{path: w.path, content: w.buf.Bytes()}, w.opts)
+}
+
+func readAll(ctx context.Context, backend omnistorage.Backend, path string, opts ...omnistorage.ReaderOption) ([]byte, error) {
+	r, err := backend.NewReader(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func writeAll(ctx context.Context, backend omnistorage.Backend, path string, content []byte, opts ...omnistorage.WriterOption) error {
+	w, err := backend.NewWriter(ctx, path, opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+var _ omnistorage.Backend = (*ReplicatedBackend)(nil)
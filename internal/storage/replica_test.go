@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grokify/omnistorage"
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+// flakyBackend fails writes while down is set.
+type flakyBackend struct {
+	omnistorage.Backend
+	down atomic.Bool
+}
+
+var errDown = errors.New("backend down")
+
+func (f *flakyBackend) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	if f.down.Load() {
+		return nil, errDown
+	}
+	return f.Backend.NewWriter(ctx, path, opts...)
+}
+
+func TestReplicationFanOut(t *testing.T) {
+	ctx := context.Background()
+	primary := memory.New()
+	mirror := memory.New()
+	rb := NewReplicatedBackend(
+		Replica{Name: "primary", Backend: primary},
+		[]Replica{{Name: "mirror", Backend: mirror}},
+		ReplicationConfig{Quorum: 2},
+	)
+	store := New(rb, "conversations")
+	defer store.Close()
+
+	if err := store.Save(ctx, "conversations/a.md", []byte("a")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := string(mustReadBackend(t, mirror, "conversations/a.md")); got != "a" {
+		t.Errorf("mirror content = %q, want %q", got, "a")
+	}
+
+	if err := store.Delete(ctx, "conversations/a.md"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, _ := mirror.Exists(ctx, "conversations/a.md"); exists {
+		t.Error("delete was not replicated")
+	}
+
+	if store.Replication() != rb {
+		t.Error("Replication() did not return the replicated backend")
+	}
+}
+
+func TestReplicationRetryAndQuorum(t *testing.T) {
+	ctx := context.Background()
+	mirror := &flakyBackend{Backend: memory.New()}
+	mirror.down.Store(true)
+
+	rb := NewReplicatedBackend(
+		Replica{Name: "primary", Backend: memory.New()},
+		[]Replica{{Name: "mirror", Backend: mirror}},
+		ReplicationConfig{Quorum: 1, RetryBackoff: 10 * time.Millisecond},
+	)
+	defer rb.Close()
+	store := New(rb, "conversations")
+
+	// Quorum 1: the primary alone is enough
+	if err := store.Save(ctx, "conversations/a.md", []byte("a")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if status := rb.Status(); len(status[1].Pending) != 1 || status[1].Healthy {
+		t.Errorf("mirror status = %+v, want one pending write and unhealthy", status[1])
+	}
+
+	// Reads avoid the replica that is behind
+	if got, err := store.Read(ctx, "conversations/a.md"); err != nil || string(got) != "a" {
+		t.Errorf("Read() = %q, %v", got, err)
+	}
+
+	divergence, err := rb.Divergence(ctx, "conversations")
+	if err != nil {
+		t.Fatalf("Divergence() error = %v", err)
+	}
+	if len(divergence) != 1 || len(divergence[0].Missing) != 1 {
+		t.Errorf("Divergence() = %+v, want a.md missing on mirror", divergence)
+	}
+
+	// Once the replica recovers, the background retry catches it up
+	mirror.down.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rb.Status()[1].Pending) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := string(mustReadBackend(t, mirror, "conversations/a.md")); got != "a" {
+		t.Errorf("mirror content after retry = %q, want %q", got, "a")
+	}
+
+	// Quorum 2 fails while the replica is down
+	strict := NewReplicatedBackend(
+		Replica{Name: "primary", Backend: memory.New()},
+		[]Replica{{Name: "mirror", Backend: mirror}},
+		ReplicationConfig{Quorum: 2, RetryBackoff: time.Hour},
+	)
+	defer strict.Close()
+	mirror.down.Store(true)
+	if err := New(strict, "conversations").Save(ctx, "conversations/b.md", []byte("b")); err == nil {
+		t.Error("Save() with unmet quorum should fail")
+	}
+}
+
+// gatedBackend holds writes of gated content until release is closed,
+// signalling entered when one arrives.
+type gatedBackend struct {
+	*flakyBackend
+	gated   string
+	entered chan struct{}
+	release chan struct{}
+}
+
+type gatedWriter struct {
+	b    *gatedBackend
+	w    io.WriteCloser
+	data []byte
+}
+
+func (g *gatedBackend) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	w, err := g.flakyBackend.NewWriter(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gatedWriter{b: g, w: w}, nil
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.data = append(w.data, p...)
+	return len(p), nil
+}
+
+func (w *gatedWriter) Close() error {
+	if string(w.data) == w.b.gated {
+		w.b.entered <- struct{}{}
+		<-w.b.release
+	}
+	if _, err := w.w.Write(w.data); err != nil {
+		return err
+	}
+	return w.w.Close()
+}
+
+func TestReplicationRetryDoesNotOverwriteNewerWrite(t *testing.T) {
+	ctx := context.Background()
+	mirror := &gatedBackend{
+		flakyBackend: &flakyBackend{Backend: memory.New()},
+		gated:        "v1",
+		entered:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	mirror.down.Store(true)
+
+	rb := NewReplicatedBackend(
+		Replica{Name: "primary", Backend: memory.New()},
+		[]Replica{{Name: "mirror", Backend: mirror}},
+		ReplicationConfig{Quorum: 1, RetryBackoff: time.Millisecond},
+	)
+	defer rb.Close()
+	store := New(rb, "conversations")
+
+	if err := store.Save(ctx, "conversations/a.md", []byte("v1")); err != nil {
+		t.Fatalf("Save(v1) error = %v", err)
+	}
+	mirror.down.Store(false)
+	<-mirror.entered // the retry of v1 is being written
+
+	saved := make(chan error)
+	go func() { saved <- store.Save(ctx, "conversations/a.md", []byte("v2")) }()
+	time.Sleep(20 * time.Millisecond)
+	close(mirror.release)
+	if err := <-saved; err != nil {
+		t.Fatalf("Save(v2) error = %v", err)
+	}
+	rb.wg.Wait() // for the retry to finish
+
+	if got := string(mustReadBackend(t, mirror, "conversations/a.md")); got != "v2" {
+		t.Errorf("mirror content = %q, want the newer write", got)
+	}
+	if status := rb.Status(); len(status[1].Pending) != 0 || len(status[1].Failed) != 0 {
+		t.Errorf("mirror status = %+v, want nothing pending", status[1])
+	}
+}
+
+func mustReadBackend(t *testing.T, b omnistorage.Backend, path string) []byte {
+	t.Helper()
+	content, err := readAll(context.Background(), b, path)
+	if err != nil {
+		t.Fatalf("read %s error = %v", path, err)
+	}
+	return content
+}
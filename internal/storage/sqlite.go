@@ -0,0 +1,496 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grokify/omnistorage"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+
+	// Pure-Go SQLite driver with FTS5
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteBackendName is the omnistorage name of the SQLite backend.
+const SQLiteBackendName = "sqlite"
+
+func init() {
+	omnistorage.Register(SQLiteBackendName, func(config map[string]string) (omnistorage.Backend, error) {
+		return OpenSQLite(config["path"])
+	})
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS documents (
+	id              INTEGER PRIMARY KEY,
+	path            TEXT NOT NULL UNIQUE,
+	header          TEXT NOT NULL DEFAULT '',
+	body            TEXT NOT NULL DEFAULT '',
+	title           TEXT NOT NULL DEFAULT '',
+	source          TEXT NOT NULL DEFAULT '',
+	conversation_id TEXT NOT NULL DEFAULT '',
+	date            TEXT NOT NULL DEFAULT '',
+	lastmod         TEXT NOT NULL DEFAULT '',
+	author          TEXT NOT NULL DEFAULT '',
+	description     TEXT NOT NULL DEFAULT '',
+	model           TEXT NOT NULL DEFAULT '',
+	message_count   INTEGER NOT NULL DEFAULT 0,
+	tokens          INTEGER NOT NULL DEFAULT 0,
+	cost            REAL NOT NULL DEFAULT 0,
+	draft           INTEGER NOT NULL DEFAULT 0,
+	tags            TEXT NOT NULL DEFAULT '[]',
+	categories      TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX IF NOT EXISTS documents_source ON documents(source, path);
+CREATE INDEX IF NOT EXISTS documents_date ON documents(date);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS documents_fts USING fts5(
+	title, description, tags, body,
+	content='documents', content_rowid='id'
+);
+CREATE TRIGGER IF NOT EXISTS documents_ai AFTER INSERT ON documents BEGIN
+	INSERT INTO documents_fts(rowid, title, description, tags, body)
+	VALUES (new.id, new.title, new.description, new.tags, new.body);
+END;
+CREATE TRIGGER IF NOT EXISTS documents_ad AFTER DELETE ON documents BEGIN
+	INSERT INTO documents_fts(documents_fts, rowid, title, description, tags, body)
+	VALUES ('delete', old.id, old.title, old.description, old.tags, old.body);
+END;
+CREATE TRIGGER IF NOT EXISTS documents_au AFTER UPDATE ON documents BEGIN
+	INSERT INTO documents_fts(documents_fts, rowid, title, description, tags, body)
+	VALUES ('delete', old.id, old.title, old.description, old.tags, old.body);
+	INSERT INTO documents_fts(rowid, title, description, tags, body)
+	VALUES (new.id, new.title, new.description, new.tags, new.body);
+END;
+`
+
+// sqliteColumns are columns added to documents after its first version.
+// Databases created before then get them on open; existing rows are
+// filled in when their documents are next written.
+var sqliteColumns = []struct{ name, definition string }{
+	{"cost", "REAL NOT NULL DEFAULT 0"},
+}
+
+// SQLiteBackend is an omnistorage.Backend that stores documents in a
+// SQLite database. Frontmatter fields are kept as columns and bodies are
+// indexed with FTS5, so listings and searches are SQL queries. The raw
+// frontmatter block is stored too, so reads return the exact bytes
+// written and exports produce the same Hugo Markdown.
+type SQLiteBackend struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// OpenSQLite opens or creates the database at path. Use ":memory:" for a
+// private in-memory database.
+func OpenSQLite(path string) (*SQLiteBackend, error) {
+	if path == "" {
+		return nil, errors.New("sqlite: path is required")
+	}
+
+	dsn := path
+	if path != ":memory:" {
+		dsn = "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: %w", err)
+	}
+	// A single connection serializes writers and keeps :memory: databases
+	// shared across calls
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: failed to create schema: %w", err)
+	}
+	if err := sqliteAddColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: failed to upgrade schema: %w", err)
+	}
+	return &SQLiteBackend{db: db}, nil
+}
+
+func sqliteAddColumns(db *sql.DB) error {
+	for _, c := range sqliteColumns {
+		var n int
+		if err := db.QueryRow(`SELECT count(*) FROM pragma_table_info('documents') WHERE name = ?`, c.name).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE documents ADD COLUMN ` + c.name + ` ` + c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewWriter returns a writer that stores its content when closed.
+func (b *SQLiteBackend) NewWriter(ctx context.Context, path string, opts ...omnistorage.WriterOption) (io.WriteCloser, error) {
+	if err := b.check(path); err != nil {
+		return nil, err
+	}
+	return &sqliteWriter{ctx: ctx, backend: b, path: path}, nil
+}
+
+// NewReader returns the stored document.
+func (b *SQLiteBackend) NewReader(ctx context.Context, path string, opts ...omnistorage.ReaderOption) (io.ReadCloser, error) {
+	if err := b.check(path); err != nil {
+		return nil, err
+	}
+
+	var header, body string
+	err := b.db.QueryRowContext(ctx, `SELECT header, body FROM documents WHERE path = ?`, path).Scan(&header, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, omnistorage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: %w", err)
+	}
+	return io.NopCloser(strings.NewReader(header + body)), nil
+}
+
+// Exists checks if a document exists.
+func (b *SQLiteBackend) Exists(ctx context.Context, path string) (bool, error) {
+	if err := b.check(path); err != nil {
+		return false, err
+	}
+
+	var n int
+	if err := b.db.QueryRowContext(ctx, `SELECT count(*) FROM documents WHERE path = ?`, path).Scan(&n); err != nil {
+		return false, fmt.Errorf("sqlite: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Delete removes a document.
+func (b *SQLiteBackend) Delete(ctx context.Context, path string) error {
+	if err := b.check(path); err != nil {
+		return err
+	}
+
+	res, err := b.db.ExecContext(ctx, `DELETE FROM documents WHERE path = ?`, path)
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return omnistorage.ErrNotFound
+	}
+	return nil
+}
+
+// List lists document paths under prefix, recursively.
+func (b *SQLiteBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := b.check(""); err != nil {
+		return nil, err
+	}
+
+	where, args := sqlitePrefix("path", prefix)
+	rows, err := b.db.QueryContext(ctx, `SELECT path FROM documents WHERE `+where+` ORDER BY path`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("sqlite: %w", err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+// Close closes the database.
+func (b *SQLiteBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	return b.db.Close()
+}
+
+// ApplyBatch applies ops in a single transaction.
+func (b *SQLiteBackend) ApplyBatch(ctx context.Context, message string, ops []BatchOp) error {
+	if err := b.check(""); err != nil {
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, op := range ops {
+		if op.Delete {
+			_, err = tx.ExecContext(ctx, `DELETE FROM documents WHERE path = ?`, op.Path)
+		} else {
+			err = sqlitePut(ctx, tx, op.Path, op.Content)
+		}
+		if err != nil {
+			return fmt.Errorf("sqlite: %s: %w", op.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// QueryConversations lists conversations with SQL.
+func (b *SQLiteBackend) QueryConversations(ctx context.Context, q ConversationQuery) ([]ConversationRecord, int, error) {
+	if err := b.check(""); err != nil {
+		return nil, 0, err
+	}
+
+	where, args := sqliteConversationFilter(q.Folder, q.Source)
+
+	var total int
+	if err := b.db.QueryRowContext(ctx, `SELECT count(*) FROM documents d WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("sqlite: %w", err)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := b.db.QueryContext(ctx,
+		`SELECT d.path, d.title, d.source, d.date, d.tags, d.categories, d.description, d.model,
+			d.message_count, d.tokens, d.cost
+		FROM documents d WHERE `+where+` ORDER BY d.path LIMIT ? OFFSET ?`,
+		append(args, limit, max(q.Offset, 0))...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sqlite: %w", err)
+	}
+	defer rows.Close()
+
+	var records []ConversationRecord
+	for rows.Next() {
+		r, err := scanConversationRecord(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, r)
+	}
+	return records, total, rows.Err()
+}
+
+// SearchConversations ranks conversations with FTS5 and BM25. Every word
+// in the query must match, as a prefix, in the title, description, tags
+// or body.
+func (b *SQLiteBackend) SearchConversations(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	if err := b.check(""); err != nil {
+		return nil, err
+	}
+
+	match := ftsQuery(q.Query)
+	if match == "" {
+		return nil, nil
+	}
+	where, args := sqliteConversationFilter(q.Folder, q.Source)
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := b.db.QueryContext(ctx,
+		`SELECT d.path, d.title, d.source, d.date, d.tags, d.categories, d.description, d.model,
+			d.message_count, d.tokens, d.cost,
+			snippet(documents_fts, 3, '', '', '...', 24), bm25(documents_fts)
+		FROM documents_fts JOIN documents d ON d.id = documents_fts.rowid
+		WHERE documents_fts MATCH ? AND `+where+`
+		ORDER BY bm25(documents_fts) LIMIT ?`,
+		append(append([]any{match}, args...), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var (
+			hit     SearchHit
+			snippet sql.NullString
+			rank    float64
+		)
+		if hit.ConversationRecord, err = scanConversationRecord(rows, &snippet, &rank); err != nil {
+			return nil, err
+		}
+		hit.Snippet = strings.Join(strings.Fields(snippet.String), " ")
+		hit.Score = -rank // bm25 is lower for better matches
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func (b *SQLiteBackend) check(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return omnistorage.ErrBackendClosed
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == ".." {
+			return omnistorage.ErrInvalidPath
+		}
+	}
+	return nil
+}
+
+// sqliteExecer is satisfied by *sql.DB and *sql.Tx.
+type sqliteExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// sqlitePut upserts a document, extracting frontmatter fields into columns.
+func sqlitePut(ctx context.Context, db sqliteExecer, path string, content []byte) error {
+	header, body, _ := frontmatter.Split(content)
+
+	var fm frontmatter.Frontmatter
+	if parsed, _, err := frontmatter.Parse(content); err == nil && parsed != nil {
+		fm = *parsed
+	}
+	tags, _ := json.Marshal(nonNil(fm.Tags))
+	categories, _ := json.Marshal(nonNil(fm.Categories))
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO documents (path, header, body, title, source, conversation_id, date, lastmod,
+			author, description, model, message_count, tokens, cost, draft, tags, categories)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET
+			header = excluded.header, body = excluded.body, title = excluded.title,
+			source = excluded.source, conversation_id = excluded.conversation_id,
+			date = excluded.date, lastmod = excluded.lastmod, author = excluded.author,
+			description = excluded.description, model = excluded.model,
+			message_count = excluded.message_count, tokens = excluded.tokens, cost = excluded.cost,
+			draft = excluded.draft, tags = excluded.tags, categories = excluded.categories`,
+		path, string(header), string(body), fm.Title, fm.Source, fm.ConversationID,
+		sqliteTime(fm.Date), sqliteTime(fm.LastMod), fm.Author, fm.Description, fm.Model,
+		messageCount(&fm, body), fm.Tokens, fm.Cost, fm.Draft, string(tags), string(categories))
+	return err
+}
+
+// sqlitePrefix returns a condition matching values of column under prefix.
+func sqlitePrefix(column, prefix string) (string, []any) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return "1 = 1", nil
+	}
+	dir := prefix + "/"
+	return fmt.Sprintf("(%[1]s = ? OR substr(%[1]s, 1, ?) = ?)", column), []any{prefix, len(dir), dir}
+}
+
+// sqliteConversationFilter matches Markdown files under folder, outside
+// internal areas, optionally from one source.
+func sqliteConversationFilter(folder, source string) (string, []any) {
+	where, args := sqlitePrefix("d.path", folder)
+	where += ` AND d.path GLOB '*.md'`
+
+	// Internal areas are directories whose name starts with "."
+	folder = strings.Trim(folder, "/")
+	skip := 0
+	if folder != "" {
+		skip = len(folder) + 1
+	}
+	where += ` AND ('/' || substr(d.path, ? + 1)) NOT GLOB '*/.*/*'`
+	args = append(args, skip)
+
+	if source != "" {
+		where += ` AND d.source = ?`
+		args = append(args, source)
+	}
+	return where, args
+}
+
+// scanConversationRecord scans the listing columns, followed by extra.
+func scanConversationRecord(rows *sql.Rows, extra ...any) (ConversationRecord, error) {
+	var (
+		r          ConversationRecord
+		date       string
+		tags       string
+		categories string
+	)
+	dest := append([]any{&r.Path, &r.Title, &r.Source, &date, &tags, &categories, &r.Description, &r.Model, &r.Messages, &r.Tokens, &r.Cost}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return r, fmt.Errorf("sqlite: %w", err)
+	}
+	r.Date, _ = time.Parse(time.RFC3339, date)
+	_ = json.Unmarshal([]byte(tags), &r.Tags)
+	_ = json.Unmarshal([]byte(categories), &r.Categories)
+	return r, nil
+}
+
+// ftsQuery turns free text into an FTS5 query in which every word must
+// match as a prefix.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func sqliteTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+type sqliteWriter struct {
+	ctx     context.Context
+	backend *SQLiteBackend
+	path    string
+	buf     bytes.Buffer
+	closed  bool
+}
+
+func (w *sqliteWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, omnistorage.ErrWriterClosed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *sqliteWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.backend.check(w.path); err != nil {
+		return err
+	}
+	if err := sqlitePut(w.ctx, w.backend.db, w.path, w.buf.Bytes()); err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	return nil
+}
+
+var (
+	_ omnistorage.Backend = (*SQLiteBackend)(nil)
+	_ Batcher             = (*SQLiteBackend)(nil)
+	_ Querier             = (*SQLiteBackend)(nil)
+)
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sqliteTestDoc = "---\ntitle: Kubernetes Networking\ndate: 2026-01-15T10:00:00Z\ntags:\n- k8s\nsource: claude\n---\n\r\n**User:** How do services route traffic?\n"
+
+func TestSQLiteBackend(t *testing.T) {
+	ctx := context.Background()
+	backend, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	store := New(backend, "conversations")
+	defer store.Close()
+
+	mustSave(t, store, "claude/k8s.md", sqliteTestDoc)
+	mustSave(t, store, "chatgpt/go.md", "---\ntitle: Go Generics\nsource: chatgpt\n---\n\nType parameters and constraints.\n")
+	mustSave(t, store, "notes.txt", "not a conversation")
+	if _, err := store.Trash(ctx, store.AbsPath("chatgpt/go.md")); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	mustSave(t, store, "chatgpt/go.md", "---\ntitle: Go Generics\nsource: chatgpt\n---\n\nType parameters, again.\n")
+
+	// Reads return the exact bytes written
+	if got := mustRead(t, store, "claude/k8s.md"); got != sqliteTestDoc {
+		t.Errorf("Read() = %q, want %q", got, sqliteTestDoc)
+	}
+
+	q := store.Querier()
+	if q == nil {
+		t.Fatal("Querier() = nil for SQLite backend")
+	}
+
+	records, total, err := q.QueryConversations(ctx, ConversationQuery{Folder: "conversations"})
+	if err != nil {
+		t.Fatalf("QueryConversations() error = %v", err)
+	}
+	if total != 2 || len(records) != 2 {
+		t.Fatalf("QueryConversations() = %+v (total %d), want 2 excluding trash and non-Markdown", records, total)
+	}
+	if records[1].Title != "Kubernetes Networking" || records[1].Date.Year() != 2026 || len(records[1].Tags) != 1 {
+		t.Errorf("record = %+v", records[1])
+	}
+
+	records, total, err = q.QueryConversations(ctx, ConversationQuery{Folder: "conversations", Source: "claude", Limit: 1})
+	if err != nil || total != 1 || records[0].Source != "claude" {
+		t.Errorf("QueryConversations(source) = %+v, %d, %v", records, total, err)
+	}
+
+	hits, err := q.SearchConversations(ctx, SearchQuery{Folder: "conversations", Query: "servic rout"})
+	if err != nil {
+		t.Fatalf("SearchConversations() error = %v", err)
+	}
+	if len(hits) != 1 || hits[0].Path != "conversations/claude/k8s.md" || hits[0].Snippet == "" {
+		t.Errorf("SearchConversations() = %+v", hits)
+	}
+
+	// Updates replace the indexed content
+	hits, err = q.SearchConversations(ctx, SearchQuery{Folder: "conversations", Query: "constraints"})
+	if err != nil || len(hits) != 0 {
+		t.Errorf("search for replaced text = %+v, %v; want none", hits, err)
+	}
+}
+
+func TestSQLiteAddsColumns(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chathub.db")
+
+	// A database from before the cost column
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(strings.Replace(sqliteSchema, "cost            REAL NOT NULL DEFAULT 0,", "", 1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	backend, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	store := New(backend, "conversations")
+	defer store.Close()
+
+	mustSave(t, store, "claude/cost.md", "---\ntitle: Cost\nsource: claude\nmodel: gpt-4o\ntokens: 120\ncost: 0.0042\n---\n\nBody\n")
+	records, _, err := store.Querier().QueryConversations(ctx, ConversationQuery{Folder: "conversations"})
+	if err != nil {
+		t.Fatalf("QueryConversations() error = %v", err)
+	}
+	if len(records) != 1 || records[0].Model != "gpt-4o" || records[0].Tokens != 120 || records[0].Cost != 0.0042 {
+		t.Errorf("QueryConversations() = %+v", records)
+	}
+}
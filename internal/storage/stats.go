@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grokify/chathub/internal/conversation"
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// Stats methods
+const (
+	StatsMethodIndex = "index" // from the backend's metadata index
+	StatsMethodScan  = "scan"  // by reading every conversation
+)
+
+// StatsUnknown names the group of conversations without a source, model
+// or date.
+const StatsUnknown = "unknown"
+
+// StatsOptions configures Stats.
+type StatsOptions struct {
+	// Source limits the statistics to one source, given by ID or name.
+	Source string
+
+	// Tags limits the tag counts to the most used tags. Zero reports
+	// every tag.
+	Tags int
+}
+
+// StatsCount is the number of conversations in a group, with their
+// message and token totals.
+type StatsCount struct {
+	Name          string `json:"name"`
+	Conversations int    `json:"conversations"`
+	Messages      int    `json:"messages,omitempty"`
+	Tokens        int    `json:"tokens,omitempty"`
+}
+
+// StatsResult summarizes the conversations in a store.
+type StatsResult struct {
+	Conversations   int           `json:"conversations"`
+	Messages        int           `json:"messages"`
+	Tokens          int           `json:"tokens"`
+	Cost            float64       `json:"cost,omitempty" jsonschema:"Estimated cost in USD of the conversations with a cost"`
+	AverageMessages float64       `json:"average_messages" jsonschema:"Average messages per conversation with a message count"`
+	AverageTokens   float64       `json:"average_tokens" jsonschema:"Average tokens per conversation with a token count"`
+	First           string        `json:"first,omitempty" jsonschema:"Date of the oldest conversation"`
+	Last            string        `json:"last,omitempty" jsonschema:"Date of the newest conversation"`
+	Sources         []StatsCount  `json:"sources,omitempty" jsonschema:"Counts by source, most used first"`
+	Models          []StatsCount  `json:"models,omitempty" jsonschema:"Counts by model, most used first"`
+	Tags            []StatsCount  `json:"tags,omitempty" jsonschema:"Counts by tag, most used first"`
+	TagCount        int           `json:"tag_count" jsonschema:"Number of distinct tags"`
+	Categories      []StatsCount  `json:"categories,omitempty" jsonschema:"Counts by category, most used first"`
+	Months          []StatsCount  `json:"months,omitempty" jsonschema:"Counts by month of the conversation date, oldest first"`
+	Method          string        `json:"method" jsonschema:"index if computed from the metadata index, scan if every conversation was read"`
+	Skipped         []SkippedFile `json:"skipped,omitempty" jsonschema:"Conversations that could not be read and are not counted"`
+}
+
+// Stats counts conversations by source, model, tag, category and month,
+// with message and token totals. With a backend that indexes frontmatter,
+// such as SQLite, only the index is read; otherwise every conversation is
+// read. Either way, message counts missing from the frontmatter are taken
+// from the body, and token totals come from the frontmatter. Conversations
+// with invalid frontmatter are counted as unknown, as the index stores
+// them; those that cannot be read are reported in Skipped.
+func Stats(ctx context.Context, s *Storage, opts StatsOptions) (*StatsResult, error) {
+	source := frontmatter.CanonicalSource(opts.Source)
+
+	var records []ConversationRecord
+	var skipped []SkippedFile
+	method := StatsMethodScan
+	if q := s.Querier(); q != nil {
+		var err error
+		if records, _, err = q.QueryConversations(ctx, ConversationQuery{Folder: s.Folder(), Source: source}); err != nil {
+			return nil, err
+		}
+		method = StatsMethodIndex
+	} else {
+		files, err := s.ListConversations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range files {
+			if !strings.HasSuffix(filePath, ".md") {
+				continue
+			}
+			content, err := s.Read(ctx, filePath)
+			if err != nil {
+				skipped = append(skipped, SkippedFile{Path: filePath, Error: err.Error()})
+				continue
+			}
+			_, body, _ := frontmatter.Split(content)
+			fm, _, err := frontmatter.Parse(content)
+			if err != nil || fm == nil {
+				fm = &frontmatter.Frontmatter{}
+			}
+			if source != "" && frontmatter.CanonicalSource(fm.Source) != source {
+				continue
+			}
+			records = append(records, ConversationRecord{
+				Path:       filePath,
+				Source:     fm.Source,
+				Date:       fm.Date,
+				Tags:       fm.Tags,
+				Categories: fm.Categories,
+				Model:      fm.Model,
+				Messages:   messageCount(fm, body),
+				Tokens:     fm.Tokens,
+				Cost:       fm.Cost,
+			})
+		}
+	}
+
+	result := aggregateStats(records, opts.Tags)
+	result.Method = method
+	result.Skipped = skipped
+	return result, nil
+}
+
+// messageCount returns the message count of a conversation: its
+// message_count, or if that is missing, the messages counted in its body.
+// The SQLite index stores the same count, so both ways of computing stats
+// agree.
+func messageCount(fm *frontmatter.Frontmatter, body []byte) int {
+	if fm.MessageCount > 0 {
+		return fm.MessageCount
+	}
+	return conversation.Count(conversation.Messages(body))
+}
+
+// aggregateStats computes statistics from conversation records.
+func aggregateStats(records []ConversationRecord, topTags int) *StatsResult {
+	result := &StatsResult{Conversations: len(records)}
+	sources := make(map[string]*StatsCount)
+	models := make(map[string]*StatsCount)
+	tags := make(map[string]*StatsCount)
+	categories := make(map[string]*StatsCount)
+	months := make(map[string]*StatsCount)
+	add := func(groups map[string]*StatsCount, name string, r ConversationRecord) {
+		name = cmp.Or(name, StatsUnknown)
+		g, ok := groups[name]
+		if !ok {
+			g = &StatsCount{Name: name}
+			groups[name] = g
+		}
+		g.Conversations++
+		g.Messages += r.Messages
+		g.Tokens += r.Tokens
+	}
+
+	withMessages, withTokens := 0, 0
+	var first, last time.Time
+	for _, r := range records {
+		result.Messages += r.Messages
+		result.Tokens += r.Tokens
+		result.Cost += r.Cost
+		if r.Messages > 0 {
+			withMessages++
+		}
+		if r.Tokens > 0 {
+			withTokens++
+		}
+
+		add(sources, r.Source, r)
+		add(models, r.Model, r)
+		for _, tag := range slices.Compact(slices.Sorted(slices.Values(r.Tags))) {
+			add(tags, tag, r)
+		}
+		for _, category := range slices.Compact(slices.Sorted(slices.Values(r.Categories))) {
+			add(categories, category, r)
+		}
+		month := ""
+		if !r.Date.IsZero() {
+			month = r.Date.UTC().Format("2006-01")
+			if first.IsZero() || r.Date.Before(first) {
+				first = r.Date
+			}
+			if r.Date.After(last) {
+				last = r.Date
+			}
+		}
+		add(months, month, r)
+	}
+	if withMessages > 0 {
+		result.AverageMessages = roundStat(float64(result.Messages) / float64(withMessages))
+	}
+	if withTokens > 0 {
+		result.AverageTokens = roundStat(float64(result.Tokens) / float64(withTokens))
+	}
+	result.Cost = math.Round(result.Cost*100) / 100
+	if !first.IsZero() {
+		result.First = first.UTC().Format(time.DateOnly)
+		result.Last = last.UTC().Format(time.DateOnly)
+	}
+
+	result.Sources = byCount(sources)
+	result.Models = byCount(models)
+	result.Tags = byCount(tags)
+	result.TagCount = len(result.Tags)
+	if topTags > 0 && len(result.Tags) > topTags {
+		result.Tags = result.Tags[:topTags]
+	}
+	result.Categories = byCount(categories)
+
+	// Months read in date order, with undated conversations last
+	for _, g := range months {
+		result.Months = append(result.Months, *g)
+	}
+	slices.SortFunc(result.Months, func(a, b StatsCount) int {
+		if (a.Name == StatsUnknown) != (b.Name == StatsUnknown) {
+			if a.Name == StatsUnknown {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// byCount returns groups ordered by conversations, most first, then name.
+func byCount(groups map[string]*StatsCount) []StatsCount {
+	counts := make([]StatsCount, 0, len(groups))
+	for _, g := range groups {
+		counts = append(counts, *g)
+	}
+	slices.SortFunc(counts, func(a, b StatsCount) int {
+		if c := cmp.Compare(b.Conversations, a.Conversations); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return counts
+}
+
+// roundStat rounds to one decimal place.
+func roundStat(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func TestStats(t *testing.T) {
+	sqlite, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	stores := map[string]*Storage{
+		StatsMethodScan:  New(memory.New(), "conversations"),
+		StatsMethodIndex: New(sqlite, "conversations"),
+	}
+	for method, store := range stores {
+		t.Run(method, func(t *testing.T) {
+			ctx := context.Background()
+			defer store.Close()
+
+			mustSave(t, store, "claude/keys.md", "---\ntitle: Keys\ndate: 2026-01-10T09:00:00Z\nsource: claude\nmodel: claude-sonnet-4\ntags: [oauth, jwks]\ncategories: [security]\nmessage_count: 4\ntokens: 1200\ncost: 0.02\n---\n\n**User:** Keys?\n")
+			mustSave(t, store, "claude/bread.md", "---\ntitle: Bread\ndate: 2026-02-03T09:00:00Z\nsource: claude\ntags: [baking]\nmessage_count: 2\ntokens: 300\n---\n\n**User:** Bread?\n")
+			mustSave(t, store, "chatgpt/jwt.md", "---\ntitle: JWT\ndate: 2026-01-20T09:00:00Z\nsource: chatgpt\nmodel: gpt-4o\ntags: [oauth]\nmessage_count: 6\n---\n\n**User:** JWT?\n")
+			mustSave(t, store, "chatgpt/undated.md", "---\ntitle: Undated\nsource: chatgpt\nmodel: gpt-4o\nmessage_count: 2\n---\n\n**User:** Hi\n")
+			if _, err := store.Trash(ctx, store.AbsPath("chatgpt/undated.md")); err != nil {
+				t.Fatalf("Trash() error = %v", err)
+			}
+			mustSave(t, store, "gemini/notes.md", "---\ntitle: Notes\nsource: gemini\n---\n\n**User:** One\n\n**Gemini:** Two\n")
+
+			result, err := Stats(ctx, store, StatsOptions{Tags: 1})
+			if err != nil {
+				t.Fatalf("Stats() error = %v", err)
+			}
+			if result.Method != method {
+				t.Errorf("Method = %s, want %s", result.Method, method)
+			}
+			if result.Conversations != 4 || result.Tokens != 1500 || result.Cost != 0.02 {
+				t.Errorf("totals = %d conversations, %d tokens, $%v", result.Conversations, result.Tokens, result.Cost)
+			}
+			// Messages of conversations without a message_count are
+			// counted from the body either way
+			if result.Messages != 14 {
+				t.Errorf("Messages = %d, want 14", result.Messages)
+			}
+			if result.AverageTokens != 750 {
+				t.Errorf("AverageTokens = %v, want 750", result.AverageTokens)
+			}
+			if result.First != "2026-01-10" || result.Last != "2026-02-03" {
+				t.Errorf("First, Last = %s, %s", result.First, result.Last)
+			}
+
+			names := func(counts []StatsCount) []string {
+				var names []string
+				for _, c := range counts {
+					names = append(names, c.Name)
+				}
+				return names
+			}
+			for field, test := range map[string]struct {
+				got  []StatsCount
+				want []string
+			}{
+				"Sources":    {result.Sources, []string{"claude", "chatgpt", "gemini"}},
+				"Models":     {result.Models, []string{StatsUnknown, "claude-sonnet-4", "gpt-4o"}},
+				"Tags":       {result.Tags, []string{"oauth"}},
+				"Categories": {result.Categories, []string{"security"}},
+				"Months":     {result.Months, []string{"2026-01", "2026-02", StatsUnknown}},
+			} {
+				if got := names(test.got); !reflect.DeepEqual(got, test.want) {
+					t.Errorf("%s = %v, want %v", field, got, test.want)
+				}
+			}
+			if result.TagCount != 3 || result.Tags[0].Conversations != 2 {
+				t.Errorf("tags = %+v of %d, want oauth twice of 3", result.Tags, result.TagCount)
+			}
+
+			claude, err := Stats(ctx, store, StatsOptions{Source: "Claude"})
+			if err != nil {
+				t.Fatalf("Stats(claude) error = %v", err)
+			}
+			if claude.Conversations != 2 || claude.Messages != 6 || len(claude.Sources) != 1 {
+				t.Errorf("Stats(claude) = %+v, want 2 conversations with 6 messages", claude)
+			}
+		})
+	}
+}
+
+func TestStatsBrokenFiles(t *testing.T) {
+	ctx := context.Background()
+	sqlite, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	index := New(sqlite, "conversations")
+	defer index.Close()
+	scan := New(&unreadableBackend{Backend: memory.New(), path: "conversations/locked.md"}, "conversations")
+	defer scan.Close()
+
+	for _, store := range []*Storage{index, scan} {
+		mustSave(t, store, "keys.md", "---\ntitle: Keys\nsource: claude\nmessage_count: 4\n---\n\n**User:** Keys?\n")
+		mustSave(t, store, "broken.md", "---\ntitle: [unclosed\n---\n\n**User:** One\n\n**Claude:** Two\n")
+	}
+	mustSave(t, scan, "locked.md", "---\ntitle: Locked\nsource: claude\n---\n\n**User:** Hi\n")
+
+	want, err := Stats(ctx, index, StatsOptions{})
+	if err != nil {
+		t.Fatalf("Stats(index) error = %v", err)
+	}
+	got, err := Stats(ctx, scan, StatsOptions{})
+	if err != nil {
+		t.Fatalf("Stats(scan) error = %v", err)
+	}
+
+	// A file with invalid frontmatter counts as unknown either way
+	if want.Conversations != 2 || want.Messages != 6 {
+		t.Errorf("Stats(index) = %d conversations, %d messages; want 2, 6", want.Conversations, want.Messages)
+	}
+	if got.Conversations != want.Conversations || got.Messages != want.Messages || !reflect.DeepEqual(got.Sources, want.Sources) {
+		t.Errorf("Stats(scan) = %+v, want the same counts as the index %+v", got, want)
+	}
+	if len(got.Skipped) != 1 || got.Skipped[0].Path != scan.AbsPath("locked.md") || got.Skipped[0].Error == "" {
+		t.Errorf("Skipped = %+v, want locked.md with a reason", got.Skipped)
+	}
+}
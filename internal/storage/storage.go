@@ -7,9 +7,13 @@ import (
 	"io"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/grokify/omnistorage"
 
+	"github.com/grokify/chathub/internal/encrypt"
+	"github.com/grokify/chathub/internal/frontmatter"
+
 	// Register backends
 	_ "github.com/grokify/omnistorage-github/backend/github"
 	_ "github.com/grokify/omnistorage/backend/file"
@@ -18,25 +22,150 @@ import (
 
 // Storage wraps an omnistorage.Backend with ChatHub-specific operations.
 type Storage struct {
-	backend omnistorage.Backend
-	folder  string
+	backend        omnistorage.Backend
+	folder         string
+	layout         *frontmatter.Layout
+	format         frontmatter.Format
+	trashRetention time.Duration
+	versions       int
+
+	keyring        *encrypt.Keyring
+	encryptionMode encrypt.Mode
+	replication    *ReplicationConfig
+	replicated     *ReplicatedBackend
+	outboxConfig   *OutboxConfig
+	outbox         *Outbox
 }
 
-// New creates a new Storage instance.
-func New(backend omnistorage.Backend, folder string) *Storage {
-	return &Storage{
-		backend: backend,
-		folder:  folder,
+// Option configures a Storage.
+type Option func(*Storage)
+
+// WithTrashRetention sets how long trashed conversations are kept before
+// they are purged. A zero or negative duration disables automatic purging.
+func WithTrashRetention(d time.Duration) Option {
+	return func(s *Storage) {
+		s.trashRetention = d
+	}
+}
+
+// WithLayout sets the path layout for new conversations. The default is
+// frontmatter.DefaultLayout.
+func WithLayout(layout *frontmatter.Layout) Option {
+	return func(s *Storage) {
+		s.layout = layout
+	}
+}
+
+// WithFrontmatterFormat sets the frontmatter format for new conversations.
+// The default is YAML.
+func WithFrontmatterFormat(format frontmatter.Format) Option {
+	return func(s *Storage) {
+		s.format = format
+	}
+}
+
+// WithEncryption encrypts content at rest with keyring. Reads transparently
+// decrypt; plaintext files are still readable.
+func WithEncryption(keyring *encrypt.Keyring, mode encrypt.Mode) Option {
+	return func(s *Storage) {
+		s.keyring = keyring
+		s.encryptionMode = mode
+	}
+}
+
+// WithReplication mirrors writes to replica backends. It only takes effect
+// with NewFromConfig, which opens the replicas; to replicate pre-opened
+// backends, pass a ReplicatedBackend to New instead.
+func WithReplication(cfg ReplicationConfig) Option {
+	return func(s *Storage) {
+		s.replication = &cfg
+	}
+}
+
+// WithOutbox journals writes that fail transiently to a local outbox and
+// replays them when the backend recovers. Like WithReplication, it only
+// takes effect with NewFromConfig; otherwise pass an Outbox to New.
+func WithOutbox(cfg OutboxConfig) Option {
+	return func(s *Storage) {
+		s.outboxConfig = &cfg
 	}
 }
 
+// New creates a new Storage instance.
+func New(backend omnistorage.Backend, folder string, opts ...Option) *Storage {
+	s := newStorage(folder, opts)
+	s.init(backend)
+	return s
+}
+
 // NewFromConfig creates a Storage from backend name and config.
-func NewFromConfig(backendName string, config map[string]string, folder string) (*Storage, error) {
+func NewFromConfig(backendName string, config map[string]string, folder string, opts ...Option) (*Storage, error) {
 	backend, err := omnistorage.Open(backendName, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open backend %s: %w", backendName, err)
 	}
-	return New(backend, folder), nil
+
+	s := newStorage(folder, opts)
+	if s.replication != nil && len(s.replication.Replicas) > 0 {
+		replicated, err := OpenReplicated(BackendSpec{Name: backendName, Config: config}, backend, *s.replication)
+		if err != nil {
+			backend.Close()
+			return nil, err
+		}
+		backend = replicated
+	}
+	if s.outboxConfig != nil {
+		outbox, err := NewOutbox(backend, *s.outboxConfig)
+		if err != nil {
+			backend.Close()
+			return nil, err
+		}
+		backend = outbox
+	}
+	s.init(backend)
+	return s, nil
+}
+
+func newStorage(folder string, opts []Option) *Storage {
+	s := &Storage{
+		folder:         folder,
+		layout:         frontmatter.MustParseLayout(frontmatter.DefaultLayout),
+		format:         frontmatter.FormatYAML,
+		trashRetention: DefaultTrashRetention,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// init sets the backend, layering encryption over the outbox and
+// replication so that every replica, and the local journal, receives
+// ciphertext.
+func (s *Storage) init(backend omnistorage.Backend) {
+	inner := backend
+	if outbox, ok := inner.(*Outbox); ok {
+		s.outbox = outbox
+		inner = outbox.Inner()
+	}
+	if replicated, ok := inner.(*ReplicatedBackend); ok {
+		s.replicated = replicated
+	}
+	if s.keyring != nil {
+		backend = encrypt.NewBackend(backend, s.keyring, s.encryptionMode)
+	}
+	s.backend = backend
+}
+
+// Replication returns the replicated backend, or nil if replication is not
+// configured.
+func (s *Storage) Replication() *ReplicatedBackend {
+	return s.replicated
+}
+
+// Outbox returns the write outbox, or nil if it is not configured.
+func (s *Storage) Outbox() *Outbox {
+	return s.outbox
 }
 
 // Close closes the underlying backend.
@@ -89,27 +218,103 @@ func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
 	return files, nil
 }
 
-// ListConversations lists all conversation files in the storage folder.
+// ListConversations lists all conversation files in the storage folder,
+// excluding internal areas such as the trash.
 func (s *Storage) ListConversations(ctx context.Context) ([]string, error) {
-	return s.List(ctx, s.folder)
+	files, err := s.List(ctx, s.folder)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]string, 0, len(files))
+	for _, f := range files {
+		if s.IsInternalPath(f) {
+			continue
+		}
+		conversations = append(conversations, f)
+	}
+	return conversations, nil
 }
 
-// ListBySource lists conversations from a specific source.
-func (s *Storage) ListBySource(ctx context.Context, source string) ([]string, error) {
-	prefix := path.Join(s.folder, source)
-	files, err := s.List(ctx, prefix)
+// SkippedFile is a file left out of a result, and why.
+type SkippedFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ListBySource lists conversations from a specific source, given by ID or
+// by a name the source registry resolves. The source is read from each
+// file's frontmatter, so it works with any path layout. Files that cannot
+// be read or parsed are skipped and returned with the reason.
+func (s *Storage) ListBySource(ctx context.Context, source string) ([]string, []SkippedFile, error) {
+	source = frontmatter.CanonicalSource(source)
+	files, err := s.ListConversations(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Filter to only .md files
 	var mdFiles []string
+	var skipped []SkippedFile
 	for _, f := range files {
-		if strings.HasSuffix(f, ".md") {
+		if !strings.HasSuffix(f, ".md") {
+			continue
+		}
+		content, err := s.Read(ctx, f)
+		if err != nil {
+			skipped = append(skipped, SkippedFile{Path: f, Error: err.Error()})
+			continue
+		}
+		fm, _, err := frontmatter.Parse(content)
+		if err != nil {
+			skipped = append(skipped, SkippedFile{Path: f, Error: err.Error()})
+			continue
+		}
+		if fm != nil && fm.Source == source {
 			mdFiles = append(mdFiles, f)
 		}
 	}
-	return mdFiles, nil
+	return mdFiles, skipped, nil
+}
+
+// Layout returns the path layout for new conversations.
+func (s *Storage) Layout() *frontmatter.Layout {
+	return s.layout
+}
+
+// FrontmatterFormat returns the frontmatter format for new conversations.
+func (s *Storage) FrontmatterFormat() frontmatter.Format {
+	return s.format
+}
+
+// PathFor returns the path of a new conversation under the storage folder.
+func (s *Storage) PathFor(fm *frontmatter.Frontmatter) string {
+	return s.layout.Path(s.folder, fm)
+}
+
+// IsInternalPath reports whether a path is inside an internal area of the
+// storage folder, i.e. a directory whose name starts with "." such as the
+// trash. Internal files are never listed as conversations.
+func (s *Storage) IsInternalPath(filePath string) bool {
+	rel := strings.TrimPrefix(filePath, s.folder+"/")
+	if rel == filePath && s.folder != "" {
+		return false
+	}
+	for _, part := range strings.Split(path.Dir(rel), "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// RelPath returns a path relative to the storage folder.
+func (s *Storage) RelPath(filePath string) string {
+	return strings.TrimPrefix(filePath, s.folder+"/")
+}
+
+// AbsPath returns the full path of a path relative to the storage folder.
+func (s *Storage) AbsPath(relPath string) string {
+	return path.Join(s.folder, relPath)
 }
 
 // Delete removes a file.
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/grokify/omnistorage"
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// unreadableBackend fails reads of one path.
+type unreadableBackend struct {
+	omnistorage.Backend
+	path string
+}
+
+func (u *unreadableBackend) NewReader(ctx context.Context, path string, opts ...omnistorage.ReaderOption) (io.ReadCloser, error) {
+	if path == u.path {
+		return nil, errors.New("permission denied")
+	}
+	return u.Backend.NewReader(ctx, path, opts...)
+}
+
+func TestListBySource(t *testing.T) {
+	ctx := context.Background()
+	backend := &unreadableBackend{Backend: memory.New(), path: "conversations/2026/locked.md"}
+	// The path says nothing about the source, so it comes from frontmatter
+	store := New(backend, "conversations", WithLayout(frontmatter.MustParseLayout("{yyyy}/{slug}.md")))
+
+	doc := "---\ntitle: %s\ndate: 2026-01-10T09:00:00Z\nsource: %s\n---\n**User:** hi\n"
+	mustSave(t, store, "2026/keys.md", fmt.Sprintf(doc, "Keys", "claude-code"))
+	mustSave(t, store, "2026/bread.md", fmt.Sprintf(doc, "Bread", "chatgpt"))
+	mustSave(t, store, "2026/broken.md", "---\ntitle: [unclosed\n---\nbody\n")
+	mustSave(t, store, "2026/locked.md", fmt.Sprintf(doc, "Locked", "claude-code"))
+
+	files, skipped, err := store.ListBySource(ctx, "Claude Code")
+	if err != nil {
+		t.Fatalf("ListBySource() error = %v", err)
+	}
+	if want := []string{store.AbsPath("2026/keys.md")}; !slices.Equal(files, want) {
+		t.Errorf("ListBySource() = %v, want %v", files, want)
+	}
+	var paths []string
+	for _, f := range skipped {
+		paths = append(paths, f.Path)
+		if f.Error == "" {
+			t.Errorf("skipped %s without a reason", f.Path)
+		}
+	}
+	slices.Sort(paths)
+	if want := []string{store.AbsPath("2026/broken.md"), store.AbsPath("2026/locked.md")}; !slices.Equal(paths, want) {
+		t.Errorf("skipped = %v, want %v", paths, want)
+	}
+}
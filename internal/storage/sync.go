@@ -0,0 +1,359 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+// SyncDirection selects one-way or bidirectional sync.
+type SyncDirection string
+
+// Sync directions
+const (
+	// SyncOneWay makes the destination match the source.
+	SyncOneWay SyncDirection = "one-way"
+	// SyncBidirectional propagates changes made on either side.
+	SyncBidirectional SyncDirection = "bidirectional"
+)
+
+// ConflictPolicy decides how a file changed on both sides is resolved.
+type ConflictPolicy string
+
+// Conflict policies
+const (
+	// ConflictSkip leaves both copies untouched and reports the conflict.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictNewer keeps the copy with the later frontmatter lastmod.
+	ConflictNewer ConflictPolicy = "newer"
+	// ConflictSource keeps the source copy.
+	ConflictSource ConflictPolicy = "source"
+	// ConflictDest keeps the destination copy.
+	ConflictDest ConflictPolicy = "dest"
+)
+
+// SyncFolder is the internal area holding sync state.
+const SyncFolder = ".sync"
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	Direction SyncDirection
+	Conflict  ConflictPolicy
+
+	// Delete propagates deletions. One-way, files missing from the source
+	// are deleted from the destination. Bidirectionally, files deleted on
+	// one side since the last sync are deleted on the other, unless they
+	// were changed there too, which is reported as a conflict.
+	Delete bool
+
+	// DryRun reports the planned actions without applying them.
+	DryRun bool
+
+	// StateName names the sync state kept in the source's SyncFolder. It
+	// records content hashes from the last sync so that changes and
+	// deletions can be attributed to one side. Required for
+	// bidirectional sync; optional one-way.
+	StateName string
+}
+
+// SyncAction is the kind of change made to a file.
+type SyncAction string
+
+// Sync actions
+const (
+	SyncCreate   SyncAction = "create"
+	SyncUpdate   SyncAction = "update"
+	SyncDelete   SyncAction = "delete"
+	SyncConflict SyncAction = "conflict"
+)
+
+// SyncChange describes one planned or applied change. Path is relative to
+// the storage folder; To is "source" or "dest".
+type SyncChange struct {
+	Path   string     `json:"path"`
+	Action SyncAction `json:"action"`
+	To     string     `json:"to,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// SyncResult summarizes a sync.
+type SyncResult struct {
+	Changes   []SyncChange `json:"changes"`
+	Unchanged int          `json:"unchanged"`
+	DryRun    bool         `json:"dry_run"`
+}
+
+// Count returns the number of changes with the given action.
+func (r *SyncResult) Count(action SyncAction) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// syncState records the content hash of each file as of the last sync.
+type syncState struct {
+	SyncedAt time.Time         `json:"synced_at"`
+	Hashes   map[string]string `json:"hashes"`
+}
+
+// syncFile is one side's copy of a file.
+type syncFile struct {
+	content []byte
+	hash    string
+	lastMod time.Time
+}
+
+// Sync synchronizes conversations between two stores, comparing content
+// hashes and using frontmatter lastmod to resolve conflicts.
+func Sync(ctx context.Context, src, dst *Storage, opts SyncOptions) (*SyncResult, error) {
+	if opts.Direction == "" {
+		opts.Direction = SyncOneWay
+	}
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictSkip
+	}
+	if opts.Direction == SyncBidirectional && opts.StateName == "" {
+		return nil, fmt.Errorf("bidirectional sync requires a state name")
+	}
+
+	srcFiles, err := loadSyncFiles(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+	dstFiles, err := loadSyncFiles(ctx, dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination: %w", err)
+	}
+
+	state, err := loadSyncState(ctx, src, opts.StateName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{DryRun: opts.DryRun}
+	newState := syncState{Hashes: make(map[string]string)}
+
+	// Changes to each side are committed together
+	srcBatch := src.Begin("Sync from " + dst.folder)
+	dstBatch := dst.Begin("Sync from " + src.folder)
+
+	for _, rel := range unionKeys(srcFiles, dstFiles) {
+		s, inSrc := srcFiles[rel]
+		d, inDst := dstFiles[rel]
+		base, synced := state.Hashes[rel]
+
+		change, keep := planSync(rel, s, d, inSrc, inDst, base, synced, opts)
+		if change == nil {
+			result.Unchanged++
+			if keep != "" {
+				newState.Hashes[rel] = keep
+			}
+			continue
+		}
+
+		if !opts.DryRun && change.Action != SyncConflict {
+			planApply(srcBatch, dstBatch, src, dst, *change, s, d)
+		}
+		result.Changes = append(result.Changes, *change)
+
+		if keep != "" {
+			newState.Hashes[rel] = keep
+		} else if change.Action == SyncConflict && synced {
+			newState.Hashes[rel] = base
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := dstBatch.Commit(ctx); err != nil {
+		return result, err
+	}
+	if opts.StateName != "" {
+		newState.SyncedAt = time.Now().UTC()
+		data, err := json.MarshalIndent(newState, "", "  ")
+		if err != nil {
+			return result, fmt.Errorf("failed to encode sync state: %w", err)
+		}
+		srcBatch.Put(syncStatePath(src, opts.StateName), data)
+	}
+	if err := srcBatch.Commit(ctx); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// planSync decides what to do with one path. It returns nil if nothing
+// needs to change, and the hash both sides will share afterwards ("" if
+// the file will not exist on both sides or is in conflict).
+func planSync(rel string, s, d *syncFile, inSrc, inDst bool, base string, synced bool, opts SyncOptions) (*SyncChange, string) {
+	bidi := opts.Direction == SyncBidirectional
+
+	switch {
+	case inSrc && inDst && s.hash == d.hash:
+		return nil, s.hash
+
+	case inSrc && !inDst:
+		if bidi && synced && opts.Delete {
+			// Edits made since the last sync would be lost with the file
+			if s.hash != base {
+				return &SyncChange{Path: rel, Action: SyncConflict, Reason: "deleted from destination but changed in source"}, ""
+			}
+			return &SyncChange{Path: rel, Action: SyncDelete, To: "source", Reason: "deleted from destination"}, ""
+		}
+		return &SyncChange{Path: rel, Action: SyncCreate, To: "dest"}, s.hash
+
+	case !inSrc && inDst:
+		if !bidi {
+			if opts.Delete {
+				return &SyncChange{Path: rel, Action: SyncDelete, To: "dest", Reason: "not in source"}, ""
+			}
+			return nil, ""
+		}
+		if synced && opts.Delete {
+			if d.hash != base {
+				return &SyncChange{Path: rel, Action: SyncConflict, Reason: "deleted from source but changed in destination"}, ""
+			}
+			return &SyncChange{Path: rel, Action: SyncDelete, To: "dest", Reason: "deleted from source"}, ""
+		}
+		return &SyncChange{Path: rel, Action: SyncCreate, To: "source"}, d.hash
+	}
+
+	// Both sides exist with different content
+	srcChanged := !synced || s.hash != base
+	dstChanged := !synced || d.hash != base
+
+	switch {
+	case srcChanged && !dstChanged:
+		return &SyncChange{Path: rel, Action: SyncUpdate, To: "dest"}, s.hash
+	case dstChanged && !srcChanged:
+		if bidi {
+			return &SyncChange{Path: rel, Action: SyncUpdate, To: "source"}, d.hash
+		}
+		return &SyncChange{Path: rel, Action: SyncUpdate, To: "dest", Reason: "destination changed; restoring source"}, s.hash
+	case !synced && !bidi && !d.lastMod.After(s.lastMod):
+		// No history: one-way sync overwrites unless the destination is newer
+		return &SyncChange{Path: rel, Action: SyncUpdate, To: "dest"}, s.hash
+	}
+
+	return resolveConflict(rel, s, d, opts)
+}
+
+func resolveConflict(rel string, s, d *syncFile, opts SyncOptions) (*SyncChange, string) {
+	reason := "changed on both sides"
+	switch opts.Conflict {
+	case ConflictSource:
+		return &SyncChange{Path: rel, Action: SyncUpdate, To: "dest", Reason: reason + "; kept source"}, s.hash
+	case ConflictDest:
+		if opts.Direction == SyncBidirectional {
+			return &SyncChange{Path: rel, Action: SyncUpdate, To: "source", Reason: reason + "; kept destination"}, d.hash
+		}
+		// One-way sync never writes to the source, so both copies stay
+		return &SyncChange{Path: rel, Action: SyncConflict, Reason: reason + "; skipped, destination kept"}, ""
+	case ConflictNewer:
+		if d.lastMod.After(s.lastMod) {
+			if opts.Direction == SyncBidirectional {
+				return &SyncChange{Path: rel, Action: SyncUpdate, To: "source", Reason: reason + "; destination newer"}, d.hash
+			}
+			return &SyncChange{Path: rel, Action: SyncConflict, Reason: reason + "; skipped, destination newer"}, ""
+		}
+		return &SyncChange{Path: rel, Action: SyncUpdate, To: "dest", Reason: reason + "; source newer"}, s.hash
+	default:
+		return &SyncChange{Path: rel, Action: SyncConflict, Reason: reason}, ""
+	}
+}
+
+func planApply(srcBatch, dstBatch *Batch, src, dst *Storage, change SyncChange, s, d *syncFile) {
+	switch {
+	case change.Action == SyncDelete && change.To == "dest":
+		dstBatch.Delete(dst.AbsPath(change.Path))
+	case change.Action == SyncDelete:
+		srcBatch.Delete(src.AbsPath(change.Path))
+	case change.To == "dest":
+		dstBatch.Put(dst.AbsPath(change.Path), s.content)
+	default:
+		srcBatch.Put(src.AbsPath(change.Path), d.content)
+	}
+}
+
+func loadSyncFiles(ctx context.Context, s *Storage) (map[string]*syncFile, error) {
+	paths, err := s.ListConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*syncFile, len(paths))
+	for _, p := range paths {
+		content, err := s.Read(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		f := &syncFile{content: content, hash: hex.EncodeToString(sum[:])}
+		if fm, _, err := frontmatter.Parse(content); err == nil && fm != nil {
+			f.lastMod = fm.LastMod
+			if f.lastMod.IsZero() {
+				f.lastMod = fm.Date
+			}
+		}
+		files[s.RelPath(p)] = f
+	}
+	return files, nil
+}
+
+func unionKeys(a, b map[string]*syncFile) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func syncStatePath(s *Storage, name string) string {
+	return path.Join(s.folder, SyncFolder, strings.ReplaceAll(name, "/", "_")+".json")
+}
+
+func loadSyncState(ctx context.Context, s *Storage, name string) (syncState, error) {
+	state := syncState{Hashes: make(map[string]string)}
+	if name == "" {
+		return state, nil
+	}
+
+	statePath := syncStatePath(s, name)
+	exists, err := s.Exists(ctx, statePath)
+	if err != nil || !exists {
+		return state, err
+	}
+
+	data, err := s.Read(ctx, statePath)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode sync state %s: %w", statePath, err)
+	}
+	if state.Hashes == nil {
+		state.Hashes = make(map[string]string)
+	}
+	return state, nil
+}
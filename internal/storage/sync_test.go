@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func mustSave(t *testing.T, s *Storage, rel, content string) {
+	t.Helper()
+	if err := s.Save(context.Background(), s.AbsPath(rel), []byte(content)); err != nil {
+		t.Fatalf("Save(%s) error = %v", rel, err)
+	}
+}
+
+func mustRead(t *testing.T, s *Storage, rel string) string {
+	t.Helper()
+	content, err := s.Read(context.Background(), s.AbsPath(rel))
+	if err != nil {
+		t.Fatalf("Read(%s) error = %v", rel, err)
+	}
+	return string(content)
+}
+
+func TestSyncOneWay(t *testing.T) {
+	ctx := context.Background()
+	src := New(memory.New(), "conversations")
+	dst := New(memory.New(), "mirror")
+
+	mustSave(t, src, "chatgpt/a.md", "a")
+	mustSave(t, src, "claude/b.md", "b")
+	mustSave(t, dst, "claude/b.md", "old b")
+	mustSave(t, dst, "gemini/extra.md", "extra")
+
+	dry, err := Sync(ctx, src, dst, SyncOptions{DryRun: true, Delete: true})
+	if err != nil {
+		t.Fatalf("Sync(dry run) error = %v", err)
+	}
+	if len(dry.Changes) != 3 {
+		t.Errorf("dry run changes = %+v, want 3", dry.Changes)
+	}
+	if exists, _ := dst.Exists(ctx, dst.AbsPath("chatgpt/a.md")); exists {
+		t.Error("dry run wrote to destination")
+	}
+
+	result, err := Sync(ctx, src, dst, SyncOptions{Delete: true})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Count(SyncCreate) != 1 || result.Count(SyncUpdate) != 1 || result.Count(SyncDelete) != 1 {
+		t.Errorf("Sync() changes = %+v", result.Changes)
+	}
+	if got := mustRead(t, dst, "claude/b.md"); got != "b" {
+		t.Errorf("dst b.md = %q, want %q", got, "b")
+	}
+	if exists, _ := dst.Exists(ctx, dst.AbsPath("gemini/extra.md")); exists {
+		t.Error("extraneous destination file was not deleted")
+	}
+}
+
+func TestSyncBidirectional(t *testing.T) {
+	ctx := context.Background()
+	a := New(memory.New(), "conversations")
+	b := New(memory.New(), "conversations")
+	opts := SyncOptions{Direction: SyncBidirectional, Delete: true, StateName: "b"}
+
+	mustSave(t, a, "x.md", "x1")
+	mustSave(t, a, "y.md", "y1")
+	mustSave(t, a, "z.md", "z1")
+	if _, err := Sync(ctx, a, b, opts); err != nil {
+		t.Fatalf("initial Sync() error = %v", err)
+	}
+
+	// Change x on b, delete y on a, change z on both
+	mustSave(t, b, "x.md", "x2")
+	if err := a.Delete(ctx, a.AbsPath("y.md")); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	mustSave(t, a, "z.md", "z-a")
+	mustSave(t, b, "z.md", "z-b")
+
+	result, err := Sync(ctx, a, b, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if got := mustRead(t, a, "x.md"); got != "x2" {
+		t.Errorf("a x.md = %q, want x2", got)
+	}
+	if exists, _ := b.Exists(ctx, b.AbsPath("y.md")); exists {
+		t.Error("deletion of y.md was not propagated")
+	}
+	if result.Count(SyncConflict) != 1 {
+		t.Errorf("conflicts = %d, want 1 (changes %+v)", result.Count(SyncConflict), result.Changes)
+	}
+	if got := mustRead(t, b, "z.md"); got != "z-b" {
+		t.Errorf("conflicting z.md was overwritten: %q", got)
+	}
+
+	// The sync state itself must not be listed or synced
+	files, err := a.ListConversations(ctx)
+	if err != nil {
+		t.Fatalf("ListConversations() error = %v", err)
+	}
+	for _, f := range files {
+		if a.IsInternalPath(f) {
+			t.Errorf("ListConversations() includes internal path %s", f)
+		}
+	}
+}
+
+func TestSyncDeleteChangedFile(t *testing.T) {
+	ctx := context.Background()
+	a := New(memory.New(), "conversations")
+	b := New(memory.New(), "conversations")
+	opts := SyncOptions{Direction: SyncBidirectional, Delete: true, StateName: "b"}
+
+	mustSave(t, a, "x.md", "x1")
+	mustSave(t, a, "y.md", "y1")
+	if _, err := Sync(ctx, a, b, opts); err != nil {
+		t.Fatalf("initial Sync() error = %v", err)
+	}
+
+	// x is deleted on a but edited on b; y is deleted on b but edited on a
+	if err := a.Delete(ctx, a.AbsPath("x.md")); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	mustSave(t, b, "x.md", "x2")
+	if err := b.Delete(ctx, b.AbsPath("y.md")); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	mustSave(t, a, "y.md", "y2")
+
+	result, err := Sync(ctx, a, b, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Count(SyncConflict) != 2 || result.Count(SyncDelete) != 0 {
+		t.Errorf("Sync() changes = %+v, want 2 conflicts and no deletes", result.Changes)
+	}
+	if got := mustRead(t, b, "x.md"); got != "x2" {
+		t.Errorf("b x.md = %q, want the edit kept", got)
+	}
+	if got := mustRead(t, a, "y.md"); got != "y2" {
+		t.Errorf("a y.md = %q, want the edit kept", got)
+	}
+}
+
+func TestSyncOneWayKeepsDestinationAsConflict(t *testing.T) {
+	ctx := context.Background()
+	for _, policy := range []ConflictPolicy{ConflictDest, ConflictNewer} {
+		t.Run(string(policy), func(t *testing.T) {
+			src := New(memory.New(), "conversations")
+			dst := New(memory.New(), "mirror")
+			mustSave(t, src, "a.md", "---\ntitle: A\nlastmod: 2026-01-01T00:00:00Z\n---\nsource\n")
+			mustSave(t, dst, "a.md", "---\ntitle: A\nlastmod: 2026-02-01T00:00:00Z\n---\ndest\n")
+
+			result, err := Sync(ctx, src, dst, SyncOptions{Conflict: policy})
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if result.Count(SyncConflict) != 1 || result.Unchanged != 0 {
+				t.Errorf("Sync() = %+v, want a skipped conflict", result)
+			}
+			if got := mustRead(t, dst, "a.md"); got != "---\ntitle: A\nlastmod: 2026-02-01T00:00:00Z\n---\ndest\n" {
+				t.Errorf("dst a.md = %q, want it kept", got)
+			}
+		})
+	}
+}
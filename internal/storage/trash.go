@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// TrashFolder is the name of the trash area within the storage folder.
+	TrashFolder = ".trash"
+
+	// DefaultTrashRetention is how long trashed conversations are kept
+	// before they are purged.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	trashMetaSuffix = ".meta.json"
+)
+
+var (
+	// ErrTrashItemNotFound indicates that no trash item exists for an ID.
+	ErrTrashItemNotFound = errors.New("trash item not found")
+
+	// ErrRestoreConflict indicates that the restore destination already exists.
+	ErrRestoreConflict = errors.New("restore destination already exists")
+)
+
+// TrashItem records a conversation that has been moved to the trash.
+type TrashItem struct {
+	ID           string    `json:"id"`
+	OriginalPath string    `json:"original_path"`
+	TrashPath    string    `json:"trash_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+// ExpiresAt returns when the item becomes eligible for purging under the
+// given retention. It returns the zero time if retention is disabled.
+func (t TrashItem) ExpiresAt(retention time.Duration) time.Time {
+	if retention <= 0 {
+		return time.Time{}
+	}
+	return t.DeletedAt.Add(retention)
+}
+
+// TrashPrefix returns the path prefix of the trash area.
+func (s *Storage) TrashPrefix() string {
+	return path.Join(s.folder, TrashFolder)
+}
+
+// IsTrashPath reports whether a path is inside the trash area.
+func (s *Storage) IsTrashPath(filePath string) bool {
+	return strings.HasPrefix(filePath, s.TrashPrefix()+"/")
+}
+
+// TrashRetention returns the configured trash retention period.
+func (s *Storage) TrashRetention() time.Duration {
+	return s.trashRetention
+}
+
+// Trash moves a file into the trash area and records deletion metadata.
+func (s *Storage) Trash(ctx context.Context, filePath string) (TrashItem, error) {
+	content, err := s.Read(ctx, filePath)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	batch := s.Begin("Move " + s.RelPath(filePath) + " to trash")
+	item, err := batch.Trash(ctx, filePath, content)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return TrashItem{}, err
+	}
+
+	return item, nil
+}
+
+// Trash queues moving a file, whose current content is given, into the
+// trash area, so it can be committed together with other changes. Its
+// versions are deleted, so they are not mistaken for the history of a
+// later conversation saved at the same path.
+func (b *Batch) Trash(ctx context.Context, filePath string, content []byte) (TrashItem, error) {
+	s := b.store
+	if s.IsTrashPath(filePath) {
+		return TrashItem{}, fmt.Errorf("%s is already in the trash", filePath)
+	}
+
+	now := time.Now().UTC()
+	id := fmt.Sprintf("trash_%d", nextTrashNanos(now))
+	item := TrashItem{
+		ID:           id,
+		OriginalPath: filePath,
+		TrashPath:    path.Join(s.TrashPrefix(), id+path.Ext(filePath)),
+		DeletedAt:    now,
+	}
+
+	meta, err := encodeTrashMeta(item)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	if err := b.deleteVersions(ctx, filePath); err != nil {
+		return TrashItem{}, err
+	}
+	b.Put(item.TrashPath, content)
+	b.Put(s.trashMetaPath(item.ID), meta)
+	b.Delete(filePath)
+	return item, nil
+}
+
+// lastTrashNanos is the timestamp of the last trash ID.
+var lastTrashNanos atomic.Int64
+
+// nextTrashNanos returns the timestamp of a new trash ID: that of now, or
+// later if needed to keep IDs unique when several are made at once.
+func nextTrashNanos(now time.Time) int64 {
+	for {
+		last := lastTrashNanos.Load()
+		next := max(now.UnixNano(), last+1)
+		if lastTrashNanos.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// ListTrash returns all trash items, most recently deleted first.
+func (s *Storage) ListTrash(ctx context.Context) ([]TrashItem, error) {
+	files, err := s.List(ctx, s.TrashPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TrashItem
+	for _, f := range files {
+		if !strings.HasSuffix(f, trashMetaSuffix) {
+			continue
+		}
+		item, err := s.readTrashMeta(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+
+	return items, nil
+}
+
+// GetTrashItem returns the trash item with the given ID.
+func (s *Storage) GetTrashItem(ctx context.Context, id string) (TrashItem, error) {
+	if id == "" || strings.ContainsAny(id, "/\\") {
+		return TrashItem{}, fmt.Errorf("%w: %q", ErrTrashItemNotFound, id)
+	}
+
+	metaPath := s.trashMetaPath(id)
+	exists, err := s.Exists(ctx, metaPath)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	if !exists {
+		return TrashItem{}, fmt.Errorf("%w: %s", ErrTrashItemNotFound, id)
+	}
+
+	return s.readTrashMeta(ctx, metaPath)
+}
+
+// Restore moves a trash item back to dstPath, or to its original path if
+// dstPath is empty. It refuses to overwrite an existing file.
+func (s *Storage) Restore(ctx context.Context, id, dstPath string) (string, error) {
+	item, err := s.GetTrashItem(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if dstPath == "" {
+		dstPath = item.OriginalPath
+	}
+
+	exists, err := s.Exists(ctx, dstPath)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("%w: %s", ErrRestoreConflict, dstPath)
+	}
+
+	content, err := s.Read(ctx, item.TrashPath)
+	if err != nil {
+		return "", err
+	}
+	batch := s.Begin("Restore " + s.RelPath(dstPath) + " from trash")
+	batch.Put(dstPath, content)
+	s.removeTrashItem(batch, item)
+	if err := batch.Commit(ctx); err != nil {
+		return "", err
+	}
+
+	return dstPath, nil
+}
+
+// EmptyTrash permanently deletes trash items deleted more than olderThan
+// ago. A zero olderThan deletes every item. It returns the purged items.
+func (s *Storage) EmptyTrash(ctx context.Context, olderThan time.Duration) ([]TrashItem, error) {
+	items, err := s.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().UTC().Add(-olderThan)
+	var purged []TrashItem
+	batch := s.Begin("Empty trash")
+	for _, item := range items {
+		if olderThan > 0 && item.DeletedAt.After(cutoff) {
+			continue
+		}
+		s.removeTrashItem(batch, item)
+		purged = append(purged, item)
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// PurgeExpiredTrash permanently deletes trash items older than the
+// configured retention. It does nothing if retention is disabled.
+func (s *Storage) PurgeExpiredTrash(ctx context.Context) ([]TrashItem, error) {
+	if s.trashRetention <= 0 {
+		return nil, nil
+	}
+	return s.EmptyTrash(ctx, s.trashRetention)
+}
+
+// DeleteTrashItem permanently deletes a single trash item.
+func (s *Storage) DeleteTrashItem(ctx context.Context, id string) (TrashItem, error) {
+	item, err := s.GetTrashItem(ctx, id)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	batch := s.Begin("Delete " + item.ID + " from trash")
+	s.removeTrashItem(batch, item)
+	return item, batch.Commit(ctx)
+}
+
+func (s *Storage) trashMetaPath(id string) string {
+	return path.Join(s.TrashPrefix(), id+trashMetaSuffix)
+}
+
+func encodeTrashMeta(item TrashItem) ([]byte, error) {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trash metadata: %w", err)
+	}
+	return data, nil
+}
+
+func (s *Storage) readTrashMeta(ctx context.Context, metaPath string) (TrashItem, error) {
+	data, err := s.Read(ctx, metaPath)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	var item TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return TrashItem{}, fmt.Errorf("failed to decode trash metadata %s: %w", metaPath, err)
+	}
+	return item, nil
+}
+
+func (s *Storage) removeTrashItem(batch *Batch, item TrashItem) {
+	batch.Delete(item.TrashPath)
+	batch.Delete(s.trashMetaPath(item.ID))
+}
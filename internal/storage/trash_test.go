@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+)
+
+func TestTrashRestore(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+
+	filePath := "conversations/chatgpt/2026-01-10_hello.md"
+	if err := store.Save(ctx, filePath, []byte("hello")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	item, err := store.Trash(ctx, filePath)
+	if err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+
+	if exists, _ := store.Exists(ctx, filePath); exists {
+		t.Error("original file still exists after Trash()")
+	}
+
+	files, err := store.ListConversations(ctx)
+	if err != nil {
+		t.Fatalf("ListConversations() error = %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("ListConversations() = %v, want trash excluded", files)
+	}
+
+	items, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != filePath {
+		t.Fatalf("ListTrash() = %+v, want one item for %s", items, filePath)
+	}
+
+	restored, err := store.Restore(ctx, item.ID, "")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored != filePath {
+		t.Errorf("Restore() path = %q, want %q", restored, filePath)
+	}
+
+	content, err := store.Read(ctx, filePath)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if string(content) != "hello" {
+		t.Errorf("restored content = %q, want %q", content, "hello")
+	}
+
+	if _, err := store.GetTrashItem(ctx, item.ID); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Errorf("GetTrashItem() after restore error = %v, want ErrTrashItemNotFound", err)
+	}
+}
+
+func TestRestoreConflict(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations")
+
+	filePath := "conversations/claude/2026-01-10_hello.md"
+	if err := store.Save(ctx, filePath, []byte("old")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	item, err := store.Trash(ctx, filePath)
+	if err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	if err := store.Save(ctx, filePath, []byte("new")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if _, err := store.Restore(ctx, item.ID, ""); !errors.Is(err, ErrRestoreConflict) {
+		t.Errorf("Restore() error = %v, want ErrRestoreConflict", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations", WithTrashRetention(0))
+
+	for _, p := range []string{"conversations/a.md", "conversations/b.md"} {
+		if err := store.Save(ctx, p, []byte(p)); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if _, err := store.Trash(ctx, p); err != nil {
+			t.Fatalf("Trash() error = %v", err)
+		}
+	}
+
+	purged, err := store.PurgeExpiredTrash(ctx)
+	if err != nil {
+		t.Fatalf("PurgeExpiredTrash() error = %v", err)
+	}
+	if len(purged) != 0 {
+		t.Errorf("PurgeExpiredTrash() with retention disabled purged %d items", len(purged))
+	}
+
+	purged, err = store.EmptyTrash(ctx, 0)
+	if err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+	if len(purged) != 2 {
+		t.Errorf("EmptyTrash() purged %d items, want 2", len(purged))
+	}
+
+	files, err := store.List(ctx, store.TrashPrefix())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("trash still contains %v", files)
+	}
+}
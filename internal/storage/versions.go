@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// VersionsFolder is the internal area holding previous versions of
+	// conversations.
+	VersionsFolder = ".versions"
+
+	// DefaultVersions is how many previous versions of a conversation are
+	// kept by default.
+	DefaultVersions = 10
+
+	versionPrefix = "v_"
+)
+
+// ErrVersionNotFound indicates that a conversation has no version with an ID.
+var ErrVersionNotFound = errors.New("version not found")
+
+// Version is a previous version of a conversation, saved before it was
+// overwritten.
+type Version struct {
+	ID      string    `json:"id"`
+	SavedAt time.Time `json:"saved_at" jsonschema:"When this version was replaced"`
+	Path    string    `json:"-"`
+}
+
+// WithVersions sets how many previous versions of each conversation are
+// kept when it is overwritten. Zero, the default of New, keeps none.
+func WithVersions(n int) Option {
+	return func(s *Storage) {
+		s.versions = max(n, 0)
+	}
+}
+
+// Versions returns how many previous versions of a conversation are kept.
+func (s *Storage) Versions() int {
+	return s.versions
+}
+
+// versionDir returns the directory of a conversation's versions.
+func (s *Storage) versionDir(filePath string) string {
+	return path.Join(s.folder, VersionsFolder, strings.TrimSuffix(s.RelPath(filePath), ".md"))
+}
+
+// versioned reports whether overwriting filePath keeps its previous version.
+func (s *Storage) versioned(filePath string) bool {
+	return s.versions > 0 && strings.HasSuffix(filePath, ".md") && !s.IsInternalPath(filePath) &&
+		(s.folder == "" || strings.HasPrefix(filePath, s.folder+"/"))
+}
+
+// SaveVersioned is Save that first keeps the previous content of a
+// conversation as a version, if versions are kept. It is meant for edits
+// made on a user's behalf; bulk operations such as sync and migrate use
+// Save, so they do not pay for or crowd out versions.
+func (s *Storage) SaveVersioned(ctx context.Context, filePath string, content []byte) error {
+	if s.versioned(filePath) {
+		if previous, ok := s.previousVersion(ctx, filePath, content); ok {
+			return s.saveVersioned(ctx, filePath, previous, content)
+		}
+	}
+	return s.Save(ctx, filePath, content)
+}
+
+// saveVersioned overwrites a conversation, keeping its previous content as
+// a version and dropping the oldest versions beyond the limit, in one batch.
+func (s *Storage) saveVersioned(ctx context.Context, filePath string, previous, content []byte) error {
+	versions, err := s.ListVersions(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	batch := s.Begin("Update " + s.RelPath(filePath))
+	batch.Put(path.Join(s.versionDir(filePath), versionPrefix+strconv.FormatInt(now.UnixNano(), 10)+".md"), previous)
+	batch.Put(filePath, content)
+	for _, v := range versions[min(len(versions), s.versions-1):] {
+		batch.Delete(v.Path)
+	}
+	return batch.Commit(ctx)
+}
+
+// ListVersions returns the previous versions of a conversation, newest
+// first.
+func (s *Storage) ListVersions(ctx context.Context, filePath string) ([]Version, error) {
+	dir := s.versionDir(filePath)
+	files, err := s.List(ctx, dir+"/")
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, f := range files {
+		if path.Dir(f) != dir {
+			continue
+		}
+		id := strings.TrimSuffix(path.Base(f), ".md")
+		nanos, err := strconv.ParseInt(strings.TrimPrefix(id, versionPrefix), 10, 64)
+		if err != nil || !strings.HasPrefix(id, versionPrefix) {
+			continue
+		}
+		versions = append(versions, Version{ID: id, SavedAt: time.Unix(0, nanos).UTC(), Path: f})
+	}
+	slices.SortFunc(versions, func(a, b Version) int { return b.SavedAt.Compare(a.SavedAt) })
+	return versions, nil
+}
+
+// ReadVersion returns the content of a previous version of a conversation.
+func (s *Storage) ReadVersion(ctx context.Context, filePath, id string) ([]byte, error) {
+	if !strings.HasPrefix(id, versionPrefix) || strings.ContainsAny(id, "/\\.") {
+		return nil, fmt.Errorf("%w: %q", ErrVersionNotFound, id)
+	}
+	versionPath := path.Join(s.versionDir(filePath), id+".md")
+	exists, err := s.Exists(ctx, versionPath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s has no version %q", ErrVersionNotFound, filePath, id)
+	}
+	return s.Read(ctx, versionPath)
+}
+
+// deleteVersions queues the removal of every version of a conversation.
+func (b *Batch) deleteVersions(ctx context.Context, filePath string) error {
+	versions, err := b.store.ListVersions(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		b.Delete(v.Path)
+	}
+	return nil
+}
+
+// moveVersions queues moving versions, given by path, to the versions of
+// the conversation at newPath.
+func (b *Batch) moveVersions(ctx context.Context, newPath string, versions []string) error {
+	s := b.store
+	dir := s.versionDir(newPath)
+	for _, v := range versions {
+		content, err := s.Read(ctx, v)
+		if err != nil {
+			return err
+		}
+		b.Put(path.Join(dir, path.Base(v)), content)
+		b.Delete(v)
+	}
+	return nil
+}
+
+// previousVersion returns what filePath holds before content is saved,
+// and whether it should be kept as a version: it must exist, be readable
+// and differ from content.
+func (s *Storage) previousVersion(ctx context.Context, filePath string, content []byte) ([]byte, bool) {
+	previous, err := s.Read(ctx, filePath)
+	if err != nil || bytes.Equal(previous, content) {
+		return nil, false
+	}
+	return previous, true
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+)
+
+func mustSaveVersioned(t *testing.T, s *Storage, rel, content string) {
+	t.Helper()
+	if err := s.SaveVersioned(context.Background(), s.AbsPath(rel), []byte(content)); err != nil {
+		t.Fatalf("SaveVersioned(%s) error = %v", rel, err)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	ctx := context.Background()
+	file, err := NewFromConfig("file", map[string]string{"root": t.TempDir()}, "conversations", WithVersions(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]*Storage{
+		"memory": New(memory.New(), "conversations", WithVersions(2)),
+		"file":   file,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			p := store.AbsPath("claude/a.md")
+			if versions, err := store.ListVersions(ctx, p); err != nil || len(versions) != 0 {
+				t.Fatalf("ListVersions() before saving = %v, %v", versions, err)
+			}
+			for i := 1; i <= 4; i++ {
+				mustSaveVersioned(t, store, "claude/a.md", fmt.Sprintf("v%d", i))
+			}
+			// Saving the same content again keeps no version, and neither
+			// does Save
+			mustSaveVersioned(t, store, "claude/a.md", "v4")
+			mustSave(t, store, "claude/a.md", "v5")
+
+			versions, err := store.ListVersions(ctx, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 2 {
+				t.Fatalf("got %d versions, want 2", len(versions))
+			}
+			if got := mustRead(t, store, "claude/a.md"); got != "v5" {
+				t.Errorf("content = %q, want v5", got)
+			}
+			for i, want := range []string{"v3", "v2"} {
+				content, err := store.ReadVersion(ctx, p, versions[i].ID)
+				if err != nil || string(content) != want {
+					t.Errorf("ReadVersion(%s) = %q, %v, want %q", versions[i].ID, content, err, want)
+				}
+			}
+			if _, err := store.ReadVersion(ctx, p, "v_1"); !errors.Is(err, ErrVersionNotFound) {
+				t.Errorf("ReadVersion(missing) error = %v, want ErrVersionNotFound", err)
+			}
+			if _, err := store.ReadVersion(ctx, p, "../a"); !errors.Is(err, ErrVersionNotFound) {
+				t.Errorf("ReadVersion(../a) error = %v, want ErrVersionNotFound", err)
+			}
+
+			// Versions are not conversations
+			files, err := store.ListConversations(ctx)
+			if err != nil || len(files) != 1 {
+				t.Errorf("ListConversations() = %v, %v, want only a.md", files, err)
+			}
+		})
+	}
+}
+
+func TestVersionsFollowConversation(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New(), "conversations", WithVersions(5))
+	mustSaveVersioned(t, store, "claude/2026-01-10_hello-world.md", legacyConversation)
+	mustSaveVersioned(t, store, "claude/2026-01-10_hello-world.md", legacyConversation+"**Claude:** hello\n")
+
+	if _, err := Migrate(ctx, store, MigrateOptions{Layout: frontmatter.MustParseLayout("{yyyy}/{source}-{slug}.md")}); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	moved := store.AbsPath("2026/claude-hello-world.md")
+	versions, err := store.ListVersions(ctx, moved)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("ListVersions(moved) = %v, %v, want the version moved along", versions, err)
+	}
+	if content, err := store.ReadVersion(ctx, moved, versions[0].ID); err != nil || string(content) != legacyConversation {
+		t.Errorf("ReadVersion() = %q, %v, want the first save", content, err)
+	}
+	if old, _ := store.ListVersions(ctx, store.AbsPath("claude/2026-01-10_hello-world.md")); len(old) != 0 {
+		t.Errorf("versions left at the old path: %v", old)
+	}
+
+	if _, err := store.Trash(ctx, moved); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	if versions, err := store.ListVersions(ctx, moved); err != nil || len(versions) != 0 {
+		t.Errorf("ListVersions() after trashing = %v, %v, want none", versions, err)
+	}
+}
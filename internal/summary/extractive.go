@@ -0,0 +1,151 @@
+package summary
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+var (
+	// sentenceEndRegex splits text after sentence punctuation
+	sentenceEndRegex = regexp.MustCompile(`([.!?])\s+`)
+	// markupRegex matches Markdown emphasis, code and link markup
+	markupRegex = regexp.MustCompile("\\*\\*|__|`|\\[([^\\]]*)\\]\\([^)]*\\)")
+)
+
+type sentence struct {
+	text     string
+	words    []string
+	position int
+	score    float64
+}
+
+// Extractive summarizes a conversation without a model. The description
+// is the highest-scoring sentences, in their original order, where a
+// sentence scores by how frequent its words are in the whole conversation;
+// sentences from the first substantial user message, which usually states
+// the topic, score higher. Tags are the most frequent words. The result only depends
+// on the body.
+func Extractive(body []byte) Summary {
+	messages := conversation.Messages(body)
+
+	var sentences []sentence
+	freq := make(map[string]int)
+	firstUser := true
+	for _, m := range messages {
+		bonus := 1.0
+		if m.IsUser() && firstUser {
+			bonus = 1.5
+		}
+		for _, text := range splitSentences(m.Text) {
+			words := conversation.ContentWords(text)
+			for _, w := range words {
+				freq[w]++
+			}
+			if len(strings.Fields(text)) >= 4 && len(words) > 0 {
+				sentences = append(sentences, sentence{text: text, words: words, position: len(sentences), score: bonus})
+				if bonus > 1 {
+					firstUser = false
+				}
+			}
+		}
+	}
+
+	summary := Summary{Method: MethodExtractive, Tags: frequentWords(freq, MaxTags)}
+	if len(sentences) == 0 {
+		summary.Description = truncate(firstLine(messages), DescriptionLength)
+		return summary
+	}
+
+	for i := range sentences {
+		total := 0
+		for _, w := range sentences[i].words {
+			total += freq[w]
+		}
+		sentences[i].score *= float64(total) / math.Sqrt(float64(len(sentences[i].words)))
+	}
+	ranked := slices.Clone(sentences)
+	slices.SortStableFunc(ranked, func(a, b sentence) int { return cmp.Compare(b.score, a.score) })
+
+	var chosen []sentence
+	length := 0
+	for _, s := range ranked {
+		n := len([]rune(s.text))
+		if len(chosen) > 0 && length+1+n > DescriptionLength {
+			continue
+		}
+		chosen = append(chosen, s)
+		length += n + 1
+		if length >= DescriptionLength/2 {
+			break
+		}
+	}
+	slices.SortFunc(chosen, func(a, b sentence) int { return cmp.Compare(a.position, b.position) })
+
+	parts := make([]string, len(chosen))
+	for i, s := range chosen {
+		parts[i] = s.text
+	}
+	summary.Description = truncate(strings.Join(parts, " "), DescriptionLength)
+	return summary
+}
+
+// splitSentences returns the sentences of a message, skipping code
+// blocks, headings, tables and Markdown markup.
+func splitSentences(text string) []string {
+	var sentences []string
+	inFence := false
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "|") {
+			continue
+		}
+		line = strings.TrimLeft(line, "-*+> ")
+		line = markupRegex.ReplaceAllString(line, "$1")
+		for _, s := range strings.Split(sentenceEndRegex.ReplaceAllString(line, "$1\n"), "\n") {
+			if s = strings.TrimSpace(s); s != "" {
+				sentences = append(sentences, s)
+			}
+		}
+	}
+	return sentences
+}
+
+// frequentWords returns up to n words used at least twice, most frequent
+// first, ties in alphabetical order.
+func frequentWords(freq map[string]int, n int) []string {
+	var words []string
+	for w, count := range freq {
+		if count >= 2 {
+			words = append(words, w)
+		}
+	}
+	slices.SortFunc(words, func(a, b string) int {
+		if c := cmp.Compare(freq[b], freq[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	if len(words) > n {
+		words = words[:n]
+	}
+	return words
+}
+
+// firstLine returns the first line of text in the messages.
+func firstLine(messages []conversation.Message) string {
+	for _, m := range messages {
+		for _, s := range splitSentences(m.Text) {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+// Package summary writes short descriptions and suggests tags for
+// conversations, either by asking a model or extractively.
+package summary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+// Summary methods
+const (
+	MethodSampling   = "sampling"   // written by the client's model
+	MethodExtractive = "extractive" // selected from the conversation
+)
+
+// Limits of a summary
+const (
+	DescriptionLength = 160
+	MaxTags           = 5
+)
+
+// Mode decides how conversations are summarized on save.
+type Mode string
+
+// Summarization modes
+const (
+	ModeAuto       Mode = "auto"       // sampling if the client supports it, else extractive
+	ModeExtractive Mode = "extractive" // never ask the client's model
+	ModeOff        Mode = "off"        // use the first line as the description
+)
+
+// ParseMode parses a mode name. An empty name is ModeAuto.
+func ParseMode(name string) (Mode, error) {
+	if name == "" {
+		return ModeAuto, nil
+	}
+	mode := Mode(strings.ToLower(name))
+	if !slices.Contains([]Mode{ModeAuto, ModeExtractive, ModeOff}, mode) {
+		return "", fmt.Errorf("invalid summarize mode %q: must be auto, extractive or off", name)
+	}
+	return mode, nil
+}
+
+// Summary is a description of a conversation and suggested tags.
+type Summary struct {
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Method      string   `json:"method" jsonschema:"sampling or extractive"`
+	Model       string   `json:"model,omitempty" jsonschema:"Model that wrote the summary, with sampling"`
+}
+
+// promptBytes bounds how much of a conversation is sent for sampling.
+const promptBytes = 48_000
+
+// SystemPrompt is the system prompt of sampling requests.
+const SystemPrompt = `You summarize saved AI conversations for a searchable archive.
+Reply with only a JSON object, no code fence: {"description": "...", "tags": ["..."]}
+- description: one or two sentences, at most 160 characters, saying what the conversation is about and what was concluded. Do not start with "The user" or "This conversation".
+- tags: up to 5 short lowercase topic tags, using hyphens instead of spaces, e.g. "kubernetes", "error-handling".`
+
+// Prompt returns the user message of a sampling request. Long
+// conversations are cut at a message boundary, keeping the beginning,
+// which usually states the topic.
+func Prompt(title string, body []byte) string {
+	messages := conversation.Messages(body)
+	cut, _, err := conversation.Fit(body, messages, 0, len(body), conversation.Budget{MaxBytes: promptBytes})
+	if err != nil {
+		cut = 0
+	}
+
+	var sb strings.Builder
+	if title != "" {
+		fmt.Fprintf(&sb, "Title: %s\n\n", title)
+	}
+	sb.WriteString("Conversation:\n\n")
+	sb.Write(body[:cut])
+	if cut < len(body) {
+		sb.WriteString("\n\n[The rest of the conversation is omitted.]")
+	}
+	return sb.String()
+}
+
+// ParseResponse reads the JSON object a model replied with. Text around
+// the object, such as a code fence, is ignored.
+func ParseResponse(text string) (Summary, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return Summary{}, errors.New("response is not a JSON object")
+	}
+	var reply struct {
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return Summary{}, fmt.Errorf("invalid response: %w", err)
+	}
+	description := strings.Join(strings.Fields(reply.Description), " ")
+	if description == "" {
+		return Summary{}, errors.New("response has no description")
+	}
+	return Summary{
+		Description: truncate(description, DescriptionLength),
+		Tags:        NormalizeTags(reply.Tags),
+		Method:      MethodSampling,
+	}, nil
+}
+
+// NormalizeTags lowercases tags, joins words with hyphens, drops empty
+// and duplicate tags, and keeps at most MaxTags.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))), "-")
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+		if len(out) == MaxTags {
+			break
+		}
+	}
+	return out
+}
+
+// truncate shortens s to at most max characters at a word boundary,
+// adding "..." if it was cut.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max-3])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "..."
+}
@@ -0,0 +1,106 @@
+package summary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConversation = `# Debugging DNS in Kubernetes
+
+**User:** hi
+
+**User:** My pods cannot resolve service names in Kubernetes. DNS lookups for other services time out.
+
+**Claude:** DNS timeouts in Kubernetes usually come from CoreDNS. Check that the CoreDNS pods are running and that the kube-dns service has endpoints.
+
+` + "```sh\nkubectl get pods -n kube-system -l k8s-app=kube-dns\n```" + `
+
+**User:** The CoreDNS pods are crash looping.
+
+**Claude:** A crash looping CoreDNS pod often means a forwarding loop. Remove the loop from the node's resolv.conf and restart CoreDNS.
+`
+
+func TestExtractive(t *testing.T) {
+	s := Extractive([]byte(testConversation))
+	if s.Method != MethodExtractive {
+		t.Errorf("Method = %q", s.Method)
+	}
+	if s.Description == "" || len([]rune(s.Description)) > DescriptionLength {
+		t.Errorf("Description = %q", s.Description)
+	}
+	if strings.Contains(s.Description, "kubectl") || strings.Contains(s.Description, "**") {
+		t.Errorf("Description %q includes code or markup", s.Description)
+	}
+	if !strings.Contains(s.Description, "resolve service names") {
+		t.Errorf("Description %q should start from the user's question", s.Description)
+	}
+	if len(s.Tags) == 0 || s.Tags[0] != "coredns" {
+		t.Errorf("Tags = %q, want coredns first", s.Tags)
+	}
+
+	// Deterministic
+	for range 5 {
+		if again := Extractive([]byte(testConversation)); !reflect.DeepEqual(again, s) {
+			t.Fatalf("Extractive() = %+v, then %+v", s, again)
+		}
+	}
+}
+
+func TestExtractiveShortAndUnlabeled(t *testing.T) {
+	if s := Extractive([]byte("**User:** hi\n\n**Claude:** Hello!\n")); s.Description != "hi" {
+		t.Errorf("Description = %q, want the first line", s.Description)
+	}
+	if s := Extractive([]byte("Notes on Go generics and type parameters for later.\n")); s.Description != "Notes on Go generics and type parameters for later." {
+		t.Errorf("Description = %q", s.Description)
+	}
+	if s := Extractive(nil); s.Description != "" || len(s.Tags) != 0 {
+		t.Errorf("Extractive(nil) = %+v", s)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	s, err := ParseResponse("```json\n{\"description\": \"Fixing  CoreDNS\\nforwarding loops.\", \"tags\": [\"Kubernetes\", \"#dns\", \"core dns\", \"dns\", \"\"]}\n```")
+	if err != nil {
+		t.Fatalf("ParseResponse() error = %v", err)
+	}
+	want := Summary{Description: "Fixing CoreDNS forwarding loops.", Tags: []string{"kubernetes", "dns", "core-dns"}, Method: MethodSampling}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("ParseResponse() = %+v, want %+v", s, want)
+	}
+
+	for _, text := range []string{"Sure! Here is a summary.", `{"tags": ["go"]}`, `{"description": 3}`} {
+		if _, err := ParseResponse(text); err == nil {
+			t.Errorf("ParseResponse(%q) should fail", text)
+		}
+	}
+
+	long, _ := ParseResponse(`{"description": "` + strings.Repeat("word ", 60) + `"}`)
+	if n := len([]rune(long.Description)); n > DescriptionLength || !strings.HasSuffix(long.Description, "word...") {
+		t.Errorf("long description = %q (%d characters)", long.Description, n)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	prompt := Prompt("DNS", []byte(testConversation))
+	if !strings.HasPrefix(prompt, "Title: DNS\n\n") || !strings.Contains(prompt, "forwarding loop") {
+		t.Errorf("Prompt() = %q", prompt)
+	}
+
+	long := strings.Repeat("**User:** "+strings.Repeat("x", 1000)+"\n\n", 100)
+	prompt = Prompt("", []byte(long))
+	if len(prompt) > promptBytes+100 || !strings.HasSuffix(prompt, "omitted.]") {
+		t.Errorf("Prompt() of a long conversation is %d bytes", len(prompt))
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for name, want := range map[string]Mode{"": ModeAuto, "auto": ModeAuto, "Extractive": ModeExtractive, "off": ModeOff} {
+		if got, err := ParseMode(name); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseMode("sampling"); err == nil {
+		t.Error("ParseMode(sampling) should fail")
+	}
+}
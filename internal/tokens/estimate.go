@@ -0,0 +1,52 @@
+package tokens
+
+import (
+	"github.com/grokify/chathub/internal/conversation"
+)
+
+// MessageTokens is the token count of one message.
+type MessageTokens struct {
+	Speaker string `json:"speaker,omitempty"`
+	Tokens  int    `json:"tokens"`
+}
+
+// Usage is the token count and estimated cost of a conversation body.
+type Usage struct {
+	Tokenizer Tokenizer       `json:"tokenizer"`
+	Total     int             `json:"total"`
+	Messages  []MessageTokens `json:"messages,omitempty"`
+
+	// InputTokens and OutputTokens are what generating the conversation
+	// through an API would bill: each assistant message is output, and
+	// every message before it is input again, since chat APIs resend the
+	// history on each turn. Prompt caching is not taken into account.
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+
+	// Cost is the estimated cost in USD, or 0 if the model has no price.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Estimate counts the tokens of a conversation body with the default
+// tokenizer and estimates its cost for model, which may be empty.
+func Estimate(body []byte, model string) Usage {
+	counter := NewCounter(Default(), model)
+	usage := Usage{Tokenizer: counter.Tokenizer()}
+
+	history := 0
+	for _, m := range conversation.Messages(body) {
+		n := counter.Count(m.Text)
+		usage.Total += n
+		usage.Messages = append(usage.Messages, MessageTokens{Speaker: m.Speaker, Tokens: n})
+		if m.Speaker != "" && !m.IsUser() {
+			usage.InputTokens += history
+			usage.OutputTokens += n
+		}
+		history += n
+	}
+
+	if price, ok := LookupPrice(model); ok {
+		usage.Cost = price.Cost(usage.InputTokens, usage.OutputTokens)
+	}
+	return usage
+}
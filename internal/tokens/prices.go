@@ -0,0 +1,108 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Price is what a model charges, in USD per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Cost returns the cost in USD of input and output tokens, rounded to
+// a hundredth of a cent.
+func (p Price) Cost(input, output int) float64 {
+	cost := (float64(input)*p.Input + float64(output)*p.Output) / 1e6
+	return math.Round(cost*1e4) / 1e4
+}
+
+// builtinPrices are list prices of common models. Keys are model names;
+// snapshots of a model, such as "claude-sonnet-4-20250514", match it
+// too. Override or extend them with SetPrices.
+var builtinPrices = map[string]Price{
+	"gpt-4o":            {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60},
+	"gpt-4.1":           {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":      {Input: 0.10, Output: 0.40},
+	"gpt-5":             {Input: 1.25, Output: 10.00},
+	"gpt-5-mini":        {Input: 0.25, Output: 2.00},
+	"o3":                {Input: 2.00, Output: 8.00},
+	"o3-mini":           {Input: 1.10, Output: 4.40},
+	"o4-mini":           {Input: 1.10, Output: 4.40},
+	"claude-opus-4":     {Input: 15.00, Output: 75.00},
+	"claude-opus-4-5":   {Input: 5.00, Output: 25.00},
+	"claude-sonnet-4":   {Input: 3.00, Output: 15.00},
+	"claude-3-7-sonnet": {Input: 3.00, Output: 15.00},
+	"claude-haiku-4-5":  {Input: 1.00, Output: 5.00},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4.00},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10.00},
+	"gemini-2.5-flash":  {Input: 0.30, Output: 2.50},
+}
+
+var prices = struct {
+	sync.RWMutex
+	models map[string]Price
+}{models: maps.Clone(builtinPrices)}
+
+// SetPrices adds model prices, replacing built-in prices for the same
+// model.
+func SetPrices(models map[string]Price) error {
+	for model, price := range models {
+		if model == "" {
+			return errors.New("price has an empty model name")
+		}
+		if price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("price for %s is negative", model)
+		}
+	}
+	prices.Lock()
+	defer prices.Unlock()
+	for model, price := range models {
+		prices.models[strings.ToLower(model)] = price
+	}
+	return nil
+}
+
+// LookupPrice returns the price of model, ignoring case. A model without
+// a price of its own gets the price of the longest priced name it is a
+// snapshot of: that name followed by a date or version, as in
+// "gpt-4o-2024-08-06" or "claude-sonnet-4@20250514", or by "-latest" or
+// "-preview". Other variants, such as "o3-mini" of "o3", do not match.
+func LookupPrice(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	prices.RLock()
+	defer prices.RUnlock()
+	if price, ok := prices.models[model]; ok {
+		return price, true
+	}
+	var (
+		best  Price
+		found string
+	)
+	for name, price := range prices.models {
+		if len(name) > len(found) && strings.HasPrefix(model, name) && snapshotSuffix(model[len(name):]) {
+			best, found = price, name
+		}
+	}
+	return best, found != ""
+}
+
+// snapshotSuffix reports whether suffix, following a model name, names a
+// snapshot of that model rather than another model.
+func snapshotSuffix(suffix string) bool {
+	if suffix == "" || !strings.ContainsRune("-@:", rune(suffix[0])) {
+		return false
+	}
+	rest := suffix[1:]
+	if rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+		return true
+	}
+	return suffix[0] == '-' && (strings.HasPrefix(rest, "latest") || strings.HasPrefix(rest, "preview"))
+}
@@ -0,0 +1,142 @@
+// Package tokens counts the tokens in conversations and estimates what
+// they cost to generate. The cl100k and o200k BPE tables are embedded, so
+// counting works offline.
+package tokens
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Tokenizer names a way of counting tokens.
+type Tokenizer string
+
+// Tokenizers
+const (
+	TokenizerAuto      Tokenizer = "auto"        // chosen from the model
+	TokenizerCL100K    Tokenizer = "cl100k_base" // GPT-4, GPT-3.5
+	TokenizerO200K     Tokenizer = "o200k_base"  // GPT-4o, GPT-4.1, GPT-5, o-series
+	TokenizerHeuristic Tokenizer = "heuristic"   // about four characters per token
+)
+
+// Tokenizers lists the supported tokenizers.
+var Tokenizers = []Tokenizer{TokenizerAuto, TokenizerCL100K, TokenizerO200K, TokenizerHeuristic}
+
+// o200kModels are model prefixes that use o200k_base. Other GPT models
+// use cl100k_base.
+var o200kModels = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "chatgpt-4o", "o1", "o3", "o4"}
+
+var defaultTokenizer atomic.Value
+
+func init() {
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+	defaultTokenizer.Store(TokenizerAuto)
+}
+
+// ParseTokenizer parses a tokenizer name. An empty name is TokenizerAuto.
+func ParseTokenizer(name string) (Tokenizer, error) {
+	if name == "" {
+		return TokenizerAuto, nil
+	}
+	t := Tokenizer(strings.ToLower(name))
+	switch t {
+	case "cl100k":
+		t = TokenizerCL100K
+	case "o200k":
+		t = TokenizerO200K
+	}
+	if !slices.Contains(Tokenizers, t) {
+		return "", fmt.Errorf("invalid tokenizer %q: must be auto, cl100k_base, o200k_base or heuristic", name)
+	}
+	return t, nil
+}
+
+// SetDefault sets the tokenizer used by Estimate.
+func SetDefault(t Tokenizer) {
+	defaultTokenizer.Store(t)
+}
+
+// Default returns the tokenizer used by Estimate.
+func Default() Tokenizer {
+	return defaultTokenizer.Load().(Tokenizer)
+}
+
+// ForModel resolves TokenizerAuto to the tokenizer of a model. Models
+// without an offline tokenizer, such as Claude and Gemini, are counted
+// with cl100k_base, which is within about 10-20% for English text.
+func ForModel(t Tokenizer, model string) Tokenizer {
+	if t != TokenizerAuto {
+		return t
+	}
+	model = strings.ToLower(model)
+	for _, prefix := range o200kModels {
+		if strings.HasPrefix(model, prefix) {
+			return TokenizerO200K
+		}
+	}
+	return TokenizerCL100K
+}
+
+// Counter counts tokens with one tokenizer.
+type Counter struct {
+	tokenizer Tokenizer
+	enc       *tiktoken.Tiktoken
+}
+
+var encodings sync.Map // Tokenizer → func() (*tiktoken.Tiktoken, error)
+
+// NewCounter returns a counter for t, resolving TokenizerAuto with model.
+// If a BPE table cannot be loaded, the counter uses the heuristic.
+func NewCounter(t Tokenizer, model string) *Counter {
+	t = ForModel(t, model)
+	if t == TokenizerHeuristic {
+		return &Counter{tokenizer: t}
+	}
+	load, _ := encodings.LoadOrStore(t, sync.OnceValues(func() (*tiktoken.Tiktoken, error) {
+		return tiktoken.GetEncoding(string(t))
+	}))
+	enc, err := load.(func() (*tiktoken.Tiktoken, error))()
+	if err != nil {
+		return &Counter{tokenizer: TokenizerHeuristic}
+	}
+	return &Counter{tokenizer: t, enc: enc}
+}
+
+// Tokenizer returns the tokenizer the counter uses.
+func (c *Counter) Tokenizer() Tokenizer {
+	return c.tokenizer
+}
+
+// Count returns the number of tokens in text.
+func (c *Counter) Count(text string) int {
+	if c.enc == nil {
+		return Heuristic(text)
+	}
+	return len(c.enc.EncodeOrdinary(text))
+}
+
+// Heuristic estimates the tokens in text without a BPE table: a token per
+// four characters of ASCII letters and digits, plus one per punctuation
+// mark and per character of other scripts, which BPE tokenizers split
+// more finely.
+func Heuristic(text string) int {
+	word, other := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+		case unicode.IsSpace(r):
+		default:
+			other++
+		}
+	}
+	return (word+3)/4 + other
+}
@@ -0,0 +1,123 @@
+package tokens
+
+import (
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		tokenizer Tokenizer
+		text      string
+		want      int
+	}{
+		{TokenizerCL100K, "hello world", 2},
+		{TokenizerO200K, "hello world", 2},
+		{TokenizerCL100K, "", 0},
+		{TokenizerHeuristic, "hello world", 3},
+		{TokenizerHeuristic, "日本語", 3},
+	}
+	for _, tt := range tests {
+		if got := NewCounter(tt.tokenizer, "").Count(tt.text); got != tt.want {
+			t.Errorf("%s: Count(%q) = %d, want %d", tt.tokenizer, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestForModel(t *testing.T) {
+	tests := map[string]Tokenizer{
+		"gpt-4o-2024-08-06":        TokenizerO200K,
+		"o3-mini":                  TokenizerO200K,
+		"gpt-4":                    TokenizerCL100K,
+		"claude-sonnet-4-20250514": TokenizerCL100K,
+		"":                         TokenizerCL100K,
+	}
+	for model, want := range tests {
+		if got := ForModel(TokenizerAuto, model); got != want {
+			t.Errorf("ForModel(auto, %q) = %s, want %s", model, got, want)
+		}
+	}
+	if got := ForModel(TokenizerHeuristic, "gpt-4o"); got != TokenizerHeuristic {
+		t.Errorf("ForModel() = %s, should keep an explicit tokenizer", got)
+	}
+}
+
+func TestParseTokenizer(t *testing.T) {
+	for name, want := range map[string]Tokenizer{"": TokenizerAuto, "o200k": TokenizerO200K, "CL100K_BASE": TokenizerCL100K, "heuristic": TokenizerHeuristic} {
+		if got, err := ParseTokenizer(name); err != nil || got != want {
+			t.Errorf("ParseTokenizer(%q) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseTokenizer("p50k_base"); err == nil {
+		t.Error("ParseTokenizer(p50k_base) should fail")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	body := []byte("**User:** hello world\n\n**Assistant:** hello world\n\n**User:** hello world\n\n**Assistant:** hello world\n")
+	usage := Estimate(body, "gpt-4o")
+
+	if usage.Tokenizer != TokenizerO200K || usage.Total != 8 || len(usage.Messages) != 4 {
+		t.Fatalf("Estimate() = %+v", usage)
+	}
+	// Each reply is generated from the whole history before it
+	if usage.InputTokens != 2+6 || usage.OutputTokens != 4 {
+		t.Errorf("input, output = %d, %d; want 8, 4", usage.InputTokens, usage.OutputTokens)
+	}
+	want := Price{Input: 2.50, Output: 10.00}.Cost(8, 4)
+	if usage.Cost != want {
+		t.Errorf("Cost = %v, want %v", usage.Cost, want)
+	}
+
+	if usage := Estimate(body, "unknown-model"); usage.Cost != 0 {
+		t.Errorf("Cost for unknown model = %v, want 0", usage.Cost)
+	}
+}
+
+func TestLookupPrice(t *testing.T) {
+	price, ok := LookupPrice("GPT-4o-mini-2024-07-18")
+	if !ok || price != builtinPrices["gpt-4o-mini"] {
+		t.Errorf("LookupPrice() = %+v, %v; want the longest matching prefix", price, ok)
+	}
+	if _, ok := LookupPrice("llama-3"); ok {
+		t.Error("LookupPrice(llama-3) should not match")
+	}
+	for model, want := range map[string]string{
+		"o3":                          "o3",
+		"o3-2025-04-16":               "o3",
+		"o3-mini":                     "o3-mini",
+		"o3-mini-2025-01-31":          "o3-mini",
+		"claude-sonnet-4@20250514":    "claude-sonnet-4",
+		"claude-opus-4-5-20251101":    "claude-opus-4-5",
+		"gemini-2.5-flash-preview-05": "gemini-2.5-flash",
+	} {
+		if price, ok := LookupPrice(model); !ok || price != builtinPrices[want] {
+			t.Errorf("LookupPrice(%s) = %+v, %v, want the price of %s", model, price, ok, want)
+		}
+	}
+	for _, model := range []string{"o3-pro", "gpt-5-nano", "gemini-2.5-flash-lite"} {
+		if price, ok := LookupPrice(model); ok {
+			t.Errorf("LookupPrice(%s) = %+v, want no price for another variant", model, price)
+		}
+	}
+
+	defer func() {
+		prices.Lock()
+		delete(prices.models, "llama-3")
+		prices.Unlock()
+	}()
+	if err := SetPrices(map[string]Price{"Llama-3": {Input: 0.1, Output: 0.2}}); err != nil {
+		t.Fatal(err)
+	}
+	if price, ok := LookupPrice("llama-3-70b"); !ok || price.Output != 0.2 {
+		t.Errorf("LookupPrice() after SetPrices = %+v, %v", price, ok)
+	}
+	if err := SetPrices(map[string]Price{"x": {Input: -1}}); err == nil {
+		t.Error("SetPrices() should reject negative prices")
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	if got := (Price{Input: 3, Output: 15}).Cost(1_000_000, 100_000); got != 4.5 {
+		t.Errorf("Cost() = %v, want 4.5", got)
+	}
+}
@@ -1,12 +1,14 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"time"
 
 	"github.com/grokify/chathub/internal/frontmatter"
 	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/tokens"
 )
 
 // AppendConversationInput is the input for the append_conversation tool.
@@ -17,12 +19,23 @@ type AppendConversationInput struct {
 
 // AppendConversationOutput is the output for the append_conversation tool.
 type AppendConversationOutput struct {
-	Path         string `json:"path" jsonschema:"Updated file path"`
-	MessageCount int    `json:"message_count,omitempty" jsonschema:"Updated message count"`
+	Path         string           `json:"path" jsonschema:"Updated file path"`
+	MessageCount int              `json:"message_count,omitempty" jsonschema:"Updated message count"`
+	Tokens       *tokens.Usage    `json:"tokens,omitempty" jsonschema:"Updated token counts and estimated cost"`
+	Redaction    *RedactionReport `json:"redaction,omitempty" jsonschema:"Sensitive content found in the input"`
+	Queued       *QueuedWrite     `json:"queued,omitempty" jsonschema:"Set if the backend was unavailable and the write was queued for delivery"`
 }
 
 // AppendConversation appends content to an existing conversation.
-func AppendConversation(ctx context.Context, store *storage.Storage, input AppendConversationInput) (AppendConversationOutput, error) {
+// The appended content is passed through deps.Redactor.
+func AppendConversation(ctx context.Context, store *storage.Storage, input AppendConversationInput, deps Deps) (AppendConversationOutput, error) {
+	// Redact sensitive content
+	rd := newRedactor(deps.Redactor)
+	var err error
+	if input.Content, err = rd.apply("content", input.Content); err != nil {
+		return AppendConversationOutput{}, err
+	}
+
 	// Read existing content
 	existing, err := store.Read(ctx, input.Path)
 	if err != nil {
@@ -38,18 +51,24 @@ func AppendConversation(ctx context.Context, store *storage.Storage, input Appen
 	if fm == nil {
 		// No frontmatter - just append
 		newContent := append(existing, []byte("\n\n"+input.Content)...)
-		if err := store.Save(ctx, input.Path, newContent); err != nil {
+		queued, err := queuedWrite(store.SaveVersioned(ctx, input.Path, newContent))
+		if err != nil {
 			return AppendConversationOutput{}, fmt.Errorf("failed to save: %w", err)
 		}
-		return AppendConversationOutput{Path: input.Path}, nil
+		return AppendConversationOutput{Path: input.Path, Redaction: rd.result(), Queued: queued}, nil
 	}
 
-	// Update frontmatter
-	fm.LastMod = time.Now().UTC()
-	fm.MessageCount++ // Increment message count (approximate)
+	// Append new content to body, keeping it intact up to its trailing whitespace
+	newBody := append(bytes.TrimRight(body, " \t\r\n"), []byte("\n\n"+input.Content)...)
 
-	// Append new content to body
-	newBody := append(body, []byte("\n\n"+input.Content)...)
+	// Update frontmatter. Without speaker labels to count, the message
+	// count is incremented instead (approximate)
+	fm.LastMod = time.Now().UTC()
+	previous := fm.MessageCount
+	usage := countTokens(fm, newBody)
+	if fm.MessageCount == previous {
+		fm.MessageCount++
+	}
 
 	// Render updated document
 	updated, err := fm.RenderWithContent(newBody)
@@ -58,12 +77,16 @@ func AppendConversation(ctx context.Context, store *storage.Storage, input Appen
 	}
 
 	// Save
-	if err := store.Save(ctx, input.Path, updated); err != nil {
+	queued, err := queuedWrite(store.SaveVersioned(ctx, input.Path, updated))
+	if err != nil {
 		return AppendConversationOutput{}, fmt.Errorf("failed to save: %w", err)
 	}
 
 	return AppendConversationOutput{
 		Path:         input.Path,
 		MessageCount: fm.MessageCount,
+		Tokens:       usage,
+		Redaction:    rd.result(),
+		Queued:       queued,
 	}, nil
 }
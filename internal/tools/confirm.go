@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/grokify/chathub/internal/config"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// maxConfirmItems limits how many conversations are listed in a prompt.
+const maxConfirmItems = 10
+
+// ConfirmItem describes a conversation affected by a destructive operation.
+type ConfirmItem struct {
+	Path   string
+	Title  string
+	Source string
+	Date   string
+}
+
+// ConfirmRequest describes a destructive operation awaiting user confirmation.
+type ConfirmRequest struct {
+	Action string
+	Items  []ConfirmItem
+}
+
+// Confirmation is the outcome of a confirmation request.
+type Confirmation struct {
+	Approved bool
+	Reason   string
+}
+
+// ConfirmFunc asks the user to approve a destructive operation.
+type ConfirmFunc func(ctx context.Context, req ConfirmRequest) (Confirmation, error)
+
+// NewSessionConfirmer returns a ConfirmFunc that asks the user through MCP
+// elicitation. If the client does not support elicitation, the fallback
+// policy, config.ConfirmPolicyAllow or config.ConfirmPolicyDeny, decides
+// whether the operation proceeds. The user must explicitly confirm; an
+// accepted prompt without a true confirm field is not approval.
+func NewSessionConfirmer(session *mcp.ServerSession, fallbackPolicy string) ConfirmFunc {
+	return func(ctx context.Context, req ConfirmRequest) (Confirmation, error) {
+		if !supportsElicitation(session) {
+			if fallbackPolicy == config.ConfirmPolicyAllow {
+				return Confirmation{Approved: true}, nil
+			}
+			return Confirmation{
+				Reason: "confirmation required but client does not support elicitation",
+			}, nil
+		}
+
+		result, err := session.Elicit(ctx, &mcp.ElicitParams{
+			Message: req.Message(),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{
+						"type":        "boolean",
+						"title":       "Confirm",
+						"description": req.Action,
+						"default":     false,
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		})
+		if err != nil {
+			return Confirmation{}, fmt.Errorf("failed to request confirmation: %w", err)
+		}
+
+		switch result.Action {
+		case "accept":
+		case "decline":
+			return Confirmation{Reason: "declined by user"}, nil
+		default:
+			return Confirmation{Reason: "cancelled by user"}, nil
+		}
+		if confirmed, _ := result.Content["confirm"].(bool); !confirmed {
+			return Confirmation{Reason: "not confirmed by user"}, nil
+		}
+
+		return Confirmation{Approved: true}, nil
+	}
+}
+
+// Message renders the prompt shown to the user.
+func (r ConfirmRequest) Message() string {
+	var sb strings.Builder
+	sb.WriteString(r.Action)
+	sb.WriteString("?\n")
+
+	for i, item := range r.Items {
+		if i == maxConfirmItems {
+			fmt.Fprintf(&sb, "\n...and %d more", len(r.Items)-maxConfirmItems)
+			break
+		}
+		sb.WriteString("\n- ")
+		if item.Title != "" {
+			fmt.Fprintf(&sb, "%q", item.Title)
+		} else {
+			sb.WriteString(item.Path)
+		}
+
+		var details []string
+		if item.Source != "" {
+			details = append(details, item.Source)
+		}
+		if item.Date != "" {
+			details = append(details, item.Date)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(details, ", "))
+		}
+		if item.Title != "" {
+			fmt.Fprintf(&sb, " %s", item.Path)
+		}
+	}
+
+	return sb.String()
+}
+
+func supportsElicitation(session *mcp.ServerSession) bool {
+	if session == nil {
+		return false
+	}
+	params := session.InitializeParams()
+	return params != nil && params.Capabilities != nil && params.Capabilities.Elicitation != nil
+}
+
+// confirm runs confirmFn, approving the request if confirmFn is nil.
+func confirm(ctx context.Context, confirmFn ConfirmFunc, req ConfirmRequest) (Confirmation, error) {
+	if confirmFn == nil {
+		return Confirmation{Approved: true}, nil
+	}
+	return confirmFn(ctx, req)
+}
+
+// describeConversation builds a ConfirmItem from a stored conversation,
+// falling back to the path alone if it cannot be read or parsed.
+func describeConversation(ctx context.Context, store *storage.Storage, filePath string) ConfirmItem {
+	item := ConfirmItem{Path: filePath}
+
+	content, err := store.Read(ctx, filePath)
+	if err != nil {
+		return item
+	}
+
+	fm, _, err := frontmatter.Parse(content)
+	if err != nil || fm == nil {
+		return item
+	}
+
+	item.Title = fm.Title
+	item.Source = fm.Source
+	if !fm.Date.IsZero() {
+		item.Date = fm.Date.Format("2006-01-02")
+	}
+	return item
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/grokify/chathub/internal/config"
+)
+
+// confirmThroughClient asks for confirmation from a tool handler, so the
+// request goes to a connected client that answers with result.
+func confirmThroughClient(t *testing.T, result *mcp.ElicitResult) (Confirmation, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	var got Confirmation
+	var confirmErr error
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	server.AddTool(&mcp.Tool{Name: "confirm", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			got, confirmErr = NewSessionConfirmer(req.Session, config.ConfirmPolicyDeny)(ctx, ConfirmRequest{Action: "Delete 1 conversation"})
+			return &mcp.CallToolResult{}, nil
+		})
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, &mcp.ClientOptions{
+		ElicitationHandler: func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			return result, nil
+		},
+	})
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "confirm"})
+	if err != nil || res.IsError {
+		t.Fatalf("CallTool() = %+v, %v", res, err)
+	}
+	return got, confirmErr
+}
+
+func TestSessionConfirmer(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *mcp.ElicitResult
+		want   bool
+	}{
+		{"confirmed", &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": true}}, true},
+		{"not confirmed", &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": false}}, false},
+		{"accepted without content", &mcp.ElicitResult{Action: "accept"}, false},
+		{"declined", &mcp.ElicitResult{Action: "decline"}, false},
+		{"cancelled", &mcp.ElicitResult{Action: "cancel"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An answer without a confirm field may be rejected as an
+			// error instead, but must never be approval
+			got, err := confirmThroughClient(t, tt.result)
+			if got.Approved != tt.want {
+				t.Errorf("Approved = %v, want %v", got.Approved, tt.want)
+			}
+			if !got.Approved && got.Reason == "" && err == nil {
+				t.Error("no reason given for not approving")
+			}
+		})
+	}
+}
+
+func TestSessionConfirmerFallback(t *testing.T) {
+	ctx := context.Background()
+	req := ConfirmRequest{Action: "Delete 1 conversation"}
+	for policy, want := range map[string]bool{config.ConfirmPolicyAllow: true, config.ConfirmPolicyDeny: false} {
+		got, err := NewSessionConfirmer(nil, policy)(ctx, req)
+		if err != nil || got.Approved != want {
+			t.Errorf("policy %s: got %+v, %v, want approved %v", policy, got, err, want)
+		}
+	}
+}
+
+func TestConfirmRequestMessage(t *testing.T) {
+	req := ConfirmRequest{Action: "Delete 12 conversations"}
+	req.Items = append(req.Items,
+		ConfirmItem{Path: "conversations/claude/keys.md", Title: "Key rotation", Source: "claude", Date: "2026-01-10"},
+		ConfirmItem{Path: "conversations/notes.md"},
+	)
+	for range 10 {
+		req.Items = append(req.Items, ConfirmItem{Path: "conversations/more.md"})
+	}
+
+	msg := req.Message()
+	for _, want := range []string{
+		"Delete 12 conversations?\n",
+		`- "Key rotation" (claude, 2026-01-10) conversations/claude/keys.md`,
+		"- conversations/notes.md",
+		"...and 2 more",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Message() = %q, want it to contain %q", msg, want)
+		}
+	}
+}
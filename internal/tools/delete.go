@@ -7,8 +7,9 @@ import (
 	"github.com/grokify/chathub/internal/storage"
 )
 
-// DeleteConversation deletes a conversation from storage.
-func DeleteConversation(ctx context.Context, store *storage.Storage, input DeleteConversationInput) (DeleteConversationOutput, error) {
+// DeleteConversation moves a conversation to the trash.
+// If confirmFn is non-nil, the user must approve the deletion first.
+func DeleteConversation(ctx context.Context, store *storage.Storage, input DeleteConversationInput, confirmFn ConfirmFunc) (DeleteConversationOutput, error) {
 	// Check if file exists
 	exists, err := store.Exists(ctx, input.Path)
 	if err != nil {
@@ -22,13 +23,34 @@ func DeleteConversation(ctx context.Context, store *storage.Storage, input Delet
 		}, nil
 	}
 
-	// Delete the file
-	if err := store.Delete(ctx, input.Path); err != nil {
+	// Ask the user to confirm
+	confirmation, err := confirm(ctx, confirmFn, ConfirmRequest{
+		Action: "Delete conversation",
+		Items:  []ConfirmItem{describeConversation(ctx, store, input.Path)},
+	})
+	if err != nil {
+		return DeleteConversationOutput{}, err
+	}
+	if !confirmation.Approved {
+		return DeleteConversationOutput{
+			Deleted: false,
+			Message: fmt.Sprintf("not deleted: %s", confirmation.Reason),
+		}, nil
+	}
+
+	// Move the file to the trash
+	item, err := store.Trash(ctx, input.Path)
+	if err != nil {
 		return DeleteConversationOutput{}, fmt.Errorf("failed to delete conversation: %w", err)
 	}
 
+	// Opportunistically purge expired trash; failures here should not
+	// fail the delete itself.
+	_, _ = store.PurgeExpiredTrash(ctx)
+
 	return DeleteConversationOutput{
 		Deleted: true,
-		Message: fmt.Sprintf("deleted: %s", input.Path),
+		TrashID: item.ID,
+		Message: fmt.Sprintf("moved to trash: %s (restore with id %s)", input.Path, item.ID),
 	}, nil
 }
@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+	"github.com/grokify/chathub/internal/diff"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// Diff modes
+const (
+	DiffModeUnified  = "unified"  // a unified diff of the bodies
+	DiffModeMessages = "messages" // changes aligned by message
+)
+
+// Message change statuses
+const (
+	MessageAdded   = "added"
+	MessageRemoved = "removed"
+	MessageChanged = "changed"
+)
+
+// defaultDiffContext is the default number of context lines in diffs.
+const defaultDiffContext = 3
+
+// DiffConversationsInput is the input for the diff_conversations tool.
+type DiffConversationsInput struct {
+	Path        string `json:"path" jsonschema:"Path to the conversation to compare from"`
+	Other       string `json:"other,omitempty" jsonschema:"Path to the conversation to compare to; omit to compare versions of path"`
+	FromVersion string `json:"from_version,omitempty" jsonschema:"Version ID of path to compare from (default: the current content if other is set, otherwise the latest previous version)"`
+	ToVersion   string `json:"to_version,omitempty" jsonschema:"Version ID of other, or of path, to compare to (default: the current content)"`
+	Mode        string `json:"mode,omitempty" jsonschema:"unified (default) for a unified diff of the bodies; messages to align changes by message"`
+	Context     *int   `json:"context,omitempty" jsonschema:"Context lines around each change (default 3)"`
+}
+
+// DiffSide is one of the compared conversations.
+type DiffSide struct {
+	Path     string `json:"path"`
+	Version  string `json:"version,omitempty" jsonschema:"Version ID, or empty for the current content"`
+	Title    string `json:"title,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Messages int    `json:"messages"`
+}
+
+// MessageChange is a message that differs between the conversations.
+// Messages are numbered from 1 as in read_conversation; text before the
+// first message is 0.
+type MessageChange struct {
+	Status  string `json:"status" jsonschema:"added, removed or changed"`
+	From    int    `json:"from,omitempty" jsonschema:"Number of the message in the old conversation"`
+	To      int    `json:"to,omitempty" jsonschema:"Number of the message in the new conversation"`
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text,omitempty" jsonschema:"Text of an added or removed message"`
+	Diff    string `json:"diff,omitempty" jsonschema:"Unified diff of a changed message"`
+}
+
+// DiffStats counts the changes between the conversations.
+type DiffStats struct {
+	LinesAdded      int `json:"lines_added"`
+	LinesRemoved    int `json:"lines_removed"`
+	MessagesAdded   int `json:"messages_added"`
+	MessagesRemoved int `json:"messages_removed"`
+	MessagesChanged int `json:"messages_changed"`
+}
+
+// DiffConversationsOutput is the output for the diff_conversations tool.
+type DiffConversationsOutput struct {
+	From      DiffSide             `json:"from"`
+	To        DiffSide             `json:"to"`
+	Identical bool                 `json:"identical"`
+	Metadata  []frontmatter.Change `json:"metadata,omitempty" jsonschema:"Frontmatter fields that changed"`
+	Diff      string               `json:"diff,omitempty" jsonschema:"Unified diff of the bodies, in unified mode"`
+	Messages  []MessageChange      `json:"messages,omitempty" jsonschema:"Changed messages, in messages mode"`
+	Stats     DiffStats            `json:"stats"`
+	Versions  []storage.Version    `json:"versions,omitempty" jsonschema:"Previous versions of path, newest first, when comparing versions"`
+}
+
+// diffDocument is a parsed side of a diff.
+type diffDocument struct {
+	fm       *frontmatter.Frontmatter
+	body     []byte
+	messages []conversation.Message
+}
+
+// DiffConversations compares two conversations, or two versions of one,
+// listing frontmatter changes separately from changes to the body.
+func DiffConversations(ctx context.Context, store *storage.Storage, input DiffConversationsInput) (DiffConversationsOutput, error) {
+	mode := cmp.Or(strings.ToLower(input.Mode), DiffModeUnified)
+	if mode != DiffModeUnified && mode != DiffModeMessages {
+		return DiffConversationsOutput{}, fmt.Errorf("invalid mode %q: must be %s or %s", input.Mode, DiffModeUnified, DiffModeMessages)
+	}
+	contextLines := defaultDiffContext
+	if input.Context != nil {
+		contextLines = max(*input.Context, 0)
+	}
+
+	output := DiffConversationsOutput{
+		From: DiffSide{Path: input.Path, Version: input.FromVersion},
+		To:   DiffSide{Path: cmp.Or(input.Other, input.Path), Version: input.ToVersion},
+	}
+	if input.Other == "" {
+		versions, err := store.ListVersions(ctx, input.Path)
+		if err != nil {
+			return DiffConversationsOutput{}, fmt.Errorf("failed to list versions: %w", err)
+		}
+		output.Versions = versions
+		if output.From.Version == "" {
+			if len(versions) == 0 {
+				return DiffConversationsOutput{}, fmt.Errorf("%s has no previous versions to compare with", input.Path)
+			}
+			output.From.Version = versions[0].ID
+		}
+		if output.From.Version == output.To.Version {
+			return DiffConversationsOutput{}, fmt.Errorf("from_version and to_version are both %q", cmp.Or(output.From.Version, "current"))
+		}
+	}
+
+	from, err := loadDiffSide(ctx, store, &output.From)
+	if err != nil {
+		return DiffConversationsOutput{}, err
+	}
+	to, err := loadDiffSide(ctx, store, &output.To)
+	if err != nil {
+		return DiffConversationsOutput{}, err
+	}
+
+	if output.Metadata, err = frontmatter.Compare(from.fm, to.fm); err != nil {
+		return DiffConversationsOutput{}, fmt.Errorf("failed to compare frontmatter: %w", err)
+	}
+
+	edits := diff.Lines(diff.SplitLines(string(from.body)), diff.SplitLines(string(to.body)))
+	output.Stats.LinesAdded, output.Stats.LinesRemoved = diff.Stats(edits)
+	output.Messages = diffMessages(from.messages, to.messages, contextLines)
+	for _, m := range output.Messages {
+		switch m.Status {
+		case MessageAdded:
+			output.Stats.MessagesAdded++
+		case MessageRemoved:
+			output.Stats.MessagesRemoved++
+		case MessageChanged:
+			output.Stats.MessagesChanged++
+		}
+	}
+	if mode == DiffModeUnified {
+		output.Messages = nil
+		output.Diff = diff.Unified(output.From.label(), output.To.label(), edits, contextLines)
+	}
+	output.Identical = len(output.Metadata) == 0 && output.Stats.LinesAdded == 0 && output.Stats.LinesRemoved == 0
+	return output, nil
+}
+
+// loadDiffSide reads and parses a side of a diff, filling in its title,
+// source and message count.
+func loadDiffSide(ctx context.Context, store *storage.Storage, side *DiffSide) (diffDocument, error) {
+	var content []byte
+	var err error
+	if side.Version != "" {
+		content, err = store.ReadVersion(ctx, side.Path, side.Version)
+	} else {
+		content, err = store.Read(ctx, side.Path)
+	}
+	if err != nil {
+		return diffDocument{}, fmt.Errorf("failed to read %s: %w", side.label(), err)
+	}
+	fm, body, err := frontmatter.Parse(content)
+	if err != nil {
+		return diffDocument{}, fmt.Errorf("failed to parse frontmatter of %s: %w", side.label(), err)
+	}
+	doc := diffDocument{fm: fm, body: body, messages: conversation.Messages(body)}
+	if fm != nil {
+		side.Title, side.Source = fm.Title, fm.Source
+	}
+	side.Messages = conversation.Count(doc.messages)
+	return doc, nil
+}
+
+// label names a side of a diff in diff headers.
+func (s DiffSide) label() string {
+	if s.Version != "" {
+		return s.Path + "@" + s.Version
+	}
+	return s.Path
+}
+
+// diffMessages aligns two lists of messages and returns those that were
+// added, removed or changed. Within a run of changes, a removed and an
+// added message by the same speaker at the same position are reported as
+// one changed message.
+func diffMessages(a, b []conversation.Message, contextLines int) []MessageChange {
+	key := func(m conversation.Message) string { return m.Speaker + "\x00" + m.Text }
+	aKeys, bKeys := make([]string, len(a)), make([]string, len(b))
+	for i, m := range a {
+		aKeys[i] = key(m)
+	}
+	for i, m := range b {
+		bKeys[i] = key(m)
+	}
+	number := func(messages []conversation.Message, i int) int {
+		if len(messages) > 0 && messages[0].Speaker == "" {
+			return i
+		}
+		return i + 1
+	}
+
+	var changes []MessageChange
+	var removed, added []int
+	flush := func() {
+		for i := range max(len(removed), len(added)) {
+			switch {
+			case i < len(removed) && i < len(added) && a[removed[i]].Speaker == b[added[i]].Speaker:
+				from, to := a[removed[i]], b[added[i]]
+				lines := diff.Lines(diff.SplitLines(from.Text), diff.SplitLines(to.Text))
+				changes = append(changes, MessageChange{
+					Status:  MessageChanged,
+					From:    number(a, removed[i]),
+					To:      number(b, added[i]),
+					Speaker: to.Speaker,
+					Diff:    diff.Unified(fmt.Sprintf("message %d", number(a, removed[i])), fmt.Sprintf("message %d", number(b, added[i])), lines, contextLines),
+				})
+			default:
+				if i < len(removed) {
+					m := a[removed[i]]
+					changes = append(changes, MessageChange{Status: MessageRemoved, From: number(a, removed[i]), Speaker: m.Speaker, Text: m.Text})
+				}
+				if i < len(added) {
+					m := b[added[i]]
+					changes = append(changes, MessageChange{Status: MessageAdded, To: number(b, added[i]), Speaker: m.Speaker, Text: m.Text})
+				}
+			}
+		}
+		removed, added = removed[:0], added[:0]
+	}
+	for _, e := range diff.Lines(aKeys, bKeys) {
+		switch e.Kind {
+		case diff.Delete:
+			removed = append(removed, e.A)
+		case diff.Insert:
+			added = append(added, e.B)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return changes
+}
@@ -13,18 +13,25 @@ const defaultListLimit = 50
 
 // ListConversations lists conversations with optional filtering.
 func ListConversations(ctx context.Context, store *storage.Storage, input ListConversationsInput) (ListConversationsOutput, error) {
+	input.Source = frontmatter.CanonicalSource(input.Source)
+
 	// Set default limit
 	limit := input.Limit
 	if limit <= 0 {
 		limit = defaultListLimit
 	}
 
+	if q := store.Querier(); q != nil {
+		return queryConversations(ctx, store, q, input, limit)
+	}
+
 	// Get file list
 	var files []string
+	var skipped []SkippedFile
 	var err error
 
 	if input.Source != "" {
-		files, err = store.ListBySource(ctx, input.Source)
+		files, skipped, err = store.ListBySource(ctx, input.Source)
 	} else {
 		files, err = store.ListConversations(ctx)
 	}
@@ -70,6 +77,7 @@ func ListConversations(ctx context.Context, store *storage.Storage, input ListCo
 		Conversations: conversations,
 		Total:         total,
 		HasMore:       hasMore,
+		Skipped:       skipped,
 	}, nil
 }
 
@@ -100,5 +108,50 @@ func getConversationSummary(ctx context.Context, store *storage.Storage, filePat
 		Source:      fm.Source,
 		Tags:        fm.Tags,
 		Description: fm.Description,
+		Model:       fm.Model,
+		Tokens:      fm.Tokens,
+		Cost:        fm.Cost,
 	}, nil
 }
+
+// queryConversations lists conversations with a backend query instead of
+// reading every file.
+func queryConversations(ctx context.Context, store *storage.Storage, q storage.Querier, input ListConversationsInput, limit int) (ListConversationsOutput, error) {
+	records, total, err := q.QueryConversations(ctx, storage.ConversationQuery{
+		Folder: store.Folder(),
+		Source: input.Source,
+		Limit:  limit,
+		Offset: input.Offset,
+	})
+	if err != nil {
+		return ListConversationsOutput{}, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	conversations := make([]ConversationSummary, 0, len(records))
+	for _, r := range records {
+		conversations = append(conversations, recordSummary(r))
+	}
+
+	return ListConversationsOutput{
+		Conversations: conversations,
+		Total:         total,
+		HasMore:       max(input.Offset, 0)+len(records) < total,
+	}, nil
+}
+
+func recordSummary(r storage.ConversationRecord) ConversationSummary {
+	summary := ConversationSummary{
+		Path:        r.Path,
+		Title:       r.Title,
+		Source:      r.Source,
+		Tags:        r.Tags,
+		Description: r.Description,
+		Model:       r.Model,
+		Tokens:      r.Tokens,
+		Cost:        r.Cost,
+	}
+	if !r.Date.IsZero() {
+		summary.Date = r.Date.Format("2006-01-02")
+	}
+	return summary
+}
@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/tokens"
+)
+
+// Merge orders
+const (
+	MergeOrderChronological = "chronological" // by date, oldest first
+	MergeOrderGiven         = "given"         // as the paths are listed
+)
+
+// MergeConversationsInput is the input for the merge_conversations tool.
+type MergeConversationsInput struct {
+	Paths          []string `json:"paths" jsonschema:"Paths of the conversations to merge, at least two"`
+	Order          string   `json:"order,omitempty" jsonschema:"chronological (default) orders by date; given keeps the order of paths"`
+	Title          string   `json:"title,omitempty" jsonschema:"Title of the merged conversation (default: title of the first)"`
+	Source         string   `json:"source,omitempty" jsonschema:"Source of the merged conversation (default: source of the first)"`
+	Description    string   `json:"description,omitempty" jsonschema:"Description of the merged conversation (default: summarized)"`
+	TrashOriginals bool     `json:"trash_originals,omitempty" jsonschema:"Move the merged conversations to the trash, adding their URLs as aliases"`
+	DryRun         bool     `json:"dry_run,omitempty" jsonschema:"Return the merged path and metadata without saving"`
+}
+
+// MergeConversationsOutput is the output for the merge_conversations tool.
+type MergeConversationsOutput struct {
+	Path           string        `json:"path"`
+	ConversationID string        `json:"conversation_id"`
+	Merged         []string      `json:"merged" jsonschema:"Paths of the merged conversations, in the order their bodies appear"`
+	MergedFrom     []string      `json:"merged_from,omitempty" jsonschema:"Conversation IDs recorded in merged_from"`
+	Tags           []string      `json:"tags,omitempty"`
+	Categories     []string      `json:"categories,omitempty"`
+	Tokens         *tokens.Usage `json:"tokens,omitempty"`
+	Trashed        []string      `json:"trashed,omitempty" jsonschema:"Trash IDs of the originals, for restore_conversation"`
+	Saved          bool          `json:"saved"`
+	Queued         *QueuedWrite  `json:"queued,omitempty" jsonschema:"Set if the backend was unavailable and the write was queued for delivery"`
+}
+
+// mergePart is a conversation being merged.
+type mergePart struct {
+	path    string
+	content []byte
+	fm      *frontmatter.Frontmatter
+	body    []byte
+}
+
+// MergeConversations combines conversations into a new one. Each body is
+// introduced by a heading naming its source, date and title; tags,
+// categories and participants are unioned, and the IDs of the originals
+// are recorded in merged_from. If the originals are to be trashed or the
+// merged path exists, deps.Confirm must approve first. The conversation is
+// only summarized, with deps.Summarize, once it is to be saved, not for a
+// dry run.
+func MergeConversations(ctx context.Context, store *storage.Storage, input MergeConversationsInput, deps Deps) (MergeConversationsOutput, error) {
+	if len(input.Paths) < 2 {
+		return MergeConversationsOutput{}, errors.New("at least two paths are required")
+	}
+	order := cmp.Or(strings.ToLower(input.Order), MergeOrderChronological)
+	if order != MergeOrderChronological && order != MergeOrderGiven {
+		return MergeConversationsOutput{}, fmt.Errorf("invalid order %q: must be %s or %s", input.Order, MergeOrderChronological, MergeOrderGiven)
+	}
+
+	var parts []mergePart
+	for _, p := range input.Paths {
+		if slices.ContainsFunc(parts, func(part mergePart) bool { return part.path == p }) {
+			return MergeConversationsOutput{}, fmt.Errorf("%s is listed twice", p)
+		}
+		content, err := store.Read(ctx, p)
+		if err != nil {
+			return MergeConversationsOutput{}, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		fm, body, err := frontmatter.Parse(content)
+		if err != nil {
+			return MergeConversationsOutput{}, fmt.Errorf("failed to parse frontmatter of %s: %w", p, err)
+		}
+		if fm == nil {
+			fm = &frontmatter.Frontmatter{}
+		}
+		parts = append(parts, mergePart{path: p, content: content, fm: fm, body: body})
+	}
+	if order == MergeOrderChronological {
+		slices.SortStableFunc(parts, func(a, b mergePart) int { return a.fm.Date.Compare(b.fm.Date) })
+	}
+
+	first := parts[0].fm
+	title := cmp.Or(input.Title, first.Title)
+	source, ok := frontmatter.ResolveSource(cmp.Or(input.Source, first.Source))
+	if !ok {
+		return MergeConversationsOutput{}, fmt.Errorf("invalid source: %s (known sources: %s)", cmp.Or(input.Source, first.Source), strings.Join(frontmatter.SourceIDs(), ", "))
+	}
+
+	fm := frontmatter.New(title, source.ID)
+	fm.Format = store.FrontmatterFormat()
+	fm.Date = first.Date
+	if fm.Date.IsZero() {
+		fm.Date = fm.LastMod
+	}
+	fm.Model = first.Model
+	var body bytes.Buffer
+	output := MergeConversationsOutput{}
+	for _, part := range parts {
+		fm.Tags = union(fm.Tags, part.fm.Tags)
+		fm.Categories = union(fm.Categories, part.fm.Categories)
+		fm.Participants = union(fm.Participants, part.fm.Participants)
+		if part.fm.Model != fm.Model {
+			fm.Model = ""
+		}
+		if part.fm.ConversationID != "" {
+			fm.MergedFrom = union(fm.MergedFrom, []string{part.fm.ConversationID})
+		}
+		if input.TrashOriginals {
+			fm.Aliases = union(fm.Aliases, append(slices.Clone(part.fm.Aliases), storage.HugoURL(part.path, part.fm)))
+		}
+		writeMergeSection(&body, part)
+		output.Merged = append(output.Merged, part.path)
+	}
+	content := body.Bytes()
+	output.Tokens = countTokens(fm, content)
+
+	filePath := store.PathFor(fm)
+	if slices.Contains(input.Paths, filePath) && !input.TrashOriginals {
+		// Keep the originals: the merge would otherwise replace the first
+		fm.Slug += "-merged"
+		filePath = store.PathFor(fm)
+	}
+	fm.Aliases = slices.DeleteFunc(fm.Aliases, func(alias string) bool { return alias == storage.HugoURL(filePath, fm) })
+	output.Path = filePath
+	output.ConversationID = fm.ConversationID
+	output.MergedFrom = fm.MergedFrom
+	output.Tags = fm.Tags
+	output.Categories = fm.Categories
+	if input.DryRun {
+		return output, nil
+	}
+
+	// Confirm before overwriting a conversation or trashing the originals
+	exists, err := store.Exists(ctx, filePath)
+	if err != nil {
+		return MergeConversationsOutput{}, fmt.Errorf("failed to check existence: %w", err)
+	}
+	var request ConfirmRequest
+	switch {
+	case exists && input.TrashOriginals:
+		request.Action = "Overwrite existing conversation and move the merged conversations to trash"
+	case exists:
+		request.Action = "Overwrite existing conversation"
+	case input.TrashOriginals:
+		request.Action = "Move the merged conversations to trash"
+	}
+	if exists && !slices.Contains(input.Paths, filePath) {
+		request.Items = append(request.Items, describeConversation(ctx, store, filePath))
+	}
+	if input.TrashOriginals {
+		for _, part := range parts {
+			request.Items = append(request.Items, describeConversation(ctx, store, part.path))
+		}
+	}
+	if request.Action != "" {
+		confirmation, err := confirm(ctx, deps.Confirm, request)
+		if err != nil {
+			return MergeConversationsOutput{}, err
+		}
+		if !confirmation.Approved {
+			return MergeConversationsOutput{}, fmt.Errorf("not merged: %s", confirmation.Reason)
+		}
+	}
+
+	switch {
+	case input.Description != "":
+		fm.Description = input.Description
+	case deps.Summarize != nil:
+		fm.Description = deps.Summarize(ctx, title, content).Description
+	}
+	if fm.Description == "" {
+		fm.Description = frontmatter.ExtractDescription(content, 150)
+	}
+
+	rendered, err := fm.RenderWithContent(content)
+	if err != nil {
+		return MergeConversationsOutput{}, fmt.Errorf("failed to render frontmatter: %w", err)
+	}
+
+	// The merge and the trashing of the originals are committed together.
+	// An original at the merged path is trashed before it is replaced.
+	batch := store.Begin("Merge conversations into " + store.RelPath(filePath))
+	var trashed []string
+	if input.TrashOriginals {
+		for _, part := range parts {
+			item, err := batch.Trash(ctx, part.path, part.content)
+			if err != nil {
+				return MergeConversationsOutput{}, err
+			}
+			trashed = append(trashed, item.ID)
+		}
+	}
+	batch.Put(filePath, rendered)
+	if output.Queued, err = queuedWrite(batch.Commit(ctx)); err != nil {
+		return MergeConversationsOutput{}, fmt.Errorf("failed to save merged conversation: %w", err)
+	}
+	output.Saved = true
+	output.Trashed = trashed
+	return output, nil
+}
+
+// writeMergeSection writes a merged conversation's body under a heading
+// naming its source, date and title. A leading title heading in the body
+// is dropped, as the section heading replaces it.
+func writeMergeSection(w *bytes.Buffer, part mergePart) {
+	label := part.fm.Source
+	if info, ok := frontmatter.LookupSource(part.fm.Source); ok {
+		label = info.Name
+	}
+	heading := cmp.Or(label, "Unknown source")
+	if !part.fm.Date.IsZero() {
+		heading += ", " + part.fm.Date.Format(time.DateOnly)
+	}
+	if part.fm.Title != "" {
+		heading += ": " + part.fm.Title
+	}
+
+	body := bytes.TrimSpace(part.body)
+	if line, rest, _ := bytes.Cut(body, []byte("\n")); part.fm.Title != "" && string(bytes.TrimSpace(line)) == "# "+part.fm.Title {
+		body = bytes.TrimSpace(rest)
+	}
+
+	if w.Len() > 0 {
+		w.WriteString("\n")
+	}
+	fmt.Fprintf(w, "## %s\n\n", heading)
+	w.Write(body)
+	w.WriteString("\n")
+}
+
+// union returns list with the values not already in it appended.
+func union(list, values []string) []string {
+	for _, v := range values {
+		if v != "" && !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/summary"
+)
+
+// saveConversation saves a conversation at the path the storage layout
+// gives it and returns the path.
+func saveConversation(t *testing.T, store *storage.Storage, title, source, id string, date time.Time, body string) string {
+	t.Helper()
+	fm := frontmatter.New(title, source)
+	fm.Date, fm.ConversationID = date, id
+	content, err := fm.RenderWithContent([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := store.PathFor(fm)
+	if err := store.Save(context.Background(), filePath, content); err != nil {
+		t.Fatalf("Save(%s) error = %v", filePath, err)
+	}
+	return filePath
+}
+
+func approve(context.Context, ConfirmRequest) (Confirmation, error) {
+	return Confirmation{Approved: true}, nil
+}
+
+func TestMergeConversations(t *testing.T) {
+	ctx := context.Background()
+	store := storage.New(memory.New(), "conversations")
+	day := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	later := saveConversation(t, store, "Part two", "chatgpt", "conv-b", day.AddDate(0, 0, 1), "**User:** second\n")
+	first := saveConversation(t, store, "Part one", "claude", "conv-a", day, "# Part one\n\n**User:** first\n")
+
+	summarized := 0
+	summarize := func(ctx context.Context, title string, body []byte) summary.Summary {
+		summarized++
+		return summary.Summary{Description: "Summarized"}
+	}
+
+	// A dry run neither saves nor summarizes
+	dry, err := MergeConversations(ctx, store, MergeConversationsInput{Paths: []string{later, first}, DryRun: true}, Deps{Confirm: approve, Summarize: summarize})
+	if err != nil {
+		t.Fatalf("MergeConversations(dry run) error = %v", err)
+	}
+	if dry.Saved || summarized != 0 {
+		t.Errorf("dry run saved = %v and summarized %d times, want neither", dry.Saved, summarized)
+	}
+	if !slices.Equal(dry.Merged, []string{first, later}) || !slices.Equal(dry.MergedFrom, []string{"conv-a", "conv-b"}) {
+		t.Errorf("dry run merged = %v from %v, want chronological order", dry.Merged, dry.MergedFrom)
+	}
+	if exists, _ := store.Exists(ctx, dry.Path); exists {
+		t.Errorf("dry run wrote %s", dry.Path)
+	}
+
+	// The merged path is the first original's, which is trashed, not lost
+	output, err := MergeConversations(ctx, store, MergeConversationsInput{Paths: []string{later, first}, TrashOriginals: true}, Deps{Confirm: approve, Summarize: summarize})
+	if err != nil {
+		t.Fatalf("MergeConversations() error = %v", err)
+	}
+	if output.Path != first || !output.Saved || len(output.Trashed) != 2 || summarized != 1 {
+		t.Fatalf("MergeConversations() = %+v, want the first path reused, both originals trashed and one summary", output)
+	}
+	if exists, _ := store.Exists(ctx, later); exists {
+		t.Error("second original was not trashed")
+	}
+
+	content, err := store.Read(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm, body, err := frontmatter.Parse(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fm.MergedFrom, []string{"conv-a", "conv-b"}) || fm.Description != "Summarized" {
+		t.Errorf("merged frontmatter = %+v, want merged_from and the summary", fm)
+	}
+	wantBody := "\n## Claude, 2026-01-10: Part one\n\n**User:** first\n\n## ChatGPT, 2026-01-11: Part two\n\n**User:** second\n"
+	if string(body) != wantBody {
+		t.Errorf("merged body = %q, want %q", body, wantBody)
+	}
+
+	restored, err := store.Restore(ctx, output.Trashed[0], store.AbsPath("restored.md"))
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	original, err := store.Read(ctx, restored)
+	if err != nil || !strings.Contains(string(original), "title: Part one") || strings.Contains(string(original), "merged_from") {
+		t.Errorf("restored original = %q, %v; want the first conversation as it was", original, err)
+	}
+}
+
+func TestMergeConversationsKeepsOriginalAtMergedPath(t *testing.T) {
+	ctx := context.Background()
+	store := storage.New(memory.New(), "conversations")
+	day := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	first := saveConversation(t, store, "Part one", "claude", "conv-a", day, "**User:** first\n")
+	second := saveConversation(t, store, "Part two", "claude", "conv-b", day.AddDate(0, 0, 1), "**User:** second\n")
+
+	output, err := MergeConversations(ctx, store, MergeConversationsInput{Paths: []string{first, second}}, Deps{Confirm: approve})
+	if err != nil {
+		t.Fatalf("MergeConversations() error = %v", err)
+	}
+	if output.Path == first || output.Path == second {
+		t.Fatalf("merged path = %s, want a new path", output.Path)
+	}
+	for _, p := range []string{first, second, output.Path} {
+		if exists, _ := store.Exists(ctx, p); !exists {
+			t.Errorf("%s does not exist", p)
+		}
+	}
+}
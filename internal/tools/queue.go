@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// QueuedWrite acknowledges a write that was journaled to the outbox.
+type QueuedWrite struct {
+	ID     string `json:"id" jsonschema:"Outbox entry ID"`
+	Reason string `json:"reason,omitempty" jsonschema:"Why the backend could not accept the write"`
+}
+
+// queuedWrite converts a queued save into an acknowledgement. Other errors
+// are returned unchanged.
+func queuedWrite(err error) (*QueuedWrite, error) {
+	var qerr *storage.QueuedError
+	if !errors.As(err, &qerr) {
+		return nil, err
+	}
+	queued := &QueuedWrite{ID: qerr.ID}
+	if qerr.Err != nil {
+		queued.Reason = qerr.Err.Error()
+	} else {
+		queued.Reason = "earlier writes to this path are still queued"
+	}
+	return queued, nil
+}
+
+// QueueStatusInput is the input for the queue_status tool.
+type QueueStatusInput struct {
+	Retry bool `json:"retry,omitempty" jsonschema:"Retry delivering queued writes now instead of waiting for the next backoff"`
+}
+
+// QueueStatusOutput is the output for the queue_status tool.
+type QueueStatusOutput struct {
+	Dir     string                `json:"dir" jsonschema:"Local outbox directory"`
+	Pending []storage.OutboxEntry `json:"pending" jsonschema:"Writes awaiting delivery, oldest first"`
+	Failed  []storage.OutboxEntry `json:"failed,omitempty" jsonschema:"Writes the backend rejected permanently"`
+	Error   string                `json:"error,omitempty" jsonschema:"Error from the retry, if any"`
+}
+
+// QueueStatus reports writes waiting in the outbox and, optionally,
+// retries them.
+func QueueStatus(ctx context.Context, store *storage.Storage, input QueueStatusInput) (QueueStatusOutput, error) {
+	outbox := store.Outbox()
+	if outbox == nil {
+		return QueueStatusOutput{}, errors.New("the outbox is not configured")
+	}
+
+	var output QueueStatusOutput
+	if input.Retry {
+		outbox.Kick()
+		if err := outbox.Flush(ctx); err != nil {
+			output.Error = err.Error()
+		}
+	}
+
+	status := outbox.Status()
+	output.Dir = status.Dir
+	output.Pending = status.Pending
+	output.Failed = status.Failed
+	return output, nil
+}
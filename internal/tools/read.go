@@ -8,7 +8,9 @@ import (
 	"github.com/grokify/chathub/internal/storage"
 )
 
-// ReadConversation reads a conversation from storage.
+// ReadConversation reads a conversation from storage. With window options,
+// only part of the body is returned, so long conversations can be read
+// within a client's context limit.
 func ReadConversation(ctx context.Context, store *storage.Storage, input ReadConversationInput) (ReadConversationOutput, error) {
 	// Read from storage
 	content, err := store.Read(ctx, input.Path)
@@ -34,18 +36,38 @@ func ReadConversation(ctx context.Context, store *storage.Storage, input ReadCon
 		output.Tags = fm.Tags
 		output.Description = fm.Description
 
-		output.Metadata = map[string]string{
-			"conversation_id": fm.ConversationID,
-			"author":          fm.Author,
-			"slug":            fm.Slug,
+		// Custom fields first so they cannot shadow the standard ones
+		output.Metadata = fm.Metadata()
+		if output.Metadata == nil {
+			output.Metadata = make(map[string]any)
 		}
+		output.Metadata["conversation_id"] = fm.ConversationID
+		output.Metadata["author"] = fm.Author
+		output.Metadata["slug"] = fm.Slug
 		if fm.Model != "" {
 			output.Metadata["model"] = fm.Model
 		}
+		if fm.Tokens > 0 {
+			output.Metadata["tokens"] = fm.Tokens
+			output.Metadata["tokenizer"] = fm.Tokenizer
+		}
+		if fm.Cost > 0 {
+			output.Metadata["cost"] = fm.Cost
+		}
 	} else {
 		// No frontmatter - just return content
 		output.Content = string(body)
 	}
 
+	if input.windowed() {
+		model := ""
+		if fm != nil {
+			model = fm.Model
+		}
+		if err := readWindow(&output, body, model, input); err != nil {
+			return ReadConversationOutput{}, err
+		}
+	}
+
 	return output, nil
 }
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/grokify/chathub/internal/redact"
+)
+
+// RedactionFinding records sensitive content found in a tool input field.
+type RedactionFinding struct {
+	Field    string `json:"field" jsonschema:"Input field containing the finding"`
+	Detector string `json:"detector" jsonschema:"Detector that matched, e.g. aws_access_key"`
+	Line     int    `json:"line" jsonschema:"Line number within the field"`
+}
+
+// RedactionReport describes sensitive content found while saving.
+type RedactionReport struct {
+	Mode     string             `json:"mode" jsonschema:"Redaction mode: warn (kept) or redact (replaced)"`
+	Findings []RedactionFinding `json:"findings"`
+}
+
+// redactor applies a redact.Redactor to several input fields and
+// accumulates a single report.
+type redactor struct {
+	r      *redact.Redactor
+	report RedactionReport
+}
+
+func newRedactor(r *redact.Redactor) *redactor {
+	return &redactor{
+		r:      r,
+		report: RedactionReport{Mode: string(r.Mode())},
+	}
+}
+
+// apply redacts one field, returning an error if the content is blocked.
+func (rd *redactor) apply(field, content string) (string, error) {
+	out, report, err := rd.r.Apply(content)
+	for _, f := range report.Findings {
+		rd.report.Findings = append(rd.report.Findings, RedactionFinding{
+			Field:    field,
+			Detector: f.Detector,
+			Line:     f.Line,
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	return out, nil
+}
+
+// applyEach redacts each value of a list field.
+func (rd *redactor) applyEach(field string, values []string) ([]string, error) {
+	if len(values) == 0 {
+		return values, nil
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		redacted, err := rd.apply(field, v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, redacted)
+	}
+	return out, nil
+}
+
+// result returns the accumulated report, or nil if nothing was found.
+func (rd *redactor) result() *RedactionReport {
+	if len(rd.report.Findings) == 0 {
+		return nil
+	}
+	return &rd.report
+}
@@ -6,24 +6,71 @@ import (
 	"github.com/agentplexus/mcpkit/runtime"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/grokify/chathub/internal/redact"
+	"github.com/grokify/chathub/internal/semantic"
 	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/summary"
 )
 
+// Options configures tool behavior.
+type Options struct {
+	// ConfirmPolicy decides whether destructive operations proceed when the
+	// client does not support elicitation (config.ConfirmPolicyAllow or
+	// config.ConfirmPolicyDeny).
+	ConfirmPolicy string
+
+	// Redactor scans saved and appended content for secrets and personal
+	// data. A nil Redactor disables redaction.
+	Redactor *redact.Redactor
+
+	// Summarize decides how conversations saved without a description are
+	// summarized.
+	Summarize summary.Mode
+
+	// Semantic is the vector index of the semantic_search tool. A nil
+	// Semantic leaves the tool out.
+	Semantic *semantic.Index
+}
+
+// Deps are the optional collaborators of the tools that write
+// conversations. A nil field leaves out what it provides.
+type Deps struct {
+	// Confirm approves overwriting or trashing conversations. A nil
+	// Confirm approves everything.
+	Confirm ConfirmFunc
+
+	// Redactor scans written content for secrets and personal data.
+	Redactor *redact.Redactor
+
+	// Summarize describes conversations saved without a description. A
+	// nil Summarize uses the first line of the content.
+	Summarize SummarizeFunc
+}
+
+// deps returns the collaborators of a tool call in session.
+func (o Options) deps(session *mcp.ServerSession) Deps {
+	return Deps{
+		Confirm:   NewSessionConfirmer(session, o.ConfirmPolicy),
+		Redactor:  o.Redactor,
+		Summarize: NewSessionSummarizer(session, o.Summarize),
+	}
+}
+
 // RegisterAll registers all ChatHub tools with the MCP runtime.
-func RegisterAll(rt *runtime.Runtime, store *storage.Storage) {
+func RegisterAll(rt *runtime.Runtime, store *storage.Storage, opts Options) {
 	// save_conversation
 	runtime.AddTool[SaveConversationInput, SaveConversationOutput](rt, &mcp.Tool{
 		Name:        "save_conversation",
 		Description: "Save an AI conversation to storage with Hugo-compatible frontmatter",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input SaveConversationInput) (*mcp.CallToolResult, SaveConversationOutput, error) {
-		output, err := SaveConversation(ctx, store, input)
+		output, err := SaveConversation(ctx, store, input, opts.deps(req.Session))
 		return nil, output, err
 	})
 
 	// read_conversation
 	runtime.AddTool[ReadConversationInput, ReadConversationOutput](rt, &mcp.Tool{
 		Name:        "read_conversation",
-		Description: "Read a conversation from storage, returning content and metadata",
+		Description: "Read a conversation from storage, returning content and metadata. For long conversations, read a range of messages, the last N messages, or an outline, and limit the size with max_bytes or max_tokens, continuing with next_cursor",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input ReadConversationInput) (*mcp.CallToolResult, ReadConversationOutput, error) {
 		output, err := ReadConversation(ctx, store, input)
 		return nil, output, err
@@ -47,12 +94,82 @@ func RegisterAll(rt *runtime.Runtime, store *storage.Storage) {
 		return nil, output, err
 	})
 
+	// semantic_search
+	if opts.Semantic != nil {
+		runtime.AddTool[SemanticSearchInput, SemanticSearchOutput](rt, &mcp.Tool{
+			Name:        "semantic_search",
+			Description: "Search conversations by meaning rather than exact words, e.g. \"auth bug\" can also find \"login failure\". Ranks each conversation by its best-matching message, combining embedding similarity with keyword scores",
+		}, func(ctx context.Context, req *mcp.CallToolRequest, input SemanticSearchInput) (*mcp.CallToolResult, SemanticSearchOutput, error) {
+			output, err := SemanticSearch(ctx, store, opts.Semantic, input)
+			return nil, output, err
+		})
+	}
+
+	// find_similar
+	runtime.AddTool[FindSimilarInput, FindSimilarOutput](rt, &mcp.Tool{
+		Name:        "find_similar",
+		Description: "Find the conversations most similar to a stored one, flagging near-duplicates such as the same chat saved from two clients",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input FindSimilarInput) (*mcp.CallToolResult, FindSimilarOutput, error) {
+		output, err := FindSimilar(ctx, store, opts.Semantic, input)
+		return nil, output, err
+	})
+
+	// merge_conversations
+	runtime.AddTool[MergeConversationsInput, MergeConversationsOutput](rt, &mcp.Tool{
+		Name:        "merge_conversations",
+		Description: "Merge conversations into one, in chronological or given order, with a heading naming the source of each part. Tags, categories and participants are combined and the original IDs recorded in merged_from; the originals can be moved to the trash",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input MergeConversationsInput) (*mcp.CallToolResult, MergeConversationsOutput, error) {
+		output, err := MergeConversations(ctx, store, input, opts.deps(req.Session))
+		return nil, output, err
+	})
+
+	// diff_conversations
+	runtime.AddTool[DiffConversationsInput, DiffConversationsOutput](rt, &mcp.Tool{
+		Name:        "diff_conversations",
+		Description: "Compare two conversations, or a conversation with a previous version of itself, as a unified diff or aligned by message. Frontmatter changes are listed separately from body changes",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input DiffConversationsInput) (*mcp.CallToolResult, DiffConversationsOutput, error) {
+		output, err := DiffConversations(ctx, store, input)
+		return nil, output, err
+	})
+
+	// conversation_stats
+	runtime.AddTool[ConversationStatsInput, ConversationStatsOutput](rt, &mcp.Tool{
+		Name:        "conversation_stats",
+		Description: "Report how many conversations there are by source, model, tag, category and month, with message and token totals, top tags and average conversation length",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input ConversationStatsInput) (*mcp.CallToolResult, ConversationStatsOutput, error) {
+		output, err := ConversationStats(ctx, store, input)
+		return nil, output, err
+	})
+
+	// summarize_conversation
+	runtime.AddTool[SummarizeConversationInput, SummarizeConversationOutput](rt, &mcp.Tool{
+		Name:        "summarize_conversation",
+		Description: "Write a concise description and suggested tags for a conversation into its frontmatter, using the client's model via sampling when available",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input SummarizeConversationInput) (*mcp.CallToolResult, SummarizeConversationOutput, error) {
+		// Asked for explicitly, so only sampling is subject to the mode
+		mode := opts.Summarize
+		if input.Extractive || mode == summary.ModeOff {
+			mode = summary.ModeExtractive
+		}
+		output, err := SummarizeConversation(ctx, store, input, NewSessionSummarizer(req.Session, mode))
+		return nil, output, err
+	})
+
+	// validate_conversation
+	runtime.AddTool[ValidateConversationInput, ValidateConversationOutput](rt, &mcp.Tool{
+		Name:        "validate_conversation",
+		Description: "Check conversation frontmatter for missing titles, invalid dates, unknown sources, duplicate IDs and values Hugo cannot use",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input ValidateConversationInput) (*mcp.CallToolResult, ValidateConversationOutput, error) {
+		output, err := ValidateConversation(ctx, store, input)
+		return nil, output, err
+	})
+
 	// delete_conversation
 	runtime.AddTool[DeleteConversationInput, DeleteConversationOutput](rt, &mcp.Tool{
 		Name:        "delete_conversation",
-		Description: "Delete a conversation from storage",
+		Description: "Move a conversation to the trash; it can be restored with restore_conversation until the retention period expires",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input DeleteConversationInput) (*mcp.CallToolResult, DeleteConversationOutput, error) {
-		output, err := DeleteConversation(ctx, store, input)
+		output, err := DeleteConversation(ctx, store, input, NewSessionConfirmer(req.Session, opts.ConfirmPolicy))
 		return nil, output, err
 	})
 
@@ -61,7 +178,56 @@ func RegisterAll(rt *runtime.Runtime, store *storage.Storage) {
 		Name:        "append_conversation",
 		Description: "Append content to an existing conversation",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input AppendConversationInput) (*mcp.CallToolResult, AppendConversationOutput, error) {
-		output, err := AppendConversation(ctx, store, input)
+		output, err := AppendConversation(ctx, store, input, opts.deps(req.Session))
+		return nil, output, err
+	})
+
+	// list_trash
+	runtime.AddTool[ListTrashInput, ListTrashOutput](rt, &mcp.Tool{
+		Name:        "list_trash",
+		Description: "List deleted conversations in the trash with their deletion and expiry dates",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input ListTrashInput) (*mcp.CallToolResult, ListTrashOutput, error) {
+		output, err := ListTrash(ctx, store, input)
+		return nil, output, err
+	})
+
+	// restore_conversation
+	runtime.AddTool[RestoreConversationInput, RestoreConversationOutput](rt, &mcp.Tool{
+		Name:        "restore_conversation",
+		Description: "Restore a deleted conversation from the trash",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input RestoreConversationInput) (*mcp.CallToolResult, RestoreConversationOutput, error) {
+		output, err := RestoreConversation(ctx, store, input)
 		return nil, output, err
 	})
+
+	// empty_trash
+	runtime.AddTool[EmptyTrashInput, EmptyTrashOutput](rt, &mcp.Tool{
+		Name:        "empty_trash",
+		Description: "Permanently delete conversations from the trash",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input EmptyTrashInput) (*mcp.CallToolResult, EmptyTrashOutput, error) {
+		output, err := EmptyTrash(ctx, store, input, NewSessionConfirmer(req.Session, opts.ConfirmPolicy))
+		return nil, output, err
+	})
+
+	// replication_status
+	if store.Replication() != nil {
+		runtime.AddTool[ReplicationStatusInput, ReplicationStatusOutput](rt, &mcp.Tool{
+			Name:        "replication_status",
+			Description: "Report health, pending retries, and divergence of replicated storage backends",
+		}, func(ctx context.Context, req *mcp.CallToolRequest, input ReplicationStatusInput) (*mcp.CallToolResult, ReplicationStatusOutput, error) {
+			output, err := ReplicationStatus(ctx, store, input)
+			return nil, output, err
+		})
+	}
+
+	// queue_status
+	if store.Outbox() != nil {
+		runtime.AddTool[QueueStatusInput, QueueStatusOutput](rt, &mcp.Tool{
+			Name:        "queue_status",
+			Description: "List writes queued in the local outbox while the storage backend is unavailable, optionally retrying them",
+		}, func(ctx context.Context, req *mcp.CallToolRequest, input QueueStatusInput) (*mcp.CallToolResult, QueueStatusOutput, error) {
+			output, err := QueueStatus(ctx, store, input)
+			return nil, output, err
+		})
+	}
 }
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// ReplicationStatusInput is the input for the replication_status tool.
+type ReplicationStatusInput struct {
+	CheckDivergence bool `json:"check_divergence,omitempty" jsonschema:"Compare content across all backends (reads every file)"`
+}
+
+// BackendStatus reports the state of one replicated backend.
+type BackendStatus struct {
+	Name      string   `json:"name"`
+	Primary   bool     `json:"primary"`
+	Healthy   bool     `json:"healthy"`
+	LatencyMS int64    `json:"latency_ms" jsonschema:"Average operation latency in milliseconds"`
+	Pending   []string `json:"pending,omitempty" jsonschema:"Paths awaiting retry"`
+	Failed    []string `json:"failed,omitempty" jsonschema:"Paths whose retries were exhausted"`
+	LastError string   `json:"last_error,omitempty"`
+}
+
+// ReplicationStatusOutput is the output for the replication_status tool.
+type ReplicationStatusOutput struct {
+	Backends   []BackendStatus      `json:"backends"`
+	Divergence []storage.Divergence `json:"divergence,omitempty" jsonschema:"Paths that differ between backends"`
+}
+
+// ReplicationStatus reports replica health and, optionally, divergence.
+func ReplicationStatus(ctx context.Context, store *storage.Storage, input ReplicationStatusInput) (ReplicationStatusOutput, error) {
+	rb := store.Replication()
+	if rb == nil {
+		return ReplicationStatusOutput{}, errors.New("replication is not configured")
+	}
+
+	var output ReplicationStatusOutput
+	for _, s := range rb.Status() {
+		output.Backends = append(output.Backends, BackendStatus{
+			Name:      s.Name,
+			Primary:   s.Primary,
+			Healthy:   s.Healthy,
+			LatencyMS: s.Latency.Milliseconds(),
+			Pending:   s.Pending,
+			Failed:    s.Failed,
+			LastError: s.LastError,
+		})
+	}
+
+	if input.CheckDivergence {
+		divergence, err := rb.Divergence(ctx, store.Folder())
+		if err != nil {
+			return ReplicationStatusOutput{}, fmt.Errorf("failed to check divergence: %w", err)
+		}
+		output.Divergence = divergence
+	}
+
+	return output, nil
+}
@@ -3,33 +3,84 @@ package tools
 import (
 	"context"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/grokify/chathub/internal/frontmatter"
 	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/summary"
 )
 
 // SaveConversation saves an AI conversation to storage.
-func SaveConversation(ctx context.Context, store *storage.Storage, input SaveConversationInput) (SaveConversationOutput, error) {
-	// Validate source
-	if !frontmatter.ValidSource(input.Source) {
-		return SaveConversationOutput{}, fmt.Errorf("invalid source: %s", input.Source)
+// If the target path already exists, deps.Confirm must approve overwriting
+// it first. Content, description, title, tags and categories are passed
+// through deps.Redactor before the path is built from them. Without a
+// description, deps.Summarize writes one and suggests tags if none are
+// given, once any overwrite is approved.
+func SaveConversation(ctx context.Context, store *storage.Storage, input SaveConversationInput, deps Deps) (SaveConversationOutput, error) {
+	// Validate source, accepting display names such as "Claude Code"
+	source, ok := frontmatter.ResolveSource(input.Source)
+	if !ok {
+		return SaveConversationOutput{}, fmt.Errorf("invalid source: %s (known sources: %s)", input.Source, strings.Join(frontmatter.SourceIDs(), ", "))
+	}
+	input.Source = source.ID
+
+	// Redact sensitive content
+	rd := newRedactor(deps.Redactor)
+	var err error
+	if input.Content, err = rd.apply("content", input.Content); err != nil {
+		return SaveConversationOutput{}, err
+	}
+	if input.Description, err = rd.apply("description", input.Description); err != nil {
+		return SaveConversationOutput{}, err
+	}
+	if input.Title, err = rd.apply("title", input.Title); err != nil {
+		return SaveConversationOutput{}, err
+	}
+	if input.Tags, err = rd.applyEach("tags", input.Tags); err != nil {
+		return SaveConversationOutput{}, err
+	}
+	if input.Categories, err = rd.applyEach("categories", input.Categories); err != nil {
+		return SaveConversationOutput{}, err
 	}
 
 	// Create frontmatter
 	fm := frontmatter.New(input.Title, input.Source)
 	fm.Tags = input.Tags
 	fm.Categories = input.Categories
+	fm.Model = input.Model
+	fm.Format = store.FrontmatterFormat()
+	usage := countTokens(fm, []byte(input.Content))
+
+	// Confirm before overwriting, so a declined save is not summarized
+	filePath := store.PathFor(fm)
+	if err := confirmOverwrite(ctx, store, deps.Confirm, filePath); err != nil {
+		return SaveConversationOutput{}, err
+	}
 
-	// Set description (use provided or extract from content)
-	if input.Description != "" {
+	// Set description (use provided, or summarize or extract from content)
+	var sum *summary.Summary
+	switch {
+	case input.Description != "":
 		fm.Description = input.Description
-	} else {
+	case deps.Summarize != nil:
+		s := deps.Summarize(ctx, input.Title, []byte(input.Content))
+		sum = &s
+		fm.Description = s.Description
+		if len(fm.Tags) == 0 {
+			fm.Tags = s.Tags
+		}
+	}
+	if fm.Description == "" {
 		fm.Description = frontmatter.ExtractDescription([]byte(input.Content), 150)
 	}
 
-	// Generate file path
-	filePath := frontmatter.GeneratePath(store.Folder(), input.Source, input.Title, time.Now().UTC())
+	// Suggested tags move the file under a layout with {tag}
+	if tagged := store.PathFor(fm); tagged != filePath {
+		filePath = tagged
+		if err := confirmOverwrite(ctx, store, deps.Confirm, filePath); err != nil {
+			return SaveConversationOutput{}, err
+		}
+	}
 
 	// Render complete document
 	content, err := fm.RenderWithContent([]byte(input.Content))
@@ -38,12 +89,44 @@ func SaveConversation(ctx context.Context, store *storage.Storage, input SaveCon
 	}
 
 	// Save to storage
-	if err := store.Save(ctx, filePath, content); err != nil {
+	queued, err := queuedWrite(store.SaveVersioned(ctx, filePath, content))
+	if err != nil {
 		return SaveConversationOutput{}, fmt.Errorf("failed to save conversation: %w", err)
 	}
 
 	return SaveConversationOutput{
 		Path:           filePath,
 		ConversationID: fm.ConversationID,
+		Tokens:         usage,
+		Summary:        sum,
+		Redaction:      rd.result(),
+		Queued:         queued,
 	}, nil
 }
+
+// confirmOverwrite asks confirmFn to approve overwriting filePath if it
+// exists, returning an error unless it does.
+func confirmOverwrite(ctx context.Context, store *storage.Storage, confirmFn ConfirmFunc, filePath string) error {
+	exists, err := store.Exists(ctx, filePath)
+	if err != nil && store.Outbox() != nil && storage.IsTransient(err) {
+		// The backend is unreachable; the write will be queued
+		exists, err = false, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check existence: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	confirmation, err := confirm(ctx, confirmFn, ConfirmRequest{
+		Action: "Overwrite existing conversation",
+		Items:  []ConfirmItem{describeConversation(ctx, store, filePath)},
+	})
+	if err != nil {
+		return err
+	}
+	if !confirmation.Approved {
+		return fmt.Errorf("not overwriting %s: %s", filePath, confirmation.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/redact"
+	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/summary"
+)
+
+func TestSaveConversationConfirmsBeforeSummarizing(t *testing.T) {
+	ctx := context.Background()
+	store := storage.New(memory.New(), "conversations")
+	input := SaveConversationInput{Title: "Key rotation", Source: "claude", Content: "**User:** How do I rotate keys?\n"}
+
+	summarized := 0
+	summarize := func(ctx context.Context, title string, body []byte) summary.Summary {
+		summarized++
+		return summary.Summary{Description: "About key rotation", Tags: []string{"keys"}}
+	}
+	first, err := SaveConversation(ctx, store, input, Deps{Confirm: approve, Summarize: summarize})
+	if err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+
+	decline := func(context.Context, ConfirmRequest) (Confirmation, error) {
+		return Confirmation{Reason: "declined by user"}, nil
+	}
+	if _, err := SaveConversation(ctx, store, input, Deps{Confirm: decline, Summarize: summarize}); err == nil {
+		t.Fatalf("SaveConversation() over %s succeeded without approval", first.Path)
+	}
+	if summarized != 1 {
+		t.Errorf("summarized %d times, want only the first save summarized", summarized)
+	}
+}
+
+func TestSaveConversationRedactsPathFields(t *testing.T) {
+	ctx := context.Background()
+	store := storage.New(memory.New(), "conversations", storage.WithLayout(frontmatter.MustParseLayout("{tag}/{slug}.md")))
+	detector, err := redact.NewPatternDetector("internal_host", `\b[a-z0-9-]+\.corp\.example\.com\b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor := redact.New(redact.ModeRedact, detector)
+
+	input := SaveConversationInput{
+		Title:       "Debugging db1.corp.example.com",
+		Source:      "claude",
+		Content:     "**User:** Why is it slow?\n",
+		Description: "Slow queries",
+		Tags:        []string{"build7.corp.example.com"},
+	}
+	output, err := SaveConversation(ctx, store, input, Deps{Confirm: approve, Redactor: redactor})
+	if err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+	content, err := store.Read(ctx, output.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"db1", "build7"} {
+		if strings.Contains(output.Path, leaked) || strings.Contains(string(content), leaked) {
+			t.Errorf("%s leaked into %s:\n%s", leaked, output.Path, content)
+		}
+	}
+	if output.Redaction == nil || len(output.Redaction.Findings) != 2 {
+		t.Errorf("Redaction = %+v, want findings for the title and tag", output.Redaction)
+	}
+}
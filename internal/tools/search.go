@@ -16,21 +16,39 @@ const (
 
 // SearchConversations searches conversations by content or metadata.
 func SearchConversations(ctx context.Context, store *storage.Storage, input SearchConversationsInput) (SearchConversationsOutput, error) {
+	input.Source = frontmatter.CanonicalSource(input.Source)
+
 	// Set default limit
 	limit := input.Limit
 	if limit <= 0 {
 		limit = defaultSearchLimit
 	}
 
-	// Get file list
-	var files []string
-	var err error
-
-	if input.Source != "" {
-		files, err = store.ListBySource(ctx, input.Source)
-	} else {
-		files, err = store.ListConversations(ctx)
+	if q := store.Querier(); q != nil {
+		hits, err := q.SearchConversations(ctx, storage.SearchQuery{
+			Folder: store.Folder(),
+			Source: input.Source,
+			Query:  input.Query,
+			Limit:  limit,
+		})
+		if err != nil {
+			return SearchConversationsOutput{}, fmt.Errorf("failed to search conversations: %w", err)
+		}
+		results := make([]SearchResult, 0, len(hits))
+		for _, hit := range hits {
+			results = append(results, SearchResult{
+				Path:    hit.Path,
+				Title:   hit.Title,
+				Snippet: hit.Snippet,
+				Score:   hit.Score,
+			})
+		}
+		return SearchConversationsOutput{Results: results, Total: len(results)}, nil
 	}
+
+	// Get file list. The source filter is applied to each file's
+	// frontmatter as it is read, so it works with any path layout.
+	files, err := store.ListConversations(ctx)
 	if err != nil {
 		return SearchConversationsOutput{}, fmt.Errorf("failed to list conversations: %w", err)
 	}
@@ -44,7 +62,7 @@ func SearchConversations(ctx context.Context, store *storage.Storage, input Sear
 			continue
 		}
 
-		result, found := searchFile(ctx, store, filePath, query)
+		result, found := searchFile(ctx, store, filePath, query, input.Source)
 		if found {
 			results = append(results, result)
 			if len(results) >= limit {
@@ -59,7 +77,7 @@ func SearchConversations(ctx context.Context, store *storage.Storage, input Sear
 	}, nil
 }
 
-func searchFile(ctx context.Context, store *storage.Storage, filePath, query string) (SearchResult, bool) {
+func searchFile(ctx context.Context, store *storage.Storage, filePath, query, source string) (SearchResult, bool) {
 	content, err := store.Read(ctx, filePath)
 	if err != nil {
 		return SearchResult{}, false
@@ -73,6 +91,9 @@ func searchFile(ctx context.Context, store *storage.Storage, filePath, query str
 
 	// Parse frontmatter for title
 	fm, body, _ := frontmatter.Parse(content)
+	if source != "" && (fm == nil || fm.Source != source) {
+		return SearchResult{}, false
+	}
 
 	title := ""
 	if fm != nil {
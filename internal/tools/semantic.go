@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/grokify/chathub/internal/conversation"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/semantic"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// semanticSnippetLength is the length of snippets in semantic search results.
+const semanticSnippetLength = 240
+
+// SemanticSearchInput is the input for the semantic_search tool.
+type SemanticSearchInput struct {
+	Query         string   `json:"query" jsonschema:"What to look for, in natural language"`
+	Source        string   `json:"source,omitempty" jsonschema:"Filter by source"`
+	Limit         int      `json:"limit,omitempty" jsonschema:"Max results (default 20)"`
+	KeywordWeight *float64 `json:"keyword_weight,omitempty" jsonschema:"Share of the keyword score in the ranking, from 0 (meaning only) to 1 (keywords only); default 0.3"`
+}
+
+// SemanticResult is a conversation matching a semantic search, with the
+// message that matched best.
+type SemanticResult struct {
+	Path          string  `json:"path"`
+	Title         string  `json:"title"`
+	Message       int     `json:"message" jsonschema:"Number of the best-matching message, for read_conversation start"`
+	Speaker       string  `json:"speaker,omitempty"`
+	Snippet       string  `json:"snippet" jsonschema:"Start of the best-matching message"`
+	Score         float64 `json:"score" jsonschema:"Combined relevance score"`
+	SemanticScore float64 `json:"semantic_score" jsonschema:"Similarity in meaning, from the embeddings"`
+	KeywordScore  float64 `json:"keyword_score" jsonschema:"BM25 keyword score, relative to the best match"`
+}
+
+// SemanticSearchOutput is the output for the semantic_search tool.
+type SemanticSearchOutput struct {
+	Results  []SemanticResult `json:"results"`
+	Total    int              `json:"total"`
+	Embedder string           `json:"embedder" jsonschema:"Embedder that computed the vectors"`
+	Chunks   int              `json:"chunks" jsonschema:"Number of indexed chunks"`
+	Skipped  []SkippedFile    `json:"skipped,omitempty" jsonschema:"Conversations left out because they could not be read"`
+}
+
+// SkippedFile is a conversation left out of a result, and why.
+type SkippedFile = storage.SkippedFile
+
+// SemanticSearch finds conversations related in meaning to a query, not
+// only those containing its words. Every conversation is indexed, and
+// only new or changed messages are embedded.
+func SemanticSearch(ctx context.Context, store *storage.Storage, index *semantic.Index, input SemanticSearchInput) (SemanticSearchOutput, error) {
+	input.Source = frontmatter.CanonicalSource(input.Source)
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	weight := semantic.DefaultKeywordWeight
+	if input.KeywordWeight != nil {
+		weight = *input.KeywordWeight
+	}
+
+	docs, skipped, err := loadDocuments(ctx, store)
+	if err != nil {
+		return SemanticSearchOutput{}, err
+	}
+
+	query := semantic.Query{Text: input.Query, KeywordWeight: weight, Limit: limit}
+	if input.Source != "" {
+		query.Filter = func(d semantic.Document) bool { return d.Source == input.Source }
+	}
+	hits, err := index.Search(ctx, docs, query)
+	if err != nil {
+		return SemanticSearchOutput{}, fmt.Errorf("semantic search failed: %w", err)
+	}
+
+	results := make([]SemanticResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, SemanticResult{
+			Path:          hit.Path,
+			Title:         hit.Title,
+			Message:       hit.Chunk.Message,
+			Speaker:       hit.Chunk.Speaker,
+			Snippet:       semanticSnippet(hit.Chunk),
+			Score:         roundScore(hit.Score),
+			SemanticScore: roundScore(hit.Semantic),
+			KeywordScore:  roundScore(hit.Keyword),
+		})
+	}
+	return SemanticSearchOutput{
+		Results:  results,
+		Total:    len(results),
+		Embedder: index.Embedder().Name(),
+		Chunks:   index.Len(),
+		Skipped:  skipped,
+	}, nil
+}
+
+// loadDocuments reads every conversation for indexing. Files that cannot
+// be read or parsed are skipped and returned with the error.
+func loadDocuments(ctx context.Context, store *storage.Storage) ([]semantic.Document, []SkippedFile, error) {
+	files, err := store.ListConversations(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	var docs []semantic.Document
+	var skipped []SkippedFile
+	for _, filePath := range files {
+		if !strings.HasSuffix(filePath, ".md") {
+			continue
+		}
+		content, err := store.Read(ctx, filePath)
+		if err != nil {
+			skipped = append(skipped, SkippedFile{Path: filePath, Error: err.Error()})
+			continue
+		}
+		fm, body, err := frontmatter.Parse(content)
+		if err != nil {
+			skipped = append(skipped, SkippedFile{Path: filePath, Error: err.Error()})
+			continue
+		}
+		doc := semantic.Document{Path: filePath, Body: body}
+		if fm != nil {
+			doc.Title, doc.Source = fm.Title, fm.Source
+		}
+		docs = append(docs, doc)
+	}
+	return docs, skipped, nil
+}
+
+// semanticSnippet returns the start of a chunk's text on one line,
+// without its speaker label.
+func semanticSnippet(c semantic.Chunk) string {
+	text := c.Text
+	if m := conversation.Messages([]byte(text)); len(m) > 0 && m[0].Speaker != "" {
+		text = m[0].Text
+	}
+	return conversation.Preview(strings.Join(strings.Fields(text), " "), semanticSnippetLength)
+}
+
+// roundScore rounds a score to four decimals.
+func roundScore(score float64) float64 {
+	return math.Round(score*1e4) / 1e4
+}
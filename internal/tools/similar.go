@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/grokify/chathub/internal/semantic"
+	"github.com/grokify/chathub/internal/similar"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// Defaults of the find_similar tool
+const (
+	defaultSimilarLimit = 10
+	defaultSimilarScore = 0.2
+)
+
+// Similarity methods
+const (
+	SimilarityMinHash = "minhash"        // shingle overlap only
+	SimilarityVector  = "minhash+vector" // shingle overlap and embeddings
+)
+
+// FindSimilarInput is the input for the find_similar tool.
+type FindSimilarInput struct {
+	Path     string   `json:"path" jsonschema:"Path to the conversation to compare against"`
+	Limit    int      `json:"limit,omitempty" jsonschema:"Max results (default 10)"`
+	MinScore *float64 `json:"min_score,omitempty" jsonschema:"Minimum similarity, from 0 to 1 (default 0.2)"`
+}
+
+// SimilarResult is a conversation similar to the one given.
+type SimilarResult struct {
+	Path            string   `json:"path"`
+	Title           string   `json:"title"`
+	Source          string   `json:"source,omitempty"`
+	Score           float64  `json:"score" jsonschema:"Similarity, the higher of the Jaccard and vector similarities"`
+	Jaccard         float64  `json:"jaccard" jsonschema:"Estimated overlap of word sequences (MinHash)"`
+	SimHashDistance int      `json:"simhash_distance" jsonschema:"SimHash Hamming distance, 0 to 64"`
+	Vector          *float64 `json:"vector,omitempty" jsonschema:"Cosine similarity of the conversations' embeddings"`
+	Duplicate       bool     `json:"duplicate" jsonschema:"Whether this is a near-duplicate, such as the same chat saved twice"`
+}
+
+// FindSimilarOutput is the output for the find_similar tool.
+type FindSimilarOutput struct {
+	Path    string          `json:"path"`
+	Results []SimilarResult `json:"results"`
+	Total   int             `json:"total"`
+	Method  string          `json:"method" jsonschema:"minhash, or minhash+vector when embeddings are available"`
+	Skipped []SkippedFile   `json:"skipped,omitempty" jsonschema:"Conversations left out because they could not be read"`
+}
+
+// FindSimilar returns the conversations nearest to a stored one. Near
+// duplicates are found by MinHash and SimHash fingerprints; with an index,
+// related conversations are also found by the similarity of their
+// embeddings.
+func FindSimilar(ctx context.Context, store *storage.Storage, index *semantic.Index, input FindSimilarInput) (FindSimilarOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultSimilarLimit
+	}
+	minScore := defaultSimilarScore
+	if input.MinScore != nil {
+		minScore = *input.MinScore
+	}
+
+	docs, skipped, err := loadDocuments(ctx, store)
+	if err != nil {
+		return FindSimilarOutput{}, err
+	}
+	target := slices.IndexFunc(docs, func(d semantic.Document) bool { return d.Path == input.Path })
+	if target < 0 {
+		if i := slices.IndexFunc(skipped, func(f SkippedFile) bool { return f.Path == input.Path }); i >= 0 {
+			return FindSimilarOutput{}, fmt.Errorf("failed to read %s: %s", input.Path, skipped[i].Error)
+		}
+		return FindSimilarOutput{}, fmt.Errorf("conversation not found: %s", input.Path)
+	}
+
+	output := FindSimilarOutput{Path: input.Path, Method: SimilarityMinHash, Skipped: skipped}
+	var vectors [][]float32
+	if index != nil {
+		if vectors, err = index.DocumentVectors(ctx, docs); err != nil {
+			return FindSimilarOutput{}, err
+		}
+		output.Method = SimilarityVector
+	}
+
+	fp := similar.NewFingerprint(docs[target].Body)
+	for i, doc := range docs {
+		if i == target {
+			continue
+		}
+		other := similar.NewFingerprint(doc.Body)
+		result := SimilarResult{
+			Path:            doc.Path,
+			Title:           doc.Title,
+			Source:          doc.Source,
+			Jaccard:         roundScore(similar.Jaccard(fp, other)),
+			SimHashDistance: similar.Distance(fp, other),
+			Duplicate:       similar.Duplicate(fp, other),
+		}
+		result.Score = result.Jaccard
+		if vectors != nil && vectors[target] != nil && vectors[i] != nil {
+			v := roundScore(semantic.Cosine(vectors[target], vectors[i]))
+			result.Vector = &v
+			result.Score = max(result.Score, v)
+		}
+		if result.Duplicate {
+			result.Score = max(result.Score, similar.DuplicateJaccard)
+		}
+		if result.Score >= minScore {
+			output.Results = append(output.Results, result)
+		}
+	}
+
+	slices.SortFunc(output.Results, func(a, b SimilarResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	if len(output.Results) > limit {
+		output.Results = output.Results[:limit]
+	}
+	output.Total = len(output.Results)
+	return output, nil
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grokify/omnistorage/backend/memory"
+
+	"github.com/grokify/chathub/internal/semantic"
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// mustSave saves content at a path relative to the storage folder.
+func mustSave(t *testing.T, store *storage.Storage, rel, content string) string {
+	t.Helper()
+	filePath := store.AbsPath(rel)
+	if err := store.Save(context.Background(), filePath, []byte(content)); err != nil {
+		t.Fatalf("Save(%s) error = %v", rel, err)
+	}
+	return filePath
+}
+
+func TestFindSimilar(t *testing.T) {
+	ctx := context.Background()
+	store := storage.New(memory.New(), "conversations")
+
+	body := "**User:** How do I rotate the signing keys for our OAuth provider without logging everyone out?\n\n" +
+		"**Assistant:** Publish the new key in the JWKS endpoint first, keep signing with the old key until every client has refreshed, then switch.\n"
+	claude := mustSave(t, store, "claude/keys.md", "---\ntitle: Key rotation\nsource: claude\n---\n"+body)
+	chatgpt := mustSave(t, store, "chatgpt/keys.md", "---\ntitle: Rotating keys\nsource: chatgpt\n---\n"+body+"**User:** Thanks!\n")
+	mustSave(t, store, "claude/bread.md", "---\ntitle: Bread\nsource: claude\n---\n**User:** What is a good sourdough hydration for a beginner?\n")
+	broken := mustSave(t, store, "claude/broken.md", "---\ntitle: [unclosed\n---\nbody\n")
+
+	output, err := FindSimilar(ctx, store, nil, FindSimilarInput{Path: claude})
+	if err != nil {
+		t.Fatalf("FindSimilar() error = %v", err)
+	}
+	if output.Method != SimilarityMinHash || output.Total != 1 {
+		t.Fatalf("FindSimilar() = %+v, want one result by minhash", output)
+	}
+	got := output.Results[0]
+	if got.Path != chatgpt || !got.Duplicate || got.Vector != nil || got.Score < 0.8 {
+		t.Errorf("result = %+v, want the ChatGPT copy flagged as a duplicate", got)
+	}
+	if len(output.Skipped) != 1 || output.Skipped[0].Path != broken {
+		t.Errorf("skipped = %+v, want the file with broken frontmatter", output.Skipped)
+	}
+
+	// With an index, embeddings also score the unrelated conversation
+	zero := 0.0
+	index := semantic.NewIndex(semantic.NewHashingEmbedder(0, nil), "", "")
+	output, err = FindSimilar(ctx, store, index, FindSimilarInput{Path: claude, MinScore: &zero})
+	if err != nil {
+		t.Fatalf("FindSimilar(index) error = %v", err)
+	}
+	if output.Method != SimilarityVector || output.Total != 2 || output.Results[0].Path != chatgpt {
+		t.Fatalf("FindSimilar(index) = %+v, want both conversations, the copy first", output)
+	}
+	for _, r := range output.Results {
+		if r.Vector == nil {
+			t.Errorf("result %s has no vector score", r.Path)
+		}
+	}
+
+	if _, err := FindSimilar(ctx, store, nil, FindSimilarInput{Path: store.AbsPath("missing.md")}); err == nil {
+		t.Error("FindSimilar(missing) succeeded")
+	}
+	if _, err := FindSimilar(ctx, store, nil, FindSimilarInput{Path: broken}); err == nil {
+		t.Error("FindSimilar(broken) succeeded")
+	}
+}
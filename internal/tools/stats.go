@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// defaultStatsTags is how many of the most used tags conversation_stats
+// reports by default.
+const defaultStatsTags = 10
+
+// ConversationStatsInput is the input for the conversation_stats tool.
+type ConversationStatsInput struct {
+	Source string `json:"source,omitempty" jsonschema:"Limit the statistics to one source platform"`
+	Tags   int    `json:"tags,omitempty" jsonschema:"Number of top tags to report (default 10, -1 for all)"`
+}
+
+// ConversationStatsOutput is the output for the conversation_stats tool.
+type ConversationStatsOutput struct {
+	storage.StatsResult
+}
+
+// ConversationStats reports counts by source, model, tag, category and
+// month, with message and token totals, over the whole store.
+func ConversationStats(ctx context.Context, store *storage.Storage, input ConversationStatsInput) (ConversationStatsOutput, error) {
+	tags := input.Tags
+	switch {
+	case tags == 0:
+		tags = defaultStatsTags
+	case tags < 0:
+		tags = 0
+	}
+	result, err := storage.Stats(ctx, store, storage.StatsOptions{Source: input.Source, Tags: tags})
+	if err != nil {
+		return ConversationStatsOutput{}, fmt.Errorf("failed to compute statistics: %w", err)
+	}
+	return ConversationStatsOutput{StatsResult: *result}, nil
+}
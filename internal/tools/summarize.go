@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/storage"
+	"github.com/grokify/chathub/internal/summary"
+)
+
+// Sampling limits
+const (
+	samplingMaxTokens = 300              // length of a sampled summary
+	samplingTimeout   = 60 * time.Second // wait for the client's model
+)
+
+// SummarizeConversationInput is the input for the summarize_conversation tool.
+type SummarizeConversationInput struct {
+	Path       string `json:"path" jsonschema:"Path to the conversation"`
+	Extractive bool   `json:"extractive,omitempty" jsonschema:"Summarize without asking the client's model"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Return the summary without saving it"`
+}
+
+// SummarizeConversationOutput is the output for the summarize_conversation tool.
+type SummarizeConversationOutput struct {
+	Path    string          `json:"path"`
+	Summary summary.Summary `json:"summary"`
+	Tags    []string        `json:"tags,omitempty" jsonschema:"Tags after merging in the suggested ones"`
+	Saved   bool            `json:"saved" jsonschema:"Whether the description and tags were written to the frontmatter"`
+	Queued  *QueuedWrite    `json:"queued,omitempty" jsonschema:"Set if the backend was unavailable and the write was queued for delivery"`
+}
+
+// SummarizeFunc writes a description and suggests tags for a conversation.
+type SummarizeFunc func(ctx context.Context, title string, body []byte) summary.Summary
+
+// NewSessionSummarizer returns a SummarizeFunc that asks the client's
+// model through MCP sampling in summary.ModeAuto, falling back to the
+// extractive summary if the client does not support sampling or the
+// request fails or times out. It returns nil for summary.ModeOff.
+func NewSessionSummarizer(session *mcp.ServerSession, mode summary.Mode) SummarizeFunc {
+	switch mode {
+	case summary.ModeOff:
+		return nil
+	case summary.ModeExtractive:
+		return func(ctx context.Context, title string, body []byte) summary.Summary {
+			return summary.Extractive(body)
+		}
+	}
+	return func(ctx context.Context, title string, body []byte) summary.Summary {
+		if !supportsSampling(session) {
+			return summary.Extractive(body)
+		}
+		// A client that never answers must not hang the save
+		ctx, cancel := context.WithTimeout(ctx, samplingTimeout)
+		defer cancel()
+		result, err := session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			SystemPrompt: summary.SystemPrompt,
+			Messages: []*mcp.SamplingMessage{{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: summary.Prompt(title, body)},
+			}},
+			MaxTokens: samplingMaxTokens,
+			ModelPreferences: &mcp.ModelPreferences{
+				CostPriority:  0.8,
+				SpeedPriority: 0.8,
+			},
+		})
+		if err != nil {
+			return summary.Extractive(body)
+		}
+		text, ok := result.Content.(*mcp.TextContent)
+		if !ok {
+			return summary.Extractive(body)
+		}
+		s, err := summary.ParseResponse(text.Text)
+		if err != nil {
+			return summary.Extractive(body)
+		}
+		s.Model = result.Model
+		return s
+	}
+}
+
+func supportsSampling(session *mcp.ServerSession) bool {
+	if session == nil {
+		return false
+	}
+	params := session.InitializeParams()
+	return params != nil && params.Capabilities != nil && params.Capabilities.Sampling != nil
+}
+
+// SummarizeConversation summarizes a stored conversation and writes the
+// description and suggested tags to its frontmatter. Existing tags are
+// kept, and new ones added after them.
+func SummarizeConversation(ctx context.Context, store *storage.Storage, input SummarizeConversationInput, summarize SummarizeFunc) (SummarizeConversationOutput, error) {
+	content, err := store.Read(ctx, input.Path)
+	if err != nil {
+		return SummarizeConversationOutput{}, fmt.Errorf("failed to read conversation: %w", err)
+	}
+	fm, body, err := frontmatter.Parse(content)
+	if err != nil {
+		return SummarizeConversationOutput{}, fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+	if fm == nil {
+		return SummarizeConversationOutput{}, fmt.Errorf("%s has no frontmatter to store a summary in", input.Path)
+	}
+
+	s := summarize(ctx, fm.Title, body)
+	tags := slices.Clone(fm.Tags)
+	for _, tag := range s.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	output := SummarizeConversationOutput{Path: input.Path, Summary: s, Tags: tags}
+	if input.DryRun || s.Description == "" {
+		return output, nil
+	}
+
+	fm.Description = s.Description
+	fm.Tags = tags
+	fm.LastMod = time.Now().UTC()
+	updated, err := fm.RenderWithContent(body)
+	if err != nil {
+		return SummarizeConversationOutput{}, fmt.Errorf("failed to render: %w", err)
+	}
+	if output.Queued, err = queuedWrite(store.SaveVersioned(ctx, input.Path, updated)); err != nil {
+		return SummarizeConversationOutput{}, fmt.Errorf("failed to save: %w", err)
+	}
+	output.Saved = true
+	return output, nil
+}
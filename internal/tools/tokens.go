@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"github.com/grokify/chathub/internal/conversation"
+	"github.com/grokify/chathub/internal/frontmatter"
+	"github.com/grokify/chathub/internal/tokens"
+)
+
+// countTokens sets the token count, tokenizer, estimated cost and message
+// count of fm from the conversation body.
+func countTokens(fm *frontmatter.Frontmatter, body []byte) *tokens.Usage {
+	usage := tokens.Estimate(body, fm.Model)
+	fm.Tokens = usage.Total
+	fm.Tokenizer = string(usage.Tokenizer)
+	fm.Cost = usage.Cost
+
+	if messages := conversation.Count(conversation.Messages(body)); messages > 0 {
+		fm.MessageCount = messages
+	}
+	return &usage
+}
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// ListTrashInput is the input for the list_trash tool.
+type ListTrashInput struct{}
+
+// TrashSummary represents a trashed conversation in list results.
+type TrashSummary struct {
+	ID           string `json:"id"`
+	OriginalPath string `json:"original_path"`
+	DeletedAt    string `json:"deleted_at"`
+	ExpiresAt    string `json:"expires_at,omitempty" jsonschema:"When the item will be purged"`
+}
+
+// ListTrashOutput is the output for the list_trash tool.
+type ListTrashOutput struct {
+	Items []TrashSummary `json:"items"`
+	Total int            `json:"total"`
+}
+
+// RestoreConversationInput is the input for the restore_conversation tool.
+type RestoreConversationInput struct {
+	ID   string `json:"id" jsonschema:"Trash ID returned by delete_conversation or list_trash"`
+	Path string `json:"path,omitempty" jsonschema:"Restore to this path instead of the original path"`
+}
+
+// RestoreConversationOutput is the output for the restore_conversation tool.
+type RestoreConversationOutput struct {
+	Restored bool   `json:"restored"`
+	Path     string `json:"path,omitempty" jsonschema:"Restored file path"`
+	Message  string `json:"message,omitempty"`
+}
+
+// EmptyTrashInput is the input for the empty_trash tool.
+type EmptyTrashInput struct {
+	IDs         []string `json:"ids,omitempty" jsonschema:"Only purge these trash IDs"`
+	ExpiredOnly bool     `json:"expired_only,omitempty" jsonschema:"Only purge items past the retention period"`
+}
+
+// EmptyTrashOutput is the output for the empty_trash tool.
+type EmptyTrashOutput struct {
+	Purged  []string `json:"purged" jsonschema:"Original paths of permanently deleted conversations"`
+	Total   int      `json:"total"`
+	Message string   `json:"message,omitempty"`
+}
+
+// ListTrash lists conversations in the trash.
+func ListTrash(ctx context.Context, store *storage.Storage, _ ListTrashInput) (ListTrashOutput, error) {
+	items, err := store.ListTrash(ctx)
+	if err != nil {
+		return ListTrashOutput{}, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	summaries := make([]TrashSummary, 0, len(items))
+	for _, item := range items {
+		summary := TrashSummary{
+			ID:           item.ID,
+			OriginalPath: item.OriginalPath,
+			DeletedAt:    item.DeletedAt.Format(time.RFC3339),
+		}
+		if expires := item.ExpiresAt(store.TrashRetention()); !expires.IsZero() {
+			summary.ExpiresAt = expires.Format(time.RFC3339)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return ListTrashOutput{
+		Items: summaries,
+		Total: len(summaries),
+	}, nil
+}
+
+// RestoreConversation restores a conversation from the trash.
+func RestoreConversation(ctx context.Context, store *storage.Storage, input RestoreConversationInput) (RestoreConversationOutput, error) {
+	restoredPath, err := store.Restore(ctx, input.ID, input.Path)
+	if err != nil {
+		return RestoreConversationOutput{}, fmt.Errorf("failed to restore conversation: %w", err)
+	}
+
+	return RestoreConversationOutput{
+		Restored: true,
+		Path:     restoredPath,
+		Message:  fmt.Sprintf("restored: %s", restoredPath),
+	}, nil
+}
+
+// EmptyTrash permanently deletes conversations from the trash.
+// If confirmFn is non-nil, the user must approve the purge first.
+func EmptyTrash(ctx context.Context, store *storage.Storage, input EmptyTrashInput, confirmFn ConfirmFunc) (EmptyTrashOutput, error) {
+	// Collect the items to purge
+	var targets []storage.TrashItem
+	if len(input.IDs) > 0 {
+		for _, id := range input.IDs {
+			item, err := store.GetTrashItem(ctx, id)
+			if err != nil {
+				return EmptyTrashOutput{}, fmt.Errorf("failed to purge %s: %w", id, err)
+			}
+			targets = append(targets, item)
+		}
+	} else {
+		all, err := store.ListTrash(ctx)
+		if err != nil {
+			return EmptyTrashOutput{}, fmt.Errorf("failed to list trash: %w", err)
+		}
+		now := time.Now()
+		for _, item := range all {
+			if input.ExpiredOnly {
+				expires := item.ExpiresAt(store.TrashRetention())
+				if expires.IsZero() || expires.After(now) {
+					continue
+				}
+			}
+			targets = append(targets, item)
+		}
+	}
+
+	if len(targets) == 0 {
+		return EmptyTrashOutput{Purged: []string{}, Message: "nothing to purge"}, nil
+	}
+
+	// Ask the user to confirm
+	confirmItems := make([]ConfirmItem, 0, len(targets))
+	for _, item := range targets {
+		ci := describeConversation(ctx, store, item.TrashPath)
+		ci.Path = item.OriginalPath
+		confirmItems = append(confirmItems, ci)
+	}
+	confirmation, err := confirm(ctx, confirmFn, ConfirmRequest{
+		Action: fmt.Sprintf("Permanently delete %d conversation(s) from the trash", len(targets)),
+		Items:  confirmItems,
+	})
+	if err != nil {
+		return EmptyTrashOutput{}, err
+	}
+	if !confirmation.Approved {
+		return EmptyTrashOutput{
+			Purged:  []string{},
+			Message: fmt.Sprintf("trash not emptied: %s", confirmation.Reason),
+		}, nil
+	}
+
+	// Purge
+	var items []storage.TrashItem
+	for _, target := range targets {
+		item, err := store.DeleteTrashItem(ctx, target.ID)
+		if err != nil {
+			return EmptyTrashOutput{}, fmt.Errorf("failed to purge %s: %w", target.ID, err)
+		}
+		items = append(items, item)
+	}
+
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		paths = append(paths, item.OriginalPath)
+	}
+
+	return EmptyTrashOutput{
+		Purged: paths,
+		Total:  len(paths),
+	}, nil
+}
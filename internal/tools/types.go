@@ -1,36 +1,79 @@
 // Package tools provides MCP tool implementations for ChatHub.
 package tools
 
+import (
+	"github.com/grokify/chathub/internal/summary"
+	"github.com/grokify/chathub/internal/tokens"
+)
+
 // SaveConversationInput is the input for the save_conversation tool.
 type SaveConversationInput struct {
 	Title       string   `json:"title" jsonschema:"Conversation title"`
 	Content     string   `json:"content" jsonschema:"Full conversation in Markdown"`
-	Source      string   `json:"source" jsonschema:"Source platform (chatgpt/claude/gemini/perplexity/codex/claude-code)"`
+	Source      string   `json:"source" jsonschema:"Source platform ID or name: chatgpt, claude, claude-code, gemini, perplexity, codex, or a source configured in CHATHUB_SOURCES"`
 	Tags        []string `json:"tags,omitempty" jsonschema:"Tags for categorization"`
 	Categories  []string `json:"categories,omitempty" jsonschema:"Categories for organization"`
-	Description string   `json:"description,omitempty" jsonschema:"Brief summary"`
+	Description string   `json:"description,omitempty" jsonschema:"Brief summary; generated from the content if omitted"`
+	Model       string   `json:"model,omitempty" jsonschema:"AI model used, e.g. gpt-4o or claude-sonnet-4; used to pick the tokenizer and estimate the cost"`
 }
 
 // SaveConversationOutput is the output for the save_conversation tool.
 type SaveConversationOutput struct {
-	Path           string `json:"path" jsonschema:"Saved file path"`
-	ConversationID string `json:"conversation_id" jsonschema:"Unique conversation ID"`
+	Path           string           `json:"path" jsonschema:"Saved file path"`
+	ConversationID string           `json:"conversation_id" jsonschema:"Unique conversation ID"`
+	Tokens         *tokens.Usage    `json:"tokens,omitempty" jsonschema:"Token counts per message and in total, and the estimated cost"`
+	Summary        *summary.Summary `json:"summary,omitempty" jsonschema:"Generated description and suggested tags, if no description was given"`
+	Redaction      *RedactionReport `json:"redaction,omitempty" jsonschema:"Sensitive content found in the input"`
+	Queued         *QueuedWrite     `json:"queued,omitempty" jsonschema:"Set if the backend was unavailable and the write was queued for delivery"`
 }
 
 // ReadConversationInput is the input for the read_conversation tool.
+// Without options the whole file is returned. With any of them, content
+// is the selected part of the body, without frontmatter.
 type ReadConversationInput struct {
-	Path string `json:"path" jsonschema:"Full path to conversation"`
+	Path      string `json:"path" jsonschema:"Full path to conversation"`
+	Start     int    `json:"start,omitempty" jsonschema:"First message to return, counting from 1"`
+	End       int    `json:"end,omitempty" jsonschema:"Last message to return, inclusive"`
+	Last      int    `json:"last,omitempty" jsonschema:"Return only the last N messages"`
+	MaxBytes  int    `json:"max_bytes,omitempty" jsonschema:"Return at most this many bytes of content, continuing with next_cursor"`
+	MaxTokens int    `json:"max_tokens,omitempty" jsonschema:"Return at most this many tokens of content, continuing with next_cursor"`
+	Cursor    string `json:"cursor,omitempty" jsonschema:"next_cursor from a previous call, to continue where it stopped"`
+	Outline   bool   `json:"outline,omitempty" jsonschema:"Return the headings and a one-line preview of each message instead of content"`
 }
 
 // ReadConversationOutput is the output for the read_conversation tool.
 type ReadConversationOutput struct {
-	Content     string            `json:"content" jsonschema:"Full Markdown content"`
-	Title       string            `json:"title" jsonschema:"Conversation title"`
-	Date        string            `json:"date" jsonschema:"Creation date"`
-	Source      string            `json:"source" jsonschema:"Source platform"`
-	Tags        []string          `json:"tags,omitempty" jsonschema:"Tags"`
-	Description string            `json:"description,omitempty" jsonschema:"Brief summary"`
-	Metadata    map[string]string `json:"metadata,omitempty" jsonschema:"Additional metadata"`
+	Content     string         `json:"content" jsonschema:"Full Markdown content"`
+	Title       string         `json:"title" jsonschema:"Conversation title"`
+	Date        string         `json:"date" jsonschema:"Creation date"`
+	Source      string         `json:"source" jsonschema:"Source platform"`
+	Tags        []string       `json:"tags,omitempty" jsonschema:"Tags"`
+	Description string         `json:"description,omitempty" jsonschema:"Brief summary"`
+	Metadata    map[string]any `json:"metadata,omitempty" jsonschema:"Additional metadata, including custom frontmatter fields"`
+	Window      *ReadWindow    `json:"window,omitempty" jsonschema:"The part of the conversation returned, when reading a window"`
+	Outline     []OutlineEntry `json:"outline,omitempty" jsonschema:"One entry per message, in outline mode"`
+	NextCursor  string         `json:"next_cursor,omitempty" jsonschema:"Pass as cursor to read the rest of the window"`
+}
+
+// ReadWindow describes the part of a conversation read_conversation
+// returned. Message 0 is text before the first message, such as a title.
+type ReadWindow struct {
+	FirstMessage  int  `json:"first_message"`
+	LastMessage   int  `json:"last_message"`
+	TotalMessages int  `json:"total_messages"`
+	Bytes         int  `json:"bytes"`
+	Tokens        int  `json:"tokens,omitempty"`
+	Partial       bool `json:"partial,omitempty" jsonschema:"The last message is cut off"`
+}
+
+// OutlineEntry summarizes one message.
+type OutlineEntry struct {
+	Message  int      `json:"message"`
+	Speaker  string   `json:"speaker,omitempty"`
+	Preview  string   `json:"preview"`
+	Headings []string `json:"headings,omitempty"`
+	Bytes    int      `json:"bytes"`
+	Tokens   int      `json:"tokens"`
 }
 
 // ListConversationsInput is the input for the list_conversations tool.
@@ -48,6 +91,9 @@ type ConversationSummary struct {
 	Source      string   `json:"source"`
 	Tags        []string `json:"tags,omitempty"`
 	Description string   `json:"description,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Tokens      int      `json:"tokens,omitempty" jsonschema:"Token count of the body"`
+	Cost        float64  `json:"cost,omitempty" jsonschema:"Estimated cost in USD of generating the conversation"`
 }
 
 // ListConversationsOutput is the output for the list_conversations tool.
@@ -55,6 +101,7 @@ type ListConversationsOutput struct {
 	Conversations []ConversationSummary `json:"conversations"`
 	Total         int                   `json:"total"`
 	HasMore       bool                  `json:"has_more"`
+	Skipped       []SkippedFile         `json:"skipped,omitempty" jsonschema:"Conversations left out of a source filter because they could not be read or parsed"`
 }
 
 // SearchConversationsInput is the input for the search_conversations tool.
@@ -86,5 +133,6 @@ type DeleteConversationInput struct {
 // DeleteConversationOutput is the output for the delete_conversation tool.
 type DeleteConversationOutput struct {
 	Deleted bool   `json:"deleted"`
+	TrashID string `json:"trash_id,omitempty" jsonschema:"Trash ID for restore_conversation"`
 	Message string `json:"message,omitempty"`
 }
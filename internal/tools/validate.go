@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grokify/chathub/internal/storage"
+)
+
+// ValidateConversationInput is the input for the validate_conversation tool.
+type ValidateConversationInput struct {
+	Path string `json:"path,omitempty" jsonschema:"Conversation to validate; omit to validate every conversation"`
+}
+
+// ValidateConversationOutput is the output for the validate_conversation tool.
+type ValidateConversationOutput struct {
+	Valid    bool               `json:"valid" jsonschema:"True if no errors were found"`
+	Checked  int                `json:"checked" jsonschema:"Number of conversations checked"`
+	Errors   int                `json:"errors"`
+	Warnings int                `json:"warnings"`
+	Files    []storage.LintFile `json:"files,omitempty" jsonschema:"Conversations with issues"`
+}
+
+// ValidateConversation checks conversation frontmatter against the schema,
+// Hugo's requirements and, across the store, for duplicate IDs.
+func ValidateConversation(ctx context.Context, store *storage.Storage, input ValidateConversationInput) (ValidateConversationOutput, error) {
+	var opts storage.LintOptions
+	if input.Path != "" {
+		opts.Paths = []string{input.Path}
+	}
+
+	result, err := storage.Lint(ctx, store, opts)
+	if err != nil {
+		return ValidateConversationOutput{}, fmt.Errorf("failed to validate: %w", err)
+	}
+
+	return ValidateConversationOutput{
+		Valid:    result.Errors == 0,
+		Checked:  result.Checked,
+		Errors:   result.Errors,
+		Warnings: result.Warnings,
+		Files:    result.Files,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/grokify/chathub/internal/conversation"
+	"github.com/grokify/chathub/internal/tokens"
+)
+
+// outlinePreviewLength is the length of message previews in outlines.
+const outlinePreviewLength = 120
+
+// windowed reports whether the input asks for part of the conversation.
+func (in ReadConversationInput) windowed() bool {
+	return in.Start != 0 || in.End != 0 || in.Last != 0 || in.MaxBytes != 0 ||
+		in.MaxTokens != 0 || in.Cursor != "" || in.Outline
+}
+
+// readCursor is where a budgeted read stopped. Sum is a checksum of the
+// body before Offset, so a cursor is rejected if that part changed, while
+// appends keep it valid.
+type readCursor struct {
+	Offset int    `json:"o"`
+	End    int    `json:"e"`
+	Sum    uint32 `json:"s"`
+}
+
+func encodeCursor(body []byte, offset, end int) string {
+	data, _ := json.Marshal(readCursor{Offset: offset, End: end, Sum: crc32.ChecksumIEEE(body[:offset])})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(body []byte, cursor string) (readCursor, error) {
+	var c readCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Offset < 0 || c.Offset > c.End {
+		return c, errors.New("invalid cursor")
+	}
+	if c.End > len(body) || crc32.ChecksumIEEE(body[:c.Offset]) != c.Sum {
+		return c, errors.New("cursor is stale: the conversation changed since it was issued")
+	}
+	return c, nil
+}
+
+// readWindow fills in the content, window, outline and next cursor of a
+// windowed read.
+func readWindow(output *ReadConversationOutput, body []byte, model string, input ReadConversationInput) error {
+	if input.Start < 0 || input.End < 0 || input.Last < 0 || input.MaxBytes < 0 || input.MaxTokens < 0 {
+		return errors.New("start, end, last, max_bytes and max_tokens must not be negative")
+	}
+
+	messages := conversation.Messages(body)
+	total := conversation.Count(messages)
+
+	// Messages are numbered from 1; text before the first one is 0
+	base := 1
+	if len(messages) > 0 && messages[0].Speaker == "" {
+		base = 0
+	}
+	number := func(offset int) int {
+		for i, m := range messages {
+			if offset < m.End {
+				return i + base
+			}
+		}
+		return len(messages) - 1 + base
+	}
+
+	// A body without messages is blank, and its window empty
+	start, end := 0, 0
+	if len(messages) > 0 {
+		start, end = messages[0].Start, messages[len(messages)-1].End
+	}
+	switch {
+	case input.Cursor != "":
+		if input.Start != 0 || input.End != 0 || input.Last != 0 || input.Outline {
+			return errors.New("cursor cannot be combined with start, end, last or outline")
+		}
+		c, err := decodeCursor(body, input.Cursor)
+		if err != nil {
+			return err
+		}
+		start, end = c.Offset, c.End
+	case input.Last != 0:
+		if input.Start != 0 || input.End != 0 {
+			return errors.New("last cannot be combined with start or end")
+		}
+		if input.Last < total {
+			start = messages[len(messages)-input.Last].Start
+		} else if total > 0 {
+			start = messages[1-base].Start
+		}
+	case input.Start != 0 || input.End != 0:
+		if input.Start > total {
+			return fmt.Errorf("start %d is past the last message (%d)", input.Start, total)
+		}
+		if input.End != 0 && input.End < input.Start {
+			return fmt.Errorf("end %d is before start %d", input.End, input.Start)
+		}
+		if input.Start > 0 {
+			start = messages[input.Start-base].Start
+		}
+		if input.End != 0 && input.End < total {
+			end = messages[input.End-base].End
+		}
+	}
+
+	var counter *tokens.Counter
+	if input.MaxTokens > 0 || input.Outline {
+		counter = tokens.NewCounter(tokens.Default(), model)
+	}
+
+	output.Content = ""
+	output.Window = &ReadWindow{
+		FirstMessage:  number(start),
+		LastMessage:   number(max(end-1, start)),
+		TotalMessages: total,
+	}
+
+	if input.Outline {
+		for i, m := range messages {
+			if m.End <= start || m.Start >= end {
+				continue
+			}
+			output.Outline = append(output.Outline, OutlineEntry{
+				Message:  i + base,
+				Speaker:  m.Speaker,
+				Preview:  conversation.Preview(m.Text, outlinePreviewLength),
+				Headings: conversation.Headings(m.Text),
+				Bytes:    m.End - m.Start,
+				Tokens:   counter.Count(m.Text),
+			})
+		}
+		return nil
+	}
+
+	budget := conversation.Budget{MaxBytes: input.MaxBytes, MaxTokens: input.MaxTokens}
+	if input.MaxTokens > 0 {
+		budget.Count = counter.Count
+	}
+	cut, n, err := conversation.Fit(body, messages, start, end, budget)
+	if err != nil {
+		return fmt.Errorf("max_bytes or max_tokens: %w", err)
+	}
+
+	output.Content = string(body[start:cut])
+	output.Window.LastMessage = number(max(cut-1, start))
+	output.Window.Bytes = cut - start
+	output.Window.Tokens = n
+	if cut < end {
+		output.NextCursor = encodeCursor(body, cut, end)
+		for _, m := range messages {
+			if cut > m.Start && cut < m.End {
+				output.Window.Partial = true
+			}
+		}
+	}
+	return nil
+}
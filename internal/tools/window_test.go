@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+const windowBody = "**User:** How do I rotate keys?\n\n" +
+	"**Claude:** Publish the new key first.\nThen switch signing.\n\n" +
+	"**User:** And refresh tokens?\n"
+
+func TestReadWindowRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       ReadConversationInput
+		first, last int
+		wantErr     bool
+	}{
+		{"start and end", ReadConversationInput{Start: 2, End: 3}, 2, 3, false},
+		{"start only", ReadConversationInput{Start: 3}, 3, 3, false},
+		{"end only", ReadConversationInput{End: 1}, 1, 1, false},
+		{"end past the last message", ReadConversationInput{Start: 1, End: 10}, 1, 3, false},
+		{"last", ReadConversationInput{Last: 2}, 2, 3, false},
+		{"last over the total", ReadConversationInput{Last: 10}, 1, 3, false},
+		{"start past the last message", ReadConversationInput{Start: 4}, 0, 0, true},
+		{"end before start", ReadConversationInput{Start: 3, End: 2}, 0, 0, true},
+		{"negative start", ReadConversationInput{Start: -1}, 0, 0, true},
+		{"last with start", ReadConversationInput{Start: 1, Last: 1}, 0, 0, true},
+		{"cursor with start", ReadConversationInput{Start: 1, Cursor: "x"}, 0, 0, true},
+		{"invalid cursor", ReadConversationInput{Cursor: "x"}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output ReadConversationOutput
+			err := readWindow(&output, []byte(windowBody), "", tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if output.Window.FirstMessage != tt.first || output.Window.LastMessage != tt.last || output.Window.TotalMessages != 3 {
+				t.Errorf("window = %+v, want messages %d to %d of 3", output.Window, tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestReadWindowCursor(t *testing.T) {
+	second, third := strings.Index(windowBody, "**Claude:**"), strings.LastIndex(windowBody, "**User:**")
+	tests := []struct {
+		name  string
+		input ReadConversationInput
+		want  string
+	}{
+		{"whole conversation", ReadConversationInput{MaxBytes: 30}, windowBody},
+		{"one byte at a time", ReadConversationInput{MaxBytes: 1}, windowBody},
+		{"range", ReadConversationInput{Start: 2, End: 2, MaxBytes: 25}, windowBody[second:third]},
+		{"tokens", ReadConversationInput{Last: 2, MaxTokens: 5}, windowBody[second:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			input := tt.input
+			for reads := 0; ; reads++ {
+				if reads > len(windowBody) {
+					t.Fatal("cursor does not advance")
+				}
+				var output ReadConversationOutput
+				if err := readWindow(&output, []byte(windowBody), "", input); err != nil {
+					t.Fatalf("readWindow() error = %v", err)
+				}
+				got.WriteString(output.Content)
+				if output.NextCursor == "" {
+					break
+				}
+				input = ReadConversationInput{MaxBytes: tt.input.MaxBytes, MaxTokens: tt.input.MaxTokens, Cursor: output.NextCursor}
+			}
+			if got.String() != tt.want {
+				t.Errorf("reads joined = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWindowCursorStale(t *testing.T) {
+	var output ReadConversationOutput
+	if err := readWindow(&output, []byte(windowBody), "", ReadConversationInput{MaxBytes: 40}); err != nil {
+		t.Fatal(err)
+	}
+	if output.NextCursor == "" {
+		t.Fatal("no cursor for a partial read")
+	}
+
+	// Appending keeps the cursor valid; editing what was read does not
+	appended := windowBody + "\n**Claude:** Rotate them too.\n"
+	if err := readWindow(&output, []byte(appended), "", ReadConversationInput{Cursor: output.NextCursor}); err != nil {
+		t.Errorf("readWindow() after an append error = %v", err)
+	}
+	edited := strings.Replace(windowBody, "rotate", "roll", 1)
+	if err := readWindow(&output, []byte(edited), "", ReadConversationInput{Cursor: output.NextCursor}); err == nil {
+		t.Error("readWindow() accepted a cursor into a changed conversation")
+	}
+}
+
+func TestReadWindowBlank(t *testing.T) {
+	for _, body := range []string{"", " \n\n\t\n"} {
+		var output ReadConversationOutput
+		if err := readWindow(&output, []byte(body), "", ReadConversationInput{MaxBytes: 10}); err != nil {
+			t.Errorf("readWindow(%q) error = %v", body, err)
+			continue
+		}
+		if output.Content != "" || output.NextCursor != "" || output.Window.TotalMessages != 0 {
+			t.Errorf("readWindow(%q) = %+v, want an empty window", body, output)
+		}
+	}
+}